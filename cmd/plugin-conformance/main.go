@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"maschine.io/plugin-sdk/sdk/conformance"
+	"maschine.io/plugin-sdk/sdk/manifest"
+)
+
+func main() {
+	var (
+		manifestPath    = flag.String("manifest", "", "Path to manifest file (default: manifest next to the plugin binary)")
+		credentialsPath = flag.String("credentials", "", "Path to a JSON file with the credentials for the examples")
+		dryRun          = flag.Bool("dry-run", false, "Run the examples of actions as dry runs")
+		concurrency     = flag.Int("concurrency", conformance.DefaultConcurrency, "Concurrent callers of the concurrency check")
+		shutdownTimeout = flag.Duration("shutdown-timeout", conformance.DefaultShutdownTimeout, "How long the plugin may drain on shutdown")
+		verbose         = flag.Bool("v", false, "Print the logs of the plugin")
+	)
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Maschine Plugin Conformance\n\n")
+		fmt.Fprintf(os.Stderr, "Certifies a plugin binary against its manifest\n\n")
+		fmt.Fprintf(os.Stderr, "Usage: %s [options] <plugin binary> [plugin args...]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExample:\n")
+		fmt.Fprintf(os.Stderr, "  %s -credentials creds.json -dry-run ./dist/mail-plugin\n\n", os.Args[0])
+	}
+
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		fmt.Fprintf(os.Stderr, "Error: plugin binary is required\n\n")
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg := conformance.Config{
+		Path:            flag.Arg(0),
+		Args:            flag.Args()[1:],
+		DryRun:          *dryRun,
+		Concurrency:     *concurrency,
+		ShutdownTimeout: *shutdownTimeout,
+	}
+	if *manifestPath != "" {
+		m, err := manifest.Load(*manifestPath)
+		if err != nil {
+			log.Fatalf("Failed to load manifest: %v", err)
+		}
+		cfg.Manifest = m
+	}
+	if *credentialsPath != "" {
+		data, err := os.ReadFile(*credentialsPath)
+		if err != nil {
+			log.Fatalf("Failed to read credentials: %v", err)
+		}
+		if err := json.Unmarshal(data, &cfg.Credentials); err != nil {
+			log.Fatalf("Failed to parse credentials: %v", err)
+		}
+	}
+	if *verbose {
+		cfg.Logger = hclog.New(&hclog.LoggerOptions{
+			Name:   "plugin-conformance",
+			Output: os.Stderr,
+			Level:  hclog.Debug,
+		})
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	start := time.Now()
+	report := conformance.Run(ctx, cfg)
+	if err := report.Write(os.Stdout); err != nil {
+		log.Fatalf("Failed to write report: %v", err)
+	}
+	fmt.Printf("Finished in %s\n", time.Since(start).Round(time.Millisecond))
+
+	if !report.Passed() {
+		os.Exit(1)
+	}
+}
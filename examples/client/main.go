@@ -30,6 +30,7 @@ func main() {
 		AllowedProtocols: []plugin.Protocol{
 			plugin.ProtocolGRPC,
 		},
+		AutoMTLS: true,
 	})
 	defer client.Kill()
 	
@@ -58,6 +59,19 @@ func main() {
 	fmt.Printf("Resources: %v\n", metadata.SupportedResources)
 	fmt.Printf("Capabilities: %v\n", metadata.Capabilities)
 	
+	// Test GetManifest
+	fmt.Println("\n=== Testing GetManifest ===")
+	if provider, ok := maschinePlugin.(sdk.ManifestProvider); ok {
+		m, err := provider.GetManifest(context.Background())
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Manifest: %s v%s\n", m.Plugin.ID, m.Plugin.Version)
+		for _, r := range m.Resources {
+			fmt.Printf("  %s (%s): %d parameters\n", r.Type, r.Category, len(r.Parameters))
+		}
+	}
+	
 	// Test HealthCheck
 	fmt.Println("\n=== Testing HealthCheck ===")
 	health, err := maschinePlugin.HealthCheck(context.Background(), &sdk.HealthCheckRequest{})
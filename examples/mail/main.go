@@ -2,19 +2,16 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"os"
 	
-	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-plugin"
 	sdk "maschine.io/plugin-sdk/sdk"
+	"maschine.io/plugin-sdk/sdk/logger"
+	"maschine.io/plugin-sdk/sdk/manifest"
 )
 
 // MailPlugin is our implementation of the MaschineResource interface
-type MailPlugin struct {
-	logger hclog.Logger
-}
+type MailPlugin struct{}
 
 // GetMetadata returns plugin metadata
 func (p *MailPlugin) GetMetadata(ctx context.Context, req *sdk.GetMetadataRequest) (*sdk.GetMetadataResponse, error) {
@@ -34,7 +31,9 @@ func (p *MailPlugin) GetMetadata(ctx context.Context, req *sdk.GetMetadataReques
 
 // Execute runs a plugin command
 func (p *MailPlugin) Execute(ctx context.Context, req *sdk.ExecuteRequest) (*sdk.ExecuteResponse, error) {
-	p.logger.Info("executing resource", "resource", req.Resource)
+	// The logger of the execution tags every line with the resource and
+	// the execution ID, the host adds the plugin ID
+	logger.FromContext(ctx).Info("executing resource")
 	
 	switch req.Resource {
 	case "mrn:mail:smtp:send":
@@ -57,43 +56,29 @@ func (p *MailPlugin) HealthCheck(ctx context.Context, req *sdk.HealthCheckReques
 }
 
 func (p *MailPlugin) sendMail(ctx context.Context, req *sdk.ExecuteRequest) (*sdk.ExecuteResponse, error) {
-	// Parse parameters
-	params := make(map[string]interface{})
-	for key, data := range req.Parameters {
-		var value interface{}
-		if err := json.Unmarshal(data, &value); err != nil {
-			params[key] = string(data)
-		} else {
-			params[key] = value
-		}
+	// Decode parameters with the codecs of their content types
+	var params struct {
+		To      string `json:"to"`
+		From    string `json:"from"`
+		Subject string `json:"subject"`
+		Body    string `json:"body"`
 	}
-	
-	// Extract email parameters
-	to, _ := params["to"].(string)
-	from, _ := params["from"].(string) 
-	subject, _ := params["subject"].(string)
-	body, _ := params["body"].(string)
-	
-	// Validate
-	if to == "" || from == "" || subject == "" {
-		return &sdk.ExecuteResponse{
-			Error: "missing required parameters: to, from, subject",
-		}, nil
+	typed := &sdk.TypedExecuteRequest{ExecuteRequest: req}
+	if err := typed.GetParameters(&params); err != nil {
+		return &sdk.ExecuteResponse{Error: err.Error()}, nil
 	}
 	
-	// Get credentials
+	// Get credentials, the password is only revealed when it is used
 	server := req.Credentials["smtp_server"]
-	user := req.Credentials["smtp_user"]
-	password := req.Credentials["smtp_password"]
+	password := req.Secret("smtp_password")
 	
-	p.logger.Info("sending email",
-		"to", to,
-		"from", from,
-		"subject", subject,
-		"body_length", len(body),
-		"server", server,
-		"user", user,
-		"has_password", password != "",
+	logger.FromContext(ctx).Info("sending email",
+		"to", params.To,
+		"from", params.From,
+		"subject", params.Subject,
+		"body_length", len(params.Body),
+		"has_server", server != "",
+		"has_password", password.Reveal() != "",
 	)
 	
 	// TODO: Actual SMTP implementation here
@@ -101,14 +86,20 @@ func (p *MailPlugin) sendMail(ctx context.Context, req *sdk.ExecuteRequest) (*sd
 	// Return result
 	result := map[string]interface{}{
 		"status": "success",
-		"message": fmt.Sprintf("Email sent to %s", to),
+		"message": fmt.Sprintf("Email sent to %s", params.To),
 		"messageId": "12345",
 	}
 	
-	output, _ := json.Marshal(result)
+	// Encode the result in the format the host prefers
+	codec := sdk.NegotiateCodec(sdk.AcceptFromContext(ctx))
+	output, err := codec.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
 	
 	return &sdk.ExecuteResponse{
-		Output: output,
+		Output:      output,
+		ContentType: codec.ContentType(),
 		Metadata: map[string]string{
 			"operation": "smtp_send",
 		},
@@ -122,30 +113,83 @@ func (p *MailPlugin) fetchMail(ctx context.Context, req *sdk.ExecuteRequest) (*s
 	}, nil
 }
 
+// mailManifest declares the resources and their parameters
+func mailManifest() *manifest.PluginManifest {
+	m := manifest.New("mail-plugin", "io.maschine.plugins.mail")
+	m.Plugin.Version = "1.0.0"
+	m.Plugin.Description = "Send and fetch emails"
+	m.Plugin.Category = "communication"
+	m.Configuration.Credentials = []manifest.CredentialSet{
+		{
+			Name:        "smtp",
+			Description: "SMTP server credentials",
+			Fields: []manifest.CredentialField{
+				{Name: "server", Type: "string", Required: true, Pattern: "^[^:]+:[0-9]+$"},
+				{Name: "user", Type: "string", Required: true},
+				{Name: "password", Type: "string", Required: true, Secret: true},
+			},
+		},
+	}
+	m.Resources = []manifest.ResourceDef{
+		{
+			Type:        "mrn:mail:smtp:send",
+			Name:        "Send Mail",
+			Description: "Send emails via SMTP",
+			Category:    "action",
+			Parameters: []manifest.Parameter{
+				{Name: "to", Type: "string", Required: true, Description: "Recipient email address", Pattern: `^[^@]+@[^@]+\.[^@]+$`},
+				{Name: "from", Type: "string", Required: true, Description: "Sender email address", Pattern: `^[^@]+@[^@]+\.[^@]+$`},
+				{Name: "subject", Type: "string", Required: true, Description: "Email subject line", MaxLength: 255},
+				{Name: "body", Type: "string", Description: "Email body content", Default: ""},
+			},
+			// The host can validate outputs against the schema, see
+			// host.Config.OutputValidation
+			Output: &manifest.OutputDef{
+				Type: "object",
+				Schema: map[string]interface{}{
+					"properties": map[string]interface{}{
+						"status":    map[string]interface{}{"type": "string"},
+						"message":   map[string]interface{}{"type": "string"},
+						"messageId": map[string]interface{}{"type": "string"},
+					},
+					"required": []string{"status", "messageId"},
+				},
+			},
+		},
+		{
+			Type:        "mrn:mail:imap:fetch",
+			Name:        "Fetch Mail",
+			Description: "Fetch emails via IMAP",
+			Category:    "query",
+			Parameters: []manifest.Parameter{
+				{Name: "folder", Type: "string", Description: "Mailbox folder", Default: "INBOX"},
+				{Name: "limit", Type: "integer", Description: "Maximum number of emails", Minimum: 1, Maximum: 100, Default: 10},
+			},
+		},
+	}
+	return m
+}
+
 func main() {
-	logger := hclog.New(&hclog.LoggerOptions{
-		Name:       "mail-plugin",
-		Output:     os.Stderr,
-		Level:      hclog.Info,
-		JSONFormat: true,
-	})
+	// Log level is read from MASCHINE_PLUGIN_LOG_LEVEL
+	log := logger.InitializeFromEnv("mail-plugin")
 	
-	// Check for debug mode
-	if os.Getenv("MASCHINE_PLUGIN_DEBUG") == "1" {
-		logger.SetLevel(hclog.Debug)
-	}
+	log.Info("starting mail plugin", "version", "1.0.0")
 	
-	logger.Info("starting mail plugin", "version", "1.0.0")
+	m := mailManifest()
 	
 	// pluginMap is the map of plugins we can dispense
 	var pluginMap = map[string]plugin.Plugin{
-		"maschine": &sdk.MaschinePlugin{Impl: &MailPlugin{logger: logger}},
+		"maschine": &sdk.MaschinePlugin{
+			Impl: sdk.WithSecretRedaction(sdk.WithParameterValidation(&MailPlugin{}, m), m),
+		},
 	}
 	
 	plugin.Serve(&plugin.ServeConfig{
 		HandshakeConfig: sdk.Handshake,
 		Plugins:         pluginMap,
-		Logger:          logger,
-		GRPCServer:      plugin.DefaultGRPCServer,
+		Logger:          log,
+		GRPCServer:      sdk.GRPCServer(),
+		TLSProvider:     sdk.TLSProvider,
 	})
 }
\ No newline at end of file
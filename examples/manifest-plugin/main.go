@@ -136,7 +136,7 @@ func main() {
 				Impl: p,
 			},
 		},
-		GRPCServer: plugin.DefaultGRPCServer,
+		GRPCServer: sdk.GRPCServer(),
 	})
 }
 
@@ -9,6 +9,8 @@ package pluginv1
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -21,6 +23,108 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+type HealthProbe int32
+
+const (
+	// Readiness checks that the plugin can serve executions, including its
+	// dependencies
+	HealthProbe_HEALTH_PROBE_READINESS HealthProbe = 0
+	// Liveness checks that the plugin process works
+	HealthProbe_HEALTH_PROBE_LIVENESS HealthProbe = 1
+)
+
+// Enum value maps for HealthProbe.
+var (
+	HealthProbe_name = map[int32]string{
+		0: "HEALTH_PROBE_READINESS",
+		1: "HEALTH_PROBE_LIVENESS",
+	}
+	HealthProbe_value = map[string]int32{
+		"HEALTH_PROBE_READINESS": 0,
+		"HEALTH_PROBE_LIVENESS":  1,
+	}
+)
+
+func (x HealthProbe) Enum() *HealthProbe {
+	p := new(HealthProbe)
+	*p = x
+	return p
+}
+
+func (x HealthProbe) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (HealthProbe) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_plugin_v1_plugin_proto_enumTypes[0].Descriptor()
+}
+
+func (HealthProbe) Type() protoreflect.EnumType {
+	return &file_proto_plugin_v1_plugin_proto_enumTypes[0]
+}
+
+func (x HealthProbe) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use HealthProbe.Descriptor instead.
+func (HealthProbe) EnumDescriptor() ([]byte, []int) {
+	return file_proto_plugin_v1_plugin_proto_rawDescGZIP(), []int{0}
+}
+
+type HealthStatus int32
+
+const (
+	HealthStatus_HEALTH_STATUS_UNKNOWN HealthStatus = 0
+	HealthStatus_HEALTH_STATUS_HEALTHY HealthStatus = 1
+	// Serving, but a non-critical dependency failed
+	HealthStatus_HEALTH_STATUS_DEGRADED  HealthStatus = 2
+	HealthStatus_HEALTH_STATUS_UNHEALTHY HealthStatus = 3
+)
+
+// Enum value maps for HealthStatus.
+var (
+	HealthStatus_name = map[int32]string{
+		0: "HEALTH_STATUS_UNKNOWN",
+		1: "HEALTH_STATUS_HEALTHY",
+		2: "HEALTH_STATUS_DEGRADED",
+		3: "HEALTH_STATUS_UNHEALTHY",
+	}
+	HealthStatus_value = map[string]int32{
+		"HEALTH_STATUS_UNKNOWN":   0,
+		"HEALTH_STATUS_HEALTHY":   1,
+		"HEALTH_STATUS_DEGRADED":  2,
+		"HEALTH_STATUS_UNHEALTHY": 3,
+	}
+)
+
+func (x HealthStatus) Enum() *HealthStatus {
+	p := new(HealthStatus)
+	*p = x
+	return p
+}
+
+func (x HealthStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (HealthStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_plugin_v1_plugin_proto_enumTypes[1].Descriptor()
+}
+
+func (HealthStatus) Type() protoreflect.EnumType {
+	return &file_proto_plugin_v1_plugin_proto_enumTypes[1]
+}
+
+func (x HealthStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use HealthStatus.Descriptor instead.
+func (HealthStatus) EnumDescriptor() ([]byte, []int) {
+	return file_proto_plugin_v1_plugin_proto_rawDescGZIP(), []int{1}
+}
+
 type GetMetadataRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	unknownFields protoimpl.UnknownFields
@@ -126,12 +230,29 @@ func (x *GetMetadataResponse) GetCapabilities() map[string]string {
 }
 
 type ExecuteRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Resource      string                 `protobuf:"bytes,1,opt,name=resource,proto3" json:"resource,omitempty"`
-	Input         []byte                 `protobuf:"bytes,2,opt,name=input,proto3" json:"input,omitempty"`
-	Parameters    map[string][]byte      `protobuf:"bytes,3,rep,name=parameters,proto3" json:"parameters,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	Credentials   map[string]string      `protobuf:"bytes,4,rep,name=credentials,proto3" json:"credentials,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
-	Context       map[string]string      `protobuf:"bytes,5,rep,name=context,proto3" json:"context,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Resource    string                 `protobuf:"bytes,1,opt,name=resource,proto3" json:"resource,omitempty"`
+	Input       []byte                 `protobuf:"bytes,2,opt,name=input,proto3" json:"input,omitempty"`
+	Parameters  map[string][]byte      `protobuf:"bytes,3,rep,name=parameters,proto3" json:"parameters,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Credentials map[string]string      `protobuf:"bytes,4,rep,name=credentials,proto3" json:"credentials,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Context     map[string]string      `protobuf:"bytes,5,rep,name=context,proto3" json:"context,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// ID of an uploaded ExecuteRequest that replaces all other fields
+	PayloadId string `protobuf:"bytes,6,opt,name=payload_id,json=payloadId,proto3" json:"payload_id,omitempty"`
+	// ID of the session the execution runs in, empty for stateless executions
+	SessionId string `protobuf:"bytes,7,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	// Preview the execution without changing anything, requires the
+	// supports_dry_run capability
+	DryRun bool `protobuf:"varint,8,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`
+	// Retries with the same key get the response of the first execution
+	IdempotencyKey string `protobuf:"bytes,9,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	// Content type of the input, empty for JSON
+	ContentType string `protobuf:"bytes,10,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	// Content types of the parameters by name, parameters without one are
+	// JSON or plain strings
+	ParameterContentTypes map[string]string `protobuf:"bytes,11,rep,name=parameter_content_types,json=parameterContentTypes,proto3" json:"parameter_content_types,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// ID the host assigned to the execution, replaces the execution ID of the
+	// call metadata for the executions of a batch
+	ExecutionId   string `protobuf:"bytes,12,opt,name=execution_id,json=executionId,proto3" json:"execution_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -201,11 +322,64 @@ func (x *ExecuteRequest) GetContext() map[string]string {
 	return nil
 }
 
+func (x *ExecuteRequest) GetPayloadId() string {
+	if x != nil {
+		return x.PayloadId
+	}
+	return ""
+}
+
+func (x *ExecuteRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *ExecuteRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
+func (x *ExecuteRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+func (x *ExecuteRequest) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *ExecuteRequest) GetParameterContentTypes() map[string]string {
+	if x != nil {
+		return x.ParameterContentTypes
+	}
+	return nil
+}
+
+func (x *ExecuteRequest) GetExecutionId() string {
+	if x != nil {
+		return x.ExecutionId
+	}
+	return ""
+}
+
 type ExecuteResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Output        []byte                 `protobuf:"bytes,1,opt,name=output,proto3" json:"output,omitempty"`
-	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
-	Metadata      map[string]string      `protobuf:"bytes,3,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Output   []byte                 `protobuf:"bytes,1,opt,name=output,proto3" json:"output,omitempty"`
+	Error    string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	Metadata map[string]string      `protobuf:"bytes,3,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// ID of an ExecuteResponse that must be downloaded and replaces all other fields
+	PayloadId string `protobuf:"bytes,4,opt,name=payload_id,json=payloadId,proto3" json:"payload_id,omitempty"`
+	// Content type of the output, empty for JSON or unknown
+	ContentType   string `protobuf:"bytes,5,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -261,15 +435,242 @@ func (x *ExecuteResponse) GetMetadata() map[string]string {
 	return nil
 }
 
+func (x *ExecuteResponse) GetPayloadId() string {
+	if x != nil {
+		return x.PayloadId
+	}
+	return ""
+}
+
+func (x *ExecuteResponse) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+type PlanResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Changes       []*PlannedChange       `protobuf:"bytes,1,rep,name=changes,proto3" json:"changes,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlanResponse) Reset() {
+	*x = PlanResponse{}
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlanResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlanResponse) ProtoMessage() {}
+
+func (x *PlanResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlanResponse.ProtoReflect.Descriptor instead.
+func (*PlanResponse) Descriptor() ([]byte, []int) {
+	return file_proto_plugin_v1_plugin_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *PlanResponse) GetChanges() []*PlannedChange {
+	if x != nil {
+		return x.Changes
+	}
+	return nil
+}
+
+func (x *PlanResponse) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type PlannedChange struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Kind of change, e.g. "create", "update", "delete" or "send"
+	Action string `protobuf:"bytes,1,opt,name=action,proto3" json:"action,omitempty"`
+	// Object the change applies to, e.g. a mail recipient or a bucket
+	Target        string            `protobuf:"bytes,2,opt,name=target,proto3" json:"target,omitempty"`
+	Description   string            `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Details       map[string]string `protobuf:"bytes,4,rep,name=details,proto3" json:"details,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlannedChange) Reset() {
+	*x = PlannedChange{}
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlannedChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlannedChange) ProtoMessage() {}
+
+func (x *PlannedChange) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlannedChange.ProtoReflect.Descriptor instead.
+func (*PlannedChange) Descriptor() ([]byte, []int) {
+	return file_proto_plugin_v1_plugin_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *PlannedChange) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+func (x *PlannedChange) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *PlannedChange) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *PlannedChange) GetDetails() map[string]string {
+	if x != nil {
+		return x.Details
+	}
+	return nil
+}
+
+type ExecuteBatchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Requests      []*ExecuteRequest      `protobuf:"bytes,1,rep,name=requests,proto3" json:"requests,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExecuteBatchRequest) Reset() {
+	*x = ExecuteBatchRequest{}
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecuteBatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecuteBatchRequest) ProtoMessage() {}
+
+func (x *ExecuteBatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecuteBatchRequest.ProtoReflect.Descriptor instead.
+func (*ExecuteBatchRequest) Descriptor() ([]byte, []int) {
+	return file_proto_plugin_v1_plugin_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ExecuteBatchRequest) GetRequests() []*ExecuteRequest {
+	if x != nil {
+		return x.Requests
+	}
+	return nil
+}
+
+type ExecuteBatchResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// One response per request in the same order. Failed executions report
+	// their error in the response.
+	Responses     []*ExecuteResponse `protobuf:"bytes,1,rep,name=responses,proto3" json:"responses,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExecuteBatchResponse) Reset() {
+	*x = ExecuteBatchResponse{}
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExecuteBatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExecuteBatchResponse) ProtoMessage() {}
+
+func (x *ExecuteBatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExecuteBatchResponse.ProtoReflect.Descriptor instead.
+func (*ExecuteBatchResponse) Descriptor() ([]byte, []int) {
+	return file_proto_plugin_v1_plugin_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ExecuteBatchResponse) GetResponses() []*ExecuteResponse {
+	if x != nil {
+		return x.Responses
+	}
+	return nil
+}
+
 type HealthCheckRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
+	Probe         HealthProbe            `protobuf:"varint,1,opt,name=probe,proto3,enum=maschine.plugin.v1.HealthProbe" json:"probe,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *HealthCheckRequest) Reset() {
 	*x = HealthCheckRequest{}
-	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[4]
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -281,7 +682,7 @@ func (x *HealthCheckRequest) String() string {
 func (*HealthCheckRequest) ProtoMessage() {}
 
 func (x *HealthCheckRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[4]
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -294,20 +695,30 @@ func (x *HealthCheckRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HealthCheckRequest.ProtoReflect.Descriptor instead.
 func (*HealthCheckRequest) Descriptor() ([]byte, []int) {
-	return file_proto_plugin_v1_plugin_proto_rawDescGZIP(), []int{4}
+	return file_proto_plugin_v1_plugin_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *HealthCheckRequest) GetProbe() HealthProbe {
+	if x != nil {
+		return x.Probe
+	}
+	return HealthProbe_HEALTH_PROBE_READINESS
 }
 
 type HealthCheckResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Healthy       bool                   `protobuf:"varint,1,opt,name=healthy,proto3" json:"healthy,omitempty"`
-	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// False if status is unhealthy
+	Healthy       bool                `protobuf:"varint,1,opt,name=healthy,proto3" json:"healthy,omitempty"`
+	Message       string              `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Status        HealthStatus        `protobuf:"varint,3,opt,name=status,proto3,enum=maschine.plugin.v1.HealthStatus" json:"status,omitempty"`
+	Dependencies  []*DependencyHealth `protobuf:"bytes,4,rep,name=dependencies,proto3" json:"dependencies,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *HealthCheckResponse) Reset() {
 	*x = HealthCheckResponse{}
-	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[5]
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -319,7 +730,7 @@ func (x *HealthCheckResponse) String() string {
 func (*HealthCheckResponse) ProtoMessage() {}
 
 func (x *HealthCheckResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[5]
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -332,7 +743,7 @@ func (x *HealthCheckResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use HealthCheckResponse.ProtoReflect.Descriptor instead.
 func (*HealthCheckResponse) Descriptor() ([]byte, []int) {
-	return file_proto_plugin_v1_plugin_proto_rawDescGZIP(), []int{5}
+	return file_proto_plugin_v1_plugin_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *HealthCheckResponse) GetHealthy() bool {
@@ -349,11 +760,1015 @@ func (x *HealthCheckResponse) GetMessage() string {
 	return ""
 }
 
-var File_proto_plugin_v1_plugin_proto protoreflect.FileDescriptor
+func (x *HealthCheckResponse) GetStatus() HealthStatus {
+	if x != nil {
+		return x.Status
+	}
+	return HealthStatus_HEALTH_STATUS_UNKNOWN
+}
 
-const file_proto_plugin_v1_plugin_proto_rawDesc = "" +
-	"\n" +
-	"\x1cproto/plugin/v1/plugin.proto\x12\x12maschine.plugin.v1\"\x14\n" +
+func (x *HealthCheckResponse) GetDependencies() []*DependencyHealth {
+	if x != nil {
+		return x.Dependencies
+	}
+	return nil
+}
+
+type DependencyHealth struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Name    string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Status  HealthStatus           `protobuf:"varint,2,opt,name=status,proto3,enum=maschine.plugin.v1.HealthStatus" json:"status,omitempty"`
+	Message string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	Latency *durationpb.Duration   `protobuf:"bytes,4,opt,name=latency,proto3" json:"latency,omitempty"`
+	// A failed critical dependency makes the plugin unhealthy, others degrade it
+	Critical      bool `protobuf:"varint,5,opt,name=critical,proto3" json:"critical,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DependencyHealth) Reset() {
+	*x = DependencyHealth{}
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DependencyHealth) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DependencyHealth) ProtoMessage() {}
+
+func (x *DependencyHealth) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DependencyHealth.ProtoReflect.Descriptor instead.
+func (*DependencyHealth) Descriptor() ([]byte, []int) {
+	return file_proto_plugin_v1_plugin_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *DependencyHealth) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *DependencyHealth) GetStatus() HealthStatus {
+	if x != nil {
+		return x.Status
+	}
+	return HealthStatus_HEALTH_STATUS_UNKNOWN
+}
+
+func (x *DependencyHealth) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *DependencyHealth) GetLatency() *durationpb.Duration {
+	if x != nil {
+		return x.Latency
+	}
+	return nil
+}
+
+func (x *DependencyHealth) GetCritical() bool {
+	if x != nil {
+		return x.Critical
+	}
+	return false
+}
+
+type GetManifestRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetManifestRequest) Reset() {
+	*x = GetManifestRequest{}
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetManifestRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetManifestRequest) ProtoMessage() {}
+
+func (x *GetManifestRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetManifestRequest.ProtoReflect.Descriptor instead.
+func (*GetManifestRequest) Descriptor() ([]byte, []int) {
+	return file_proto_plugin_v1_plugin_proto_rawDescGZIP(), []int{11}
+}
+
+type GetManifestResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// JSON encoded plugin manifest
+	Manifest      []byte `protobuf:"bytes,1,opt,name=manifest,proto3" json:"manifest,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetManifestResponse) Reset() {
+	*x = GetManifestResponse{}
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetManifestResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetManifestResponse) ProtoMessage() {}
+
+func (x *GetManifestResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetManifestResponse.ProtoReflect.Descriptor instead.
+func (*GetManifestResponse) Descriptor() ([]byte, []int) {
+	return file_proto_plugin_v1_plugin_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetManifestResponse) GetManifest() []byte {
+	if x != nil {
+		return x.Manifest
+	}
+	return nil
+}
+
+type PayloadChunk struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Data  []byte                 `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	// Size of the complete payload, set on the first chunk
+	TotalSize     int64 `protobuf:"varint,2,opt,name=total_size,json=totalSize,proto3" json:"total_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PayloadChunk) Reset() {
+	*x = PayloadChunk{}
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PayloadChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PayloadChunk) ProtoMessage() {}
+
+func (x *PayloadChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PayloadChunk.ProtoReflect.Descriptor instead.
+func (*PayloadChunk) Descriptor() ([]byte, []int) {
+	return file_proto_plugin_v1_plugin_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *PayloadChunk) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *PayloadChunk) GetTotalSize() int64 {
+	if x != nil {
+		return x.TotalSize
+	}
+	return 0
+}
+
+type UploadResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PayloadId     string                 `protobuf:"bytes,1,opt,name=payload_id,json=payloadId,proto3" json:"payload_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UploadResponse) Reset() {
+	*x = UploadResponse{}
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UploadResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UploadResponse) ProtoMessage() {}
+
+func (x *UploadResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UploadResponse.ProtoReflect.Descriptor instead.
+func (*UploadResponse) Descriptor() ([]byte, []int) {
+	return file_proto_plugin_v1_plugin_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *UploadResponse) GetPayloadId() string {
+	if x != nil {
+		return x.PayloadId
+	}
+	return ""
+}
+
+type DownloadRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PayloadId     string                 `protobuf:"bytes,1,opt,name=payload_id,json=payloadId,proto3" json:"payload_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DownloadRequest) Reset() {
+	*x = DownloadRequest{}
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DownloadRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DownloadRequest) ProtoMessage() {}
+
+func (x *DownloadRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DownloadRequest.ProtoReflect.Descriptor instead.
+func (*DownloadRequest) Descriptor() ([]byte, []int) {
+	return file_proto_plugin_v1_plugin_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *DownloadRequest) GetPayloadId() string {
+	if x != nil {
+		return x.PayloadId
+	}
+	return ""
+}
+
+type ConfigureRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Environment   map[string]string      `protobuf:"bytes,1,rep,name=environment,proto3" json:"environment,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Credentials   []*CredentialValues    `protobuf:"bytes,2,rep,name=credentials,proto3" json:"credentials,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConfigureRequest) Reset() {
+	*x = ConfigureRequest{}
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConfigureRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfigureRequest) ProtoMessage() {}
+
+func (x *ConfigureRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConfigureRequest.ProtoReflect.Descriptor instead.
+func (*ConfigureRequest) Descriptor() ([]byte, []int) {
+	return file_proto_plugin_v1_plugin_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ConfigureRequest) GetEnvironment() map[string]string {
+	if x != nil {
+		return x.Environment
+	}
+	return nil
+}
+
+func (x *ConfigureRequest) GetCredentials() []*CredentialValues {
+	if x != nil {
+		return x.Credentials
+	}
+	return nil
+}
+
+type CredentialValues struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Name of the credential set declared in the manifest
+	Name          string            `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Values        map[string]string `protobuf:"bytes,2,rep,name=values,proto3" json:"values,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CredentialValues) Reset() {
+	*x = CredentialValues{}
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CredentialValues) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CredentialValues) ProtoMessage() {}
+
+func (x *CredentialValues) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CredentialValues.ProtoReflect.Descriptor instead.
+func (*CredentialValues) Descriptor() ([]byte, []int) {
+	return file_proto_plugin_v1_plugin_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *CredentialValues) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CredentialValues) GetValues() map[string]string {
+	if x != nil {
+		return x.Values
+	}
+	return nil
+}
+
+type ConfigureResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Errors rejecting the configuration, empty if it was accepted
+	Errors        []*FieldError `protobuf:"bytes,1,rep,name=errors,proto3" json:"errors,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConfigureResponse) Reset() {
+	*x = ConfigureResponse{}
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConfigureResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfigureResponse) ProtoMessage() {}
+
+func (x *ConfigureResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConfigureResponse.ProtoReflect.Descriptor instead.
+func (*ConfigureResponse) Descriptor() ([]byte, []int) {
+	return file_proto_plugin_v1_plugin_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ConfigureResponse) GetErrors() []*FieldError {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+type FieldError struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Field         string                 `protobuf:"bytes,1,opt,name=field,proto3" json:"field,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FieldError) Reset() {
+	*x = FieldError{}
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FieldError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FieldError) ProtoMessage() {}
+
+func (x *FieldError) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FieldError.ProtoReflect.Descriptor instead.
+func (*FieldError) Descriptor() ([]byte, []int) {
+	return file_proto_plugin_v1_plugin_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *FieldError) GetField() string {
+	if x != nil {
+		return x.Field
+	}
+	return ""
+}
+
+func (x *FieldError) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type ShutdownRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// In-flight executions are awaited until the deadline, unset waits without limit
+	Deadline      *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=deadline,proto3" json:"deadline,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShutdownRequest) Reset() {
+	*x = ShutdownRequest{}
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShutdownRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShutdownRequest) ProtoMessage() {}
+
+func (x *ShutdownRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShutdownRequest.ProtoReflect.Descriptor instead.
+func (*ShutdownRequest) Descriptor() ([]byte, []int) {
+	return file_proto_plugin_v1_plugin_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ShutdownRequest) GetDeadline() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Deadline
+	}
+	return nil
+}
+
+type ShutdownResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Number of executions still running at the deadline
+	Unfinished    int32 `protobuf:"varint,1,opt,name=unfinished,proto3" json:"unfinished,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ShutdownResponse) Reset() {
+	*x = ShutdownResponse{}
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ShutdownResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ShutdownResponse) ProtoMessage() {}
+
+func (x *ShutdownResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ShutdownResponse.ProtoReflect.Descriptor instead.
+func (*ShutdownResponse) Descriptor() ([]byte, []int) {
+	return file_proto_plugin_v1_plugin_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ShutdownResponse) GetUnfinished() int32 {
+	if x != nil {
+		return x.Unfinished
+	}
+	return 0
+}
+
+type OpenSessionRequest struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Context map[string]string      `protobuf:"bytes,1,rep,name=context,proto3" json:"context,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Idle time after which the session expires, unset uses the plugin default
+	Ttl           *durationpb.Duration `protobuf:"bytes,2,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OpenSessionRequest) Reset() {
+	*x = OpenSessionRequest{}
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OpenSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OpenSessionRequest) ProtoMessage() {}
+
+func (x *OpenSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OpenSessionRequest.ProtoReflect.Descriptor instead.
+func (*OpenSessionRequest) Descriptor() ([]byte, []int) {
+	return file_proto_plugin_v1_plugin_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *OpenSessionRequest) GetContext() map[string]string {
+	if x != nil {
+		return x.Context
+	}
+	return nil
+}
+
+func (x *OpenSessionRequest) GetTtl() *durationpb.Duration {
+	if x != nil {
+		return x.Ttl
+	}
+	return nil
+}
+
+type OpenSessionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *OpenSessionResponse) Reset() {
+	*x = OpenSessionResponse{}
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *OpenSessionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*OpenSessionResponse) ProtoMessage() {}
+
+func (x *OpenSessionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use OpenSessionResponse.ProtoReflect.Descriptor instead.
+func (*OpenSessionResponse) Descriptor() ([]byte, []int) {
+	return file_proto_plugin_v1_plugin_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *OpenSessionResponse) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+type CloseSessionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CloseSessionRequest) Reset() {
+	*x = CloseSessionRequest{}
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CloseSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CloseSessionRequest) ProtoMessage() {}
+
+func (x *CloseSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CloseSessionRequest.ProtoReflect.Descriptor instead.
+func (*CloseSessionRequest) Descriptor() ([]byte, []int) {
+	return file_proto_plugin_v1_plugin_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *CloseSessionRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+type CloseSessionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CloseSessionResponse) Reset() {
+	*x = CloseSessionResponse{}
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CloseSessionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CloseSessionResponse) ProtoMessage() {}
+
+func (x *CloseSessionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CloseSessionResponse.ProtoReflect.Descriptor instead.
+func (*CloseSessionResponse) Descriptor() ([]byte, []int) {
+	return file_proto_plugin_v1_plugin_proto_rawDescGZIP(), []int{25}
+}
+
+type SubscribeRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Trigger resources to watch
+	Resources     []string `protobuf:"bytes,1,rep,name=resources,proto3" json:"resources,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubscribeRequest) Reset() {
+	*x = SubscribeRequest{}
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubscribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubscribeRequest) ProtoMessage() {}
+
+func (x *SubscribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubscribeRequest.ProtoReflect.Descriptor instead.
+func (*SubscribeRequest) Descriptor() ([]byte, []int) {
+	return file_proto_plugin_v1_plugin_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *SubscribeRequest) GetResources() []string {
+	if x != nil {
+		return x.Resources
+	}
+	return nil
+}
+
+type Event struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Unique ID assigned by the plugin, used to acknowledge the event
+	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	// Trigger resource that emitted the event
+	Resource string `protobuf:"bytes,2,opt,name=resource,proto3" json:"resource,omitempty"`
+	Payload  []byte `protobuf:"bytes,3,opt,name=payload,proto3" json:"payload,omitempty"`
+	// Content type of the payload, empty for JSON
+	ContentType string `protobuf:"bytes,4,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
+	// Identifies the occurrence the event reports, e.g. a message ID. The host
+	// drops events whose dedupe ID it has seen before.
+	DedupeId      string                 `protobuf:"bytes,5,opt,name=dedupe_id,json=dedupeId,proto3" json:"dedupe_id,omitempty"`
+	Time          *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=time,proto3" json:"time,omitempty"`
+	Attributes    map[string]string      `protobuf:"bytes,7,rep,name=attributes,proto3" json:"attributes,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_proto_plugin_v1_plugin_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *Event) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Event) GetResource() string {
+	if x != nil {
+		return x.Resource
+	}
+	return ""
+}
+
+func (x *Event) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *Event) GetContentType() string {
+	if x != nil {
+		return x.ContentType
+	}
+	return ""
+}
+
+func (x *Event) GetDedupeId() string {
+	if x != nil {
+		return x.DedupeId
+	}
+	return ""
+}
+
+func (x *Event) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *Event) GetAttributes() map[string]string {
+	if x != nil {
+		return x.Attributes
+	}
+	return nil
+}
+
+type AcknowledgeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	EventIds      []string               `protobuf:"bytes,1,rep,name=event_ids,json=eventIds,proto3" json:"event_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AcknowledgeRequest) Reset() {
+	*x = AcknowledgeRequest{}
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AcknowledgeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AcknowledgeRequest) ProtoMessage() {}
+
+func (x *AcknowledgeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AcknowledgeRequest.ProtoReflect.Descriptor instead.
+func (*AcknowledgeRequest) Descriptor() ([]byte, []int) {
+	return file_proto_plugin_v1_plugin_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *AcknowledgeRequest) GetEventIds() []string {
+	if x != nil {
+		return x.EventIds
+	}
+	return nil
+}
+
+type AcknowledgeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AcknowledgeResponse) Reset() {
+	*x = AcknowledgeResponse{}
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AcknowledgeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AcknowledgeResponse) ProtoMessage() {}
+
+func (x *AcknowledgeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_plugin_v1_plugin_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AcknowledgeResponse.ProtoReflect.Descriptor instead.
+func (*AcknowledgeResponse) Descriptor() ([]byte, []int) {
+	return file_proto_plugin_v1_plugin_proto_rawDescGZIP(), []int{29}
+}
+
+var File_proto_plugin_v1_plugin_proto protoreflect.FileDescriptor
+
+const file_proto_plugin_v1_plugin_proto_rawDesc = "" +
+	"\n" +
+	"\x1cproto/plugin/v1/plugin.proto\x12\x12maschine.plugin.v1\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\x14\n" +
 	"\x12GetMetadataRequest\"\x94\x02\n" +
 	"\x13GetMetadataResponse\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
@@ -362,7 +1777,7 @@ const file_proto_plugin_v1_plugin_proto_rawDesc = "" +
 	"\fcapabilities\x18\x04 \x03(\v29.maschine.plugin.v1.GetMetadataResponse.CapabilitiesEntryR\fcapabilities\x1a?\n" +
 	"\x11CapabilitiesEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xf3\x03\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xfa\x06\n" +
 	"\x0eExecuteRequest\x12\x1a\n" +
 	"\bresource\x18\x01 \x01(\tR\bresource\x12\x14\n" +
 	"\x05input\x18\x02 \x01(\fR\x05input\x12R\n" +
@@ -370,7 +1785,17 @@ const file_proto_plugin_v1_plugin_proto_rawDesc = "" +
 	"parameters\x18\x03 \x03(\v22.maschine.plugin.v1.ExecuteRequest.ParametersEntryR\n" +
 	"parameters\x12U\n" +
 	"\vcredentials\x18\x04 \x03(\v23.maschine.plugin.v1.ExecuteRequest.CredentialsEntryR\vcredentials\x12I\n" +
-	"\acontext\x18\x05 \x03(\v2/.maschine.plugin.v1.ExecuteRequest.ContextEntryR\acontext\x1a=\n" +
+	"\acontext\x18\x05 \x03(\v2/.maschine.plugin.v1.ExecuteRequest.ContextEntryR\acontext\x12\x1d\n" +
+	"\n" +
+	"payload_id\x18\x06 \x01(\tR\tpayloadId\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\a \x01(\tR\tsessionId\x12\x17\n" +
+	"\adry_run\x18\b \x01(\bR\x06dryRun\x12'\n" +
+	"\x0fidempotency_key\x18\t \x01(\tR\x0eidempotencyKey\x12!\n" +
+	"\fcontent_type\x18\n" +
+	" \x01(\tR\vcontentType\x12u\n" +
+	"\x17parameter_content_types\x18\v \x03(\v2=.maschine.plugin.v1.ExecuteRequest.ParameterContentTypesEntryR\x15parameterContentTypes\x12!\n" +
+	"\fexecution_id\x18\f \x01(\tR\vexecutionId\x1a=\n" +
 	"\x0fParametersEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
 	"\x05value\x18\x02 \x01(\fR\x05value:\x028\x01\x1a>\n" +
@@ -379,22 +1804,139 @@ const file_proto_plugin_v1_plugin_proto_rawDesc = "" +
 	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a:\n" +
 	"\fContextEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xcb\x01\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1aH\n" +
+	"\x1aParameterContentTypesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x8d\x02\n" +
 	"\x0fExecuteResponse\x12\x16\n" +
 	"\x06output\x18\x01 \x01(\fR\x06output\x12\x14\n" +
 	"\x05error\x18\x02 \x01(\tR\x05error\x12M\n" +
-	"\bmetadata\x18\x03 \x03(\v21.maschine.plugin.v1.ExecuteResponse.MetadataEntryR\bmetadata\x1a;\n" +
+	"\bmetadata\x18\x03 \x03(\v21.maschine.plugin.v1.ExecuteResponse.MetadataEntryR\bmetadata\x12\x1d\n" +
+	"\n" +
+	"payload_id\x18\x04 \x01(\tR\tpayloadId\x12!\n" +
+	"\fcontent_type\x18\x05 \x01(\tR\vcontentType\x1a;\n" +
 	"\rMetadataEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x14\n" +
-	"\x12HealthCheckRequest\"I\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"a\n" +
+	"\fPlanResponse\x12;\n" +
+	"\achanges\x18\x01 \x03(\v2!.maschine.plugin.v1.PlannedChangeR\achanges\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error\"\xe7\x01\n" +
+	"\rPlannedChange\x12\x16\n" +
+	"\x06action\x18\x01 \x01(\tR\x06action\x12\x16\n" +
+	"\x06target\x18\x02 \x01(\tR\x06target\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12H\n" +
+	"\adetails\x18\x04 \x03(\v2..maschine.plugin.v1.PlannedChange.DetailsEntryR\adetails\x1a:\n" +
+	"\fDetailsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"U\n" +
+	"\x13ExecuteBatchRequest\x12>\n" +
+	"\brequests\x18\x01 \x03(\v2\".maschine.plugin.v1.ExecuteRequestR\brequests\"Y\n" +
+	"\x14ExecuteBatchResponse\x12A\n" +
+	"\tresponses\x18\x01 \x03(\v2#.maschine.plugin.v1.ExecuteResponseR\tresponses\"K\n" +
+	"\x12HealthCheckRequest\x125\n" +
+	"\x05probe\x18\x01 \x01(\x0e2\x1f.maschine.plugin.v1.HealthProbeR\x05probe\"\xcd\x01\n" +
 	"\x13HealthCheckResponse\x12\x18\n" +
 	"\ahealthy\x18\x01 \x01(\bR\ahealthy\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage2\x9c\x02\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x128\n" +
+	"\x06status\x18\x03 \x01(\x0e2 .maschine.plugin.v1.HealthStatusR\x06status\x12H\n" +
+	"\fdependencies\x18\x04 \x03(\v2$.maschine.plugin.v1.DependencyHealthR\fdependencies\"\xcb\x01\n" +
+	"\x10DependencyHealth\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x128\n" +
+	"\x06status\x18\x02 \x01(\x0e2 .maschine.plugin.v1.HealthStatusR\x06status\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x123\n" +
+	"\alatency\x18\x04 \x01(\v2\x19.google.protobuf.DurationR\alatency\x12\x1a\n" +
+	"\bcritical\x18\x05 \x01(\bR\bcritical\"\x14\n" +
+	"\x12GetManifestRequest\"1\n" +
+	"\x13GetManifestResponse\x12\x1a\n" +
+	"\bmanifest\x18\x01 \x01(\fR\bmanifest\"A\n" +
+	"\fPayloadChunk\x12\x12\n" +
+	"\x04data\x18\x01 \x01(\fR\x04data\x12\x1d\n" +
+	"\n" +
+	"total_size\x18\x02 \x01(\x03R\ttotalSize\"/\n" +
+	"\x0eUploadResponse\x12\x1d\n" +
+	"\n" +
+	"payload_id\x18\x01 \x01(\tR\tpayloadId\"0\n" +
+	"\x0fDownloadRequest\x12\x1d\n" +
+	"\n" +
+	"payload_id\x18\x01 \x01(\tR\tpayloadId\"\xf3\x01\n" +
+	"\x10ConfigureRequest\x12W\n" +
+	"\venvironment\x18\x01 \x03(\v25.maschine.plugin.v1.ConfigureRequest.EnvironmentEntryR\venvironment\x12F\n" +
+	"\vcredentials\x18\x02 \x03(\v2$.maschine.plugin.v1.CredentialValuesR\vcredentials\x1a>\n" +
+	"\x10EnvironmentEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xab\x01\n" +
+	"\x10CredentialValues\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12H\n" +
+	"\x06values\x18\x02 \x03(\v20.maschine.plugin.v1.CredentialValues.ValuesEntryR\x06values\x1a9\n" +
+	"\vValuesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"K\n" +
+	"\x11ConfigureResponse\x126\n" +
+	"\x06errors\x18\x01 \x03(\v2\x1e.maschine.plugin.v1.FieldErrorR\x06errors\"<\n" +
+	"\n" +
+	"FieldError\x12\x14\n" +
+	"\x05field\x18\x01 \x01(\tR\x05field\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"I\n" +
+	"\x0fShutdownRequest\x126\n" +
+	"\bdeadline\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\bdeadline\"2\n" +
+	"\x10ShutdownResponse\x12\x1e\n" +
+	"\n" +
+	"unfinished\x18\x01 \x01(\x05R\n" +
+	"unfinished\"\xcc\x01\n" +
+	"\x12OpenSessionRequest\x12M\n" +
+	"\acontext\x18\x01 \x03(\v23.maschine.plugin.v1.OpenSessionRequest.ContextEntryR\acontext\x12+\n" +
+	"\x03ttl\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\x03ttl\x1a:\n" +
+	"\fContextEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"4\n" +
+	"\x13OpenSessionResponse\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\"4\n" +
+	"\x13CloseSessionRequest\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\"\x16\n" +
+	"\x14CloseSessionResponse\"0\n" +
+	"\x10SubscribeRequest\x12\x1c\n" +
+	"\tresources\x18\x01 \x03(\tR\tresources\"\xc7\x02\n" +
+	"\x05Event\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
+	"\bresource\x18\x02 \x01(\tR\bresource\x12\x18\n" +
+	"\apayload\x18\x03 \x01(\fR\apayload\x12!\n" +
+	"\fcontent_type\x18\x04 \x01(\tR\vcontentType\x12\x1b\n" +
+	"\tdedupe_id\x18\x05 \x01(\tR\bdedupeId\x12.\n" +
+	"\x04time\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12I\n" +
+	"\n" +
+	"attributes\x18\a \x03(\v2).maschine.plugin.v1.Event.AttributesEntryR\n" +
+	"attributes\x1a=\n" +
+	"\x0fAttributesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"1\n" +
+	"\x12AcknowledgeRequest\x12\x1b\n" +
+	"\tevent_ids\x18\x01 \x03(\tR\beventIds\"\x15\n" +
+	"\x13AcknowledgeResponse*D\n" +
+	"\vHealthProbe\x12\x1a\n" +
+	"\x16HEALTH_PROBE_READINESS\x10\x00\x12\x19\n" +
+	"\x15HEALTH_PROBE_LIVENESS\x10\x01*}\n" +
+	"\fHealthStatus\x12\x19\n" +
+	"\x15HEALTH_STATUS_UNKNOWN\x10\x00\x12\x19\n" +
+	"\x15HEALTH_STATUS_HEALTHY\x10\x01\x12\x1a\n" +
+	"\x16HEALTH_STATUS_DEGRADED\x10\x02\x12\x1b\n" +
+	"\x17HEALTH_STATUS_UNHEALTHY\x10\x032\xf8\t\n" +
 	"\x06Plugin\x12^\n" +
 	"\vGetMetadata\x12&.maschine.plugin.v1.GetMetadataRequest\x1a'.maschine.plugin.v1.GetMetadataResponse\x12R\n" +
-	"\aExecute\x12\".maschine.plugin.v1.ExecuteRequest\x1a#.maschine.plugin.v1.ExecuteResponse\x12^\n" +
-	"\vHealthCheck\x12&.maschine.plugin.v1.HealthCheckRequest\x1a'.maschine.plugin.v1.HealthCheckResponseB1Z/maschine.io/plugin-sdk/proto/plugin/v1;pluginv1b\x06proto3"
+	"\aExecute\x12\".maschine.plugin.v1.ExecuteRequest\x1a#.maschine.plugin.v1.ExecuteResponse\x12L\n" +
+	"\x04Plan\x12\".maschine.plugin.v1.ExecuteRequest\x1a .maschine.plugin.v1.PlanResponse\x12a\n" +
+	"\fExecuteBatch\x12'.maschine.plugin.v1.ExecuteBatchRequest\x1a(.maschine.plugin.v1.ExecuteBatchResponse\x12^\n" +
+	"\vHealthCheck\x12&.maschine.plugin.v1.HealthCheckRequest\x1a'.maschine.plugin.v1.HealthCheckResponse\x12^\n" +
+	"\vGetManifest\x12&.maschine.plugin.v1.GetManifestRequest\x1a'.maschine.plugin.v1.GetManifestResponse\x12P\n" +
+	"\x06Upload\x12 .maschine.plugin.v1.PayloadChunk\x1a\".maschine.plugin.v1.UploadResponse(\x01\x12S\n" +
+	"\bDownload\x12#.maschine.plugin.v1.DownloadRequest\x1a .maschine.plugin.v1.PayloadChunk0\x01\x12X\n" +
+	"\tConfigure\x12$.maschine.plugin.v1.ConfigureRequest\x1a%.maschine.plugin.v1.ConfigureResponse\x12U\n" +
+	"\bShutdown\x12#.maschine.plugin.v1.ShutdownRequest\x1a$.maschine.plugin.v1.ShutdownResponse\x12^\n" +
+	"\vOpenSession\x12&.maschine.plugin.v1.OpenSessionRequest\x1a'.maschine.plugin.v1.OpenSessionResponse\x12a\n" +
+	"\fCloseSession\x12'.maschine.plugin.v1.CloseSessionRequest\x1a(.maschine.plugin.v1.CloseSessionResponse\x12N\n" +
+	"\tSubscribe\x12$.maschine.plugin.v1.SubscribeRequest\x1a\x19.maschine.plugin.v1.Event0\x01\x12^\n" +
+	"\vAcknowledge\x12&.maschine.plugin.v1.AcknowledgeRequest\x1a'.maschine.plugin.v1.AcknowledgeResponseB1Z/maschine.io/plugin-sdk/proto/plugin/v1;pluginv1b\x06proto3"
 
 var (
 	file_proto_plugin_v1_plugin_proto_rawDescOnce sync.Once
@@ -408,37 +1950,113 @@ func file_proto_plugin_v1_plugin_proto_rawDescGZIP() []byte {
 	return file_proto_plugin_v1_plugin_proto_rawDescData
 }
 
-var file_proto_plugin_v1_plugin_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_proto_plugin_v1_plugin_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_proto_plugin_v1_plugin_proto_msgTypes = make([]protoimpl.MessageInfo, 41)
 var file_proto_plugin_v1_plugin_proto_goTypes = []any{
-	(*GetMetadataRequest)(nil),  // 0: maschine.plugin.v1.GetMetadataRequest
-	(*GetMetadataResponse)(nil), // 1: maschine.plugin.v1.GetMetadataResponse
-	(*ExecuteRequest)(nil),      // 2: maschine.plugin.v1.ExecuteRequest
-	(*ExecuteResponse)(nil),     // 3: maschine.plugin.v1.ExecuteResponse
-	(*HealthCheckRequest)(nil),  // 4: maschine.plugin.v1.HealthCheckRequest
-	(*HealthCheckResponse)(nil), // 5: maschine.plugin.v1.HealthCheckResponse
-	nil,                         // 6: maschine.plugin.v1.GetMetadataResponse.CapabilitiesEntry
-	nil,                         // 7: maschine.plugin.v1.ExecuteRequest.ParametersEntry
-	nil,                         // 8: maschine.plugin.v1.ExecuteRequest.CredentialsEntry
-	nil,                         // 9: maschine.plugin.v1.ExecuteRequest.ContextEntry
-	nil,                         // 10: maschine.plugin.v1.ExecuteResponse.MetadataEntry
+	(HealthProbe)(0),              // 0: maschine.plugin.v1.HealthProbe
+	(HealthStatus)(0),             // 1: maschine.plugin.v1.HealthStatus
+	(*GetMetadataRequest)(nil),    // 2: maschine.plugin.v1.GetMetadataRequest
+	(*GetMetadataResponse)(nil),   // 3: maschine.plugin.v1.GetMetadataResponse
+	(*ExecuteRequest)(nil),        // 4: maschine.plugin.v1.ExecuteRequest
+	(*ExecuteResponse)(nil),       // 5: maschine.plugin.v1.ExecuteResponse
+	(*PlanResponse)(nil),          // 6: maschine.plugin.v1.PlanResponse
+	(*PlannedChange)(nil),         // 7: maschine.plugin.v1.PlannedChange
+	(*ExecuteBatchRequest)(nil),   // 8: maschine.plugin.v1.ExecuteBatchRequest
+	(*ExecuteBatchResponse)(nil),  // 9: maschine.plugin.v1.ExecuteBatchResponse
+	(*HealthCheckRequest)(nil),    // 10: maschine.plugin.v1.HealthCheckRequest
+	(*HealthCheckResponse)(nil),   // 11: maschine.plugin.v1.HealthCheckResponse
+	(*DependencyHealth)(nil),      // 12: maschine.plugin.v1.DependencyHealth
+	(*GetManifestRequest)(nil),    // 13: maschine.plugin.v1.GetManifestRequest
+	(*GetManifestResponse)(nil),   // 14: maschine.plugin.v1.GetManifestResponse
+	(*PayloadChunk)(nil),          // 15: maschine.plugin.v1.PayloadChunk
+	(*UploadResponse)(nil),        // 16: maschine.plugin.v1.UploadResponse
+	(*DownloadRequest)(nil),       // 17: maschine.plugin.v1.DownloadRequest
+	(*ConfigureRequest)(nil),      // 18: maschine.plugin.v1.ConfigureRequest
+	(*CredentialValues)(nil),      // 19: maschine.plugin.v1.CredentialValues
+	(*ConfigureResponse)(nil),     // 20: maschine.plugin.v1.ConfigureResponse
+	(*FieldError)(nil),            // 21: maschine.plugin.v1.FieldError
+	(*ShutdownRequest)(nil),       // 22: maschine.plugin.v1.ShutdownRequest
+	(*ShutdownResponse)(nil),      // 23: maschine.plugin.v1.ShutdownResponse
+	(*OpenSessionRequest)(nil),    // 24: maschine.plugin.v1.OpenSessionRequest
+	(*OpenSessionResponse)(nil),   // 25: maschine.plugin.v1.OpenSessionResponse
+	(*CloseSessionRequest)(nil),   // 26: maschine.plugin.v1.CloseSessionRequest
+	(*CloseSessionResponse)(nil),  // 27: maschine.plugin.v1.CloseSessionResponse
+	(*SubscribeRequest)(nil),      // 28: maschine.plugin.v1.SubscribeRequest
+	(*Event)(nil),                 // 29: maschine.plugin.v1.Event
+	(*AcknowledgeRequest)(nil),    // 30: maschine.plugin.v1.AcknowledgeRequest
+	(*AcknowledgeResponse)(nil),   // 31: maschine.plugin.v1.AcknowledgeResponse
+	nil,                           // 32: maschine.plugin.v1.GetMetadataResponse.CapabilitiesEntry
+	nil,                           // 33: maschine.plugin.v1.ExecuteRequest.ParametersEntry
+	nil,                           // 34: maschine.plugin.v1.ExecuteRequest.CredentialsEntry
+	nil,                           // 35: maschine.plugin.v1.ExecuteRequest.ContextEntry
+	nil,                           // 36: maschine.plugin.v1.ExecuteRequest.ParameterContentTypesEntry
+	nil,                           // 37: maschine.plugin.v1.ExecuteResponse.MetadataEntry
+	nil,                           // 38: maschine.plugin.v1.PlannedChange.DetailsEntry
+	nil,                           // 39: maschine.plugin.v1.ConfigureRequest.EnvironmentEntry
+	nil,                           // 40: maschine.plugin.v1.CredentialValues.ValuesEntry
+	nil,                           // 41: maschine.plugin.v1.OpenSessionRequest.ContextEntry
+	nil,                           // 42: maschine.plugin.v1.Event.AttributesEntry
+	(*durationpb.Duration)(nil),   // 43: google.protobuf.Duration
+	(*timestamppb.Timestamp)(nil), // 44: google.protobuf.Timestamp
 }
 var file_proto_plugin_v1_plugin_proto_depIdxs = []int32{
-	6,  // 0: maschine.plugin.v1.GetMetadataResponse.capabilities:type_name -> maschine.plugin.v1.GetMetadataResponse.CapabilitiesEntry
-	7,  // 1: maschine.plugin.v1.ExecuteRequest.parameters:type_name -> maschine.plugin.v1.ExecuteRequest.ParametersEntry
-	8,  // 2: maschine.plugin.v1.ExecuteRequest.credentials:type_name -> maschine.plugin.v1.ExecuteRequest.CredentialsEntry
-	9,  // 3: maschine.plugin.v1.ExecuteRequest.context:type_name -> maschine.plugin.v1.ExecuteRequest.ContextEntry
-	10, // 4: maschine.plugin.v1.ExecuteResponse.metadata:type_name -> maschine.plugin.v1.ExecuteResponse.MetadataEntry
-	0,  // 5: maschine.plugin.v1.Plugin.GetMetadata:input_type -> maschine.plugin.v1.GetMetadataRequest
-	2,  // 6: maschine.plugin.v1.Plugin.Execute:input_type -> maschine.plugin.v1.ExecuteRequest
-	4,  // 7: maschine.plugin.v1.Plugin.HealthCheck:input_type -> maschine.plugin.v1.HealthCheckRequest
-	1,  // 8: maschine.plugin.v1.Plugin.GetMetadata:output_type -> maschine.plugin.v1.GetMetadataResponse
-	3,  // 9: maschine.plugin.v1.Plugin.Execute:output_type -> maschine.plugin.v1.ExecuteResponse
-	5,  // 10: maschine.plugin.v1.Plugin.HealthCheck:output_type -> maschine.plugin.v1.HealthCheckResponse
-	8,  // [8:11] is the sub-list for method output_type
-	5,  // [5:8] is the sub-list for method input_type
-	5,  // [5:5] is the sub-list for extension type_name
-	5,  // [5:5] is the sub-list for extension extendee
-	0,  // [0:5] is the sub-list for field type_name
+	32, // 0: maschine.plugin.v1.GetMetadataResponse.capabilities:type_name -> maschine.plugin.v1.GetMetadataResponse.CapabilitiesEntry
+	33, // 1: maschine.plugin.v1.ExecuteRequest.parameters:type_name -> maschine.plugin.v1.ExecuteRequest.ParametersEntry
+	34, // 2: maschine.plugin.v1.ExecuteRequest.credentials:type_name -> maschine.plugin.v1.ExecuteRequest.CredentialsEntry
+	35, // 3: maschine.plugin.v1.ExecuteRequest.context:type_name -> maschine.plugin.v1.ExecuteRequest.ContextEntry
+	36, // 4: maschine.plugin.v1.ExecuteRequest.parameter_content_types:type_name -> maschine.plugin.v1.ExecuteRequest.ParameterContentTypesEntry
+	37, // 5: maschine.plugin.v1.ExecuteResponse.metadata:type_name -> maschine.plugin.v1.ExecuteResponse.MetadataEntry
+	7,  // 6: maschine.plugin.v1.PlanResponse.changes:type_name -> maschine.plugin.v1.PlannedChange
+	38, // 7: maschine.plugin.v1.PlannedChange.details:type_name -> maschine.plugin.v1.PlannedChange.DetailsEntry
+	4,  // 8: maschine.plugin.v1.ExecuteBatchRequest.requests:type_name -> maschine.plugin.v1.ExecuteRequest
+	5,  // 9: maschine.plugin.v1.ExecuteBatchResponse.responses:type_name -> maschine.plugin.v1.ExecuteResponse
+	0,  // 10: maschine.plugin.v1.HealthCheckRequest.probe:type_name -> maschine.plugin.v1.HealthProbe
+	1,  // 11: maschine.plugin.v1.HealthCheckResponse.status:type_name -> maschine.plugin.v1.HealthStatus
+	12, // 12: maschine.plugin.v1.HealthCheckResponse.dependencies:type_name -> maschine.plugin.v1.DependencyHealth
+	1,  // 13: maschine.plugin.v1.DependencyHealth.status:type_name -> maschine.plugin.v1.HealthStatus
+	43, // 14: maschine.plugin.v1.DependencyHealth.latency:type_name -> google.protobuf.Duration
+	39, // 15: maschine.plugin.v1.ConfigureRequest.environment:type_name -> maschine.plugin.v1.ConfigureRequest.EnvironmentEntry
+	19, // 16: maschine.plugin.v1.ConfigureRequest.credentials:type_name -> maschine.plugin.v1.CredentialValues
+	40, // 17: maschine.plugin.v1.CredentialValues.values:type_name -> maschine.plugin.v1.CredentialValues.ValuesEntry
+	21, // 18: maschine.plugin.v1.ConfigureResponse.errors:type_name -> maschine.plugin.v1.FieldError
+	44, // 19: maschine.plugin.v1.ShutdownRequest.deadline:type_name -> google.protobuf.Timestamp
+	41, // 20: maschine.plugin.v1.OpenSessionRequest.context:type_name -> maschine.plugin.v1.OpenSessionRequest.ContextEntry
+	43, // 21: maschine.plugin.v1.OpenSessionRequest.ttl:type_name -> google.protobuf.Duration
+	44, // 22: maschine.plugin.v1.Event.time:type_name -> google.protobuf.Timestamp
+	42, // 23: maschine.plugin.v1.Event.attributes:type_name -> maschine.plugin.v1.Event.AttributesEntry
+	2,  // 24: maschine.plugin.v1.Plugin.GetMetadata:input_type -> maschine.plugin.v1.GetMetadataRequest
+	4,  // 25: maschine.plugin.v1.Plugin.Execute:input_type -> maschine.plugin.v1.ExecuteRequest
+	4,  // 26: maschine.plugin.v1.Plugin.Plan:input_type -> maschine.plugin.v1.ExecuteRequest
+	8,  // 27: maschine.plugin.v1.Plugin.ExecuteBatch:input_type -> maschine.plugin.v1.ExecuteBatchRequest
+	10, // 28: maschine.plugin.v1.Plugin.HealthCheck:input_type -> maschine.plugin.v1.HealthCheckRequest
+	13, // 29: maschine.plugin.v1.Plugin.GetManifest:input_type -> maschine.plugin.v1.GetManifestRequest
+	15, // 30: maschine.plugin.v1.Plugin.Upload:input_type -> maschine.plugin.v1.PayloadChunk
+	17, // 31: maschine.plugin.v1.Plugin.Download:input_type -> maschine.plugin.v1.DownloadRequest
+	18, // 32: maschine.plugin.v1.Plugin.Configure:input_type -> maschine.plugin.v1.ConfigureRequest
+	22, // 33: maschine.plugin.v1.Plugin.Shutdown:input_type -> maschine.plugin.v1.ShutdownRequest
+	24, // 34: maschine.plugin.v1.Plugin.OpenSession:input_type -> maschine.plugin.v1.OpenSessionRequest
+	26, // 35: maschine.plugin.v1.Plugin.CloseSession:input_type -> maschine.plugin.v1.CloseSessionRequest
+	28, // 36: maschine.plugin.v1.Plugin.Subscribe:input_type -> maschine.plugin.v1.SubscribeRequest
+	30, // 37: maschine.plugin.v1.Plugin.Acknowledge:input_type -> maschine.plugin.v1.AcknowledgeRequest
+	3,  // 38: maschine.plugin.v1.Plugin.GetMetadata:output_type -> maschine.plugin.v1.GetMetadataResponse
+	5,  // 39: maschine.plugin.v1.Plugin.Execute:output_type -> maschine.plugin.v1.ExecuteResponse
+	6,  // 40: maschine.plugin.v1.Plugin.Plan:output_type -> maschine.plugin.v1.PlanResponse
+	9,  // 41: maschine.plugin.v1.Plugin.ExecuteBatch:output_type -> maschine.plugin.v1.ExecuteBatchResponse
+	11, // 42: maschine.plugin.v1.Plugin.HealthCheck:output_type -> maschine.plugin.v1.HealthCheckResponse
+	14, // 43: maschine.plugin.v1.Plugin.GetManifest:output_type -> maschine.plugin.v1.GetManifestResponse
+	16, // 44: maschine.plugin.v1.Plugin.Upload:output_type -> maschine.plugin.v1.UploadResponse
+	15, // 45: maschine.plugin.v1.Plugin.Download:output_type -> maschine.plugin.v1.PayloadChunk
+	20, // 46: maschine.plugin.v1.Plugin.Configure:output_type -> maschine.plugin.v1.ConfigureResponse
+	23, // 47: maschine.plugin.v1.Plugin.Shutdown:output_type -> maschine.plugin.v1.ShutdownResponse
+	25, // 48: maschine.plugin.v1.Plugin.OpenSession:output_type -> maschine.plugin.v1.OpenSessionResponse
+	27, // 49: maschine.plugin.v1.Plugin.CloseSession:output_type -> maschine.plugin.v1.CloseSessionResponse
+	29, // 50: maschine.plugin.v1.Plugin.Subscribe:output_type -> maschine.plugin.v1.Event
+	31, // 51: maschine.plugin.v1.Plugin.Acknowledge:output_type -> maschine.plugin.v1.AcknowledgeResponse
+	38, // [38:52] is the sub-list for method output_type
+	24, // [24:38] is the sub-list for method input_type
+	24, // [24:24] is the sub-list for extension type_name
+	24, // [24:24] is the sub-list for extension extendee
+	0,  // [0:24] is the sub-list for field type_name
 }
 
 func init() { file_proto_plugin_v1_plugin_proto_init() }
@@ -451,13 +2069,14 @@ func file_proto_plugin_v1_plugin_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_plugin_v1_plugin_proto_rawDesc), len(file_proto_plugin_v1_plugin_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   11,
+			NumEnums:      2,
+			NumMessages:   41,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_proto_plugin_v1_plugin_proto_goTypes,
 		DependencyIndexes: file_proto_plugin_v1_plugin_proto_depIdxs,
+		EnumInfos:         file_proto_plugin_v1_plugin_proto_enumTypes,
 		MessageInfos:      file_proto_plugin_v1_plugin_proto_msgTypes,
 	}.Build()
 	File_proto_plugin_v1_plugin_proto = out.File
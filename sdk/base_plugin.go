@@ -0,0 +1,269 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+
+	"maschine.io/plugin-sdk/sdk/manifest"
+)
+
+var (
+	_ MaschineResource = (*BasePlugin)(nil)
+	_ ManifestProvider = (*BasePlugin)(nil)
+	_ Planner          = (*BasePlugin)(nil)
+	_ EventSource      = (*BasePlugin)(nil)
+)
+
+// SimpleFunction handles a single resource. The returned value is encoded
+// into ExecuteResponse.Output with the codec negotiated with the host, JSON
+// by default; a []byte result is passed through.
+type SimpleFunction func(ctx context.Context, req *TypedExecuteRequest) (any, error)
+
+// TriggerFunction watches a trigger resource and emits its events until ctx
+// is canceled
+type TriggerFunction func(ctx context.Context, emit EmitFunc) error
+
+// BasePlugin is a minimal MaschineResource that dispatches Execute calls to
+// registered functions and generates its metadata from the registrations
+type BasePlugin struct {
+	name    string
+	version string
+
+	mu        sync.RWMutex
+	functions map[string]registeredFunction
+	plans     map[string]PlanFunction
+	manifest  *manifest.PluginManifest
+	health    HealthChecks
+}
+
+type registeredFunction struct {
+	fn          SimpleFunction
+	trigger     TriggerFunction
+	description string
+}
+
+// NewBasePlugin creates a new plugin without any registered functions
+func NewBasePlugin(name, version string) *BasePlugin {
+	return &BasePlugin{
+		name:      name,
+		version:   version,
+		functions: make(map[string]registeredFunction),
+		plans:     make(map[string]PlanFunction),
+	}
+}
+
+// Name returns the plugin name
+func (p *BasePlugin) Name() string {
+	return p.name
+}
+
+// Version returns the plugin version
+func (p *BasePlugin) Version() string {
+	return p.version
+}
+
+// SetManifest sets the manifest returned by GetManifest
+func (p *BasePlugin) SetManifest(m *manifest.PluginManifest) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.manifest = m
+}
+
+// GetManifest returns the manifest set with SetManifest or
+// ErrManifestNotProvided if none was set
+func (p *BasePlugin) GetManifest(ctx context.Context) (*manifest.PluginManifest, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.manifest == nil {
+		return nil, ErrManifestNotProvided
+	}
+	return p.manifest, nil
+}
+
+// RegisterSimpleFunction registers fn as handler for the given resource
+func (p *BasePlugin) RegisterSimpleFunction(resource string, fn SimpleFunction, description string) error {
+	if resource == "" {
+		return fmt.Errorf("resource name is required")
+	}
+	if fn == nil {
+		return fmt.Errorf("function for resource %s is nil", resource)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, found := p.functions[resource]; found {
+		return fmt.Errorf("function already registered: %s", resource)
+	}
+	p.functions[resource] = registeredFunction{fn: fn, description: description}
+	return nil
+}
+
+// RegisterTrigger registers fn to watch the given trigger resource while
+// the host is subscribed to it
+func (p *BasePlugin) RegisterTrigger(resource string, fn TriggerFunction, description string) error {
+	if resource == "" {
+		return fmt.Errorf("resource name is required")
+	}
+	if fn == nil {
+		return fmt.Errorf("trigger for resource %s is nil", resource)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if _, found := p.functions[resource]; found {
+		return fmt.Errorf("function already registered: %s", resource)
+	}
+	p.functions[resource] = registeredFunction{trigger: fn, description: description}
+	return nil
+}
+
+// RegisterPlanFunction registers fn to preview the function registered for
+// the given resource. Dry runs of resources without a PlanFunction fail.
+func (p *BasePlugin) RegisterPlanFunction(resource string, fn PlanFunction) error {
+	if fn == nil {
+		return fmt.Errorf("plan function for resource %s is nil", resource)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if f, found := p.functions[resource]; !found || f.trigger != nil {
+		return fmt.Errorf("function not registered: %s", resource)
+	}
+	if _, found := p.plans[resource]; found {
+		return fmt.Errorf("plan function already registered: %s", resource)
+	}
+	p.plans[resource] = fn
+	return nil
+}
+
+// AddHealthCheck registers a dependency check that HealthCheck runs
+func (p *BasePlugin) AddHealthCheck(c DependencyCheck) error {
+	return p.health.Add(c)
+}
+
+// ResourceNames returns the sorted names of all registered resources
+func (p *BasePlugin) ResourceNames() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	result := make([]string, 0, len(p.functions))
+	for name := range p.functions {
+		result = append(result, name)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// GetMetadata returns the plugin metadata. Capabilities maps every
+// registered resource to its description.
+func (p *BasePlugin) GetMetadata(ctx context.Context, req *GetMetadataRequest) (*GetMetadataResponse, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	resources := make([]string, 0, len(p.functions))
+	capabilities := make(map[string]string, len(p.functions))
+	for name, f := range p.functions {
+		resources = append(resources, name)
+		capabilities[name] = f.description
+	}
+	sort.Strings(resources)
+
+	return &GetMetadataResponse{
+		Name:               p.name,
+		Version:            p.version,
+		SupportedResources: resources,
+		Capabilities:       capabilities,
+	}, nil
+}
+
+// Execute runs the function registered for the requested resource
+func (p *BasePlugin) Execute(ctx context.Context, req *ExecuteRequest) (*ExecuteResponse, error) {
+	p.mu.RLock()
+	f, found := p.functions[req.Resource]
+	p.mu.RUnlock()
+
+	if !found {
+		return &ExecuteResponse{
+			Error: fmt.Sprintf("unknown resource: %s", req.Resource),
+		}, nil
+	}
+	if f.trigger != nil {
+		return &ExecuteResponse{
+			Error: fmt.Sprintf("resource %s is a trigger and cannot be executed", req.Resource),
+		}, nil
+	}
+
+	if req.DryRun {
+		changes, err := p.Plan(ctx, req)
+		if err != nil {
+			return errorResponse(err), nil
+		}
+		output, err := json.Marshal(changes)
+		if err != nil {
+			return &ExecuteResponse{
+				Error: fmt.Sprintf("failed to encode plan: %v", err),
+			}, nil
+		}
+		return &ExecuteResponse{Output: output, ContentType: ContentTypeJSON}, nil
+	}
+
+	result, err := f.fn(ctx, &TypedExecuteRequest{ExecuteRequest: req})
+	if err != nil {
+		return errorResponse(err), nil
+	}
+
+	output, contentType, err := encodeOutput(ctx, result)
+	if err != nil {
+		return &ExecuteResponse{
+			Error: fmt.Sprintf("failed to encode output: %v", err),
+		}, nil
+	}
+
+	return &ExecuteResponse{Output: output, ContentType: contentType}, nil
+}
+
+// Plan runs the PlanFunction registered for the requested resource
+func (p *BasePlugin) Plan(ctx context.Context, req *ExecuteRequest) ([]Change, error) {
+	p.mu.RLock()
+	fn, found := p.plans[req.Resource]
+	p.mu.RUnlock()
+
+	if !found {
+		return nil, fmt.Errorf("resource %s does not support dry runs", req.Resource)
+	}
+
+	changes, err := fn(ctx, &TypedExecuteRequest{ExecuteRequest: req})
+	if err != nil {
+		return nil, err
+	}
+	if changes == nil {
+		changes = []Change{}
+	}
+	return changes, nil
+}
+
+// Watch runs the TriggerFunction registered for the resource
+func (p *BasePlugin) Watch(ctx context.Context, resource string, emit EmitFunc) error {
+	p.mu.RLock()
+	f, found := p.functions[resource]
+	p.mu.RUnlock()
+
+	if !found || f.trigger == nil {
+		return fmt.Errorf("unknown trigger: %s", resource)
+	}
+	return f.trigger(ctx, emit)
+}
+
+// HealthCheck runs the dependency checks of the probe added with
+// AddHealthCheck. Without failed checks, the plugin is healthy.
+func (p *BasePlugin) HealthCheck(ctx context.Context, req *HealthCheckRequest) (*HealthCheckResponse, error) {
+	resp := p.health.Run(ctx, req.Probe)
+	if resp.Message == "" {
+		resp.Message = fmt.Sprintf("%s is operational", p.name)
+	}
+	return resp, nil
+}
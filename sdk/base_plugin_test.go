@@ -0,0 +1,104 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type greetParams struct {
+	Name  string `json:"name"`
+	Times int    `json:"times"`
+}
+
+func newGreetPlugin(t *testing.T) *BasePlugin {
+	t.Helper()
+
+	p := NewBasePlugin("greet-plugin", "1.2.3")
+	require.NoError(t, p.RegisterSimpleFunction("mrn:greet:hello:say", func(ctx context.Context, req *TypedExecuteRequest) (any, error) {
+		var params greetParams
+		if err := req.GetParameters(&params); err != nil {
+			return nil, err
+		}
+		return map[string]any{"greeting": "hello " + params.Name, "times": params.Times}, nil
+	}, "Say hello"))
+	require.NoError(t, p.RegisterSimpleFunction("mrn:greet:hello:fail", func(ctx context.Context, req *TypedExecuteRequest) (any, error) {
+		return nil, errors.New("boom")
+	}, "Always fails"))
+	return p
+}
+
+func TestBasePluginRegister(t *testing.T) {
+	p := newGreetPlugin(t)
+
+	err := p.RegisterSimpleFunction("mrn:greet:hello:say", func(ctx context.Context, req *TypedExecuteRequest) (any, error) {
+		return nil, nil
+	}, "duplicate")
+	assert.Error(t, err, "duplicate registration should fail")
+	assert.Error(t, p.RegisterSimpleFunction("", nil, ""))
+	assert.Equal(t, []string{"mrn:greet:hello:fail", "mrn:greet:hello:say"}, p.ResourceNames())
+}
+
+func TestBasePluginGetMetadata(t *testing.T) {
+	p := newGreetPlugin(t)
+
+	md, err := p.GetMetadata(context.Background(), &GetMetadataRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "greet-plugin", md.Name)
+	assert.Equal(t, "1.2.3", md.Version)
+	assert.Equal(t, []string{"mrn:greet:hello:fail", "mrn:greet:hello:say"}, md.SupportedResources)
+	assert.Equal(t, "Say hello", md.Capabilities["mrn:greet:hello:say"])
+}
+
+func TestBasePluginExecute(t *testing.T) {
+	p := newGreetPlugin(t)
+
+	t.Run("success", func(t *testing.T) {
+		resp, err := p.Execute(context.Background(), &ExecuteRequest{
+			Resource: "mrn:greet:hello:say",
+			Parameters: map[string][]byte{
+				"name":  []byte("world"),
+				"times": []byte("2"),
+			},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, resp.Error)
+		assert.JSONEq(t, `{"greeting":"hello world","times":2}`, string(resp.Output))
+	})
+
+	t.Run("function error", func(t *testing.T) {
+		resp, err := p.Execute(context.Background(), &ExecuteRequest{Resource: "mrn:greet:hello:fail"})
+		require.NoError(t, err)
+		assert.Equal(t, "boom", resp.Error)
+	})
+
+	t.Run("unknown resource", func(t *testing.T) {
+		resp, err := p.Execute(context.Background(), &ExecuteRequest{Resource: "mrn:greet:hello:unknown"})
+		require.NoError(t, err)
+		assert.Equal(t, "unknown resource: mrn:greet:hello:unknown", resp.Error)
+	})
+}
+
+func TestTypedExecuteRequestGetParameter(t *testing.T) {
+	req := &TypedExecuteRequest{ExecuteRequest: &ExecuteRequest{
+		Parameters: map[string][]byte{"count": []byte("3"), "to": []byte(`"a@b.io"`)},
+	}}
+
+	var count int
+	found, err := req.GetParameter("count", &count)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, 3, count)
+
+	var to string
+	_, err = req.GetParameter("to", &to)
+	require.NoError(t, err)
+	assert.Equal(t, "a@b.io", to)
+
+	found, err = req.GetParameter("missing", &to)
+	require.NoError(t, err)
+	assert.False(t, found)
+}
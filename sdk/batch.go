@@ -0,0 +1,220 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	pluginv1 "maschine.io/plugin-sdk/proto/plugin/v1"
+	"maschine.io/plugin-sdk/sdk/logger"
+)
+
+// DefaultBatchConcurrency is the default for MaschinePlugin.BatchConcurrency
+const DefaultBatchConcurrency = 8
+
+// BatchExecutor is implemented by plugins that run many executions more
+// efficiently than one by one, for example with a single database round
+// trip. It returns one response per request in the same order; failed
+// executions report their error in the response. Plugins that do not
+// implement it run batches with ExecuteEach.
+//
+// BatchExecutor is only used if the plugin passed to MaschinePlugin
+// implements it directly and no request of the batch runs in a session.
+// Wrapped plugins, like WithParameterValidation, run batches through the
+// Execute of the wrapper.
+type BatchExecutor interface {
+	ExecuteBatch(context.Context, []*ExecuteRequest) ([]*ExecuteResponse, error)
+}
+
+// ExecuteEach runs the requests with res.Execute, at most concurrency at a
+// time. Errors and panics of single executions are reported in their
+// response. A concurrency of zero or less uses DefaultBatchConcurrency.
+func ExecuteEach(ctx context.Context, res MaschineResource, reqs []*ExecuteRequest, concurrency int) []*ExecuteResponse {
+	if concurrency <= 0 {
+		concurrency = DefaultBatchConcurrency
+	}
+
+	resps := make([]*ExecuteResponse, len(reqs))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			resps[i] = &ExecuteResponse{Error: ctx.Err().Error()}
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			resps[i] = executeOne(ctx, res, req)
+		}()
+	}
+	wg.Wait()
+	return resps
+}
+
+// executeOne runs a single execution of a batch
+func executeOne(ctx context.Context, res MaschineResource, req *ExecuteRequest) (resp *ExecuteResponse) {
+	defer func() {
+		if v := recover(); v != nil {
+			resp = &ExecuteResponse{Error: (&PanicError{Value: logger.Redact(fmt.Sprint(v))}).Error()}
+		}
+	}()
+
+	resp, err := res.Execute(ctx, req)
+	if err != nil {
+		return errorResponse(err)
+	}
+	if resp == nil {
+		return &ExecuteResponse{}
+	}
+	return resp
+}
+
+func (s *grpcServer) ExecuteBatch(ctx context.Context, req *pluginv1.ExecuteBatchRequest) (_ *pluginv1.ExecuteBatchResponse, err error) {
+	defer recoverPanic("ExecuteBatch", &err)
+
+	if !s.begin() {
+		return nil, status.Error(codes.Unavailable, ErrShuttingDown.Error())
+	}
+	defer s.end()
+
+	reqs := make([]*ExecuteRequest, len(req.Requests))
+	for i, r := range req.Requests {
+		if r, err = s.resolveRequest(r); err != nil {
+			return nil, err
+		}
+		if err := s.checkDryRun(ctx, r); err != nil {
+			return nil, err
+		}
+		reqs[i] = fromProtoRequest(r)
+	}
+
+	ctx = incomingLog(incomingAccept(ctx))
+
+	// Executions in sessions need the session in their context
+	inSession := false
+	for _, r := range reqs {
+		inSession = inSession || r.SessionID != ""
+	}
+
+	var resps []*ExecuteResponse
+	if batcher, ok := s.Impl.(BatchExecutor); ok && !inSession {
+		if resps, err = batcher.ExecuteBatch(ctx, reqs); err != nil {
+			return nil, err
+		}
+		if len(resps) != len(reqs) {
+			return nil, status.Errorf(codes.Internal, "plugin returned %d responses for %d requests", len(resps), len(reqs))
+		}
+	} else {
+		resps = ExecuteEach(ctx, &sessionResource{MaschineResource: s.Impl, server: s}, reqs, s.batchConcurrency)
+	}
+
+	// Responses are offloaded once the batch exceeds the threshold
+	remaining := s.transfer.Threshold
+	result := &pluginv1.ExecuteBatchResponse{}
+	for _, r := range resps {
+		if r == nil {
+			r = &ExecuteResponse{}
+		}
+		pbResp, err := s.offloadAbove(toProtoResponse(r), remaining)
+		if err != nil {
+			return nil, err
+		}
+		remaining -= proto.Size(pbResp)
+		result.Responses = append(result.Responses, pbResp)
+	}
+	return result, nil
+}
+
+// ExecuteBatch runs the requests in as few calls as the threshold of the
+// transfer configuration allows. Requests and responses above the
+// threshold are transferred in chunks. Plugins built with SDKs without
+// batch support run the requests with ExecuteEach.
+func (c *grpcClient) ExecuteBatch(ctx context.Context, reqs []*ExecuteRequest) ([]*ExecuteResponse, error) {
+	resps := make([]*ExecuteResponse, 0, len(reqs))
+
+	var batch []*pluginv1.ExecuteRequest
+	start, size := 0, 0
+	send := func(end int) error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		sent, err := c.executeBatch(ctx, batch)
+		if status.Code(err) == codes.Unimplemented {
+			// the requests from start on are executed one by one
+			c.batchUnimplemented.Store(true)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		resps = append(resps, sent...)
+		batch, start, size = nil, end, 0
+		return nil
+	}
+
+	for i, req := range reqs {
+		// nothing is uploaded for plugins without ExecuteBatch
+		if c.batchUnimplemented.Load() {
+			break
+		}
+		pbReq := toProtoRequest(req)
+		n := proto.Size(pbReq)
+		if n > c.transfer.Threshold {
+			id, err := c.upload(ctx, pbReq)
+			if err != nil {
+				return nil, err
+			}
+			pbReq = &pluginv1.ExecuteRequest{PayloadId: id}
+			n = proto.Size(pbReq)
+		}
+
+		if size+n > c.transfer.Threshold {
+			if err := send(i); err != nil {
+				return nil, err
+			}
+		}
+		batch = append(batch, pbReq)
+		size += n
+	}
+	if !c.batchUnimplemented.Load() {
+		if err := send(len(reqs)); err != nil {
+			return nil, err
+		}
+	}
+	if c.batchUnimplemented.Load() {
+		resps = append(resps, ExecuteEach(ctx, c, reqs[start:], 0)...)
+	}
+	return resps, nil
+}
+
+// executeBatch sends a single ExecuteBatch call and downloads the
+// offloaded responses
+func (c *grpcClient) executeBatch(ctx context.Context, batch []*pluginv1.ExecuteRequest) ([]*ExecuteResponse, error) {
+	resp, err := c.client.ExecuteBatch(outgoingLog(outgoingAccept(ctx)), &pluginv1.ExecuteBatchRequest{Requests: batch})
+	if err != nil {
+		return nil, clientError(err)
+	}
+	if len(resp.Responses) != len(batch) {
+		return nil, fmt.Errorf("plugin returned %d responses for %d requests", len(resp.Responses), len(batch))
+	}
+
+	resps := make([]*ExecuteResponse, len(resp.Responses))
+	for i, r := range resp.Responses {
+		if r.PayloadId != "" {
+			if r, err = c.download(ctx, r.PayloadId); err != nil {
+				return nil, err
+			}
+		}
+		resps[i] = fromProtoResponse(r)
+	}
+	return resps, nil
+}
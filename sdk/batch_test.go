@@ -0,0 +1,184 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	pluginv1 "maschine.io/plugin-sdk/proto/plugin/v1"
+)
+
+// countingPlugin counts the concurrent executions of its resource
+type countingPlugin struct {
+	*BasePlugin
+	running atomic.Int32
+	max     atomic.Int32
+}
+
+func newCountingPlugin(t *testing.T) *countingPlugin {
+	t.Helper()
+
+	p := &countingPlugin{BasePlugin: NewBasePlugin("counting-plugin", "1.0.0")}
+	require.NoError(t, p.RegisterSimpleFunction("mrn:counting:item:process", func(ctx context.Context, req *TypedExecuteRequest) (any, error) {
+		n := p.running.Add(1)
+		defer p.running.Add(-1)
+		for {
+			max := p.max.Load()
+			if n <= max || p.max.CompareAndSwap(max, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		switch string(req.Input) {
+		case "fail":
+			return nil, errors.New("item rejected")
+		case "panic":
+			panic("corrupt item")
+		}
+		return string(req.Input), nil
+	}, "Process an item"))
+	return p
+}
+
+func TestExecuteEach(t *testing.T) {
+	p := newCountingPlugin(t)
+
+	inputs := []string{"a", "fail", "b", "panic", "c", "d", "e", "f"}
+	reqs := make([]*ExecuteRequest, len(inputs))
+	for i, input := range inputs {
+		reqs[i] = &ExecuteRequest{Resource: "mrn:counting:item:process", Input: []byte(input)}
+	}
+
+	resps := ExecuteEach(context.Background(), p, reqs, 3)
+	require.Len(t, resps, len(inputs))
+	assert.LessOrEqual(t, p.max.Load(), int32(3))
+
+	for i, input := range inputs {
+		switch input {
+		case "fail":
+			assert.Equal(t, "item rejected", resps[i].Error)
+		case "panic":
+			assert.Equal(t, "plugin panicked: corrupt item", resps[i].Error)
+		default:
+			assert.Empty(t, resps[i].Error)
+			assert.JSONEq(t, strconv.Quote(input), string(resps[i].Output))
+		}
+	}
+}
+
+// batchPlugin implements BatchExecutor and records the batch sizes
+type batchPlugin struct {
+	*BasePlugin
+	batches []int
+}
+
+func (p *batchPlugin) ExecuteBatch(ctx context.Context, reqs []*ExecuteRequest) ([]*ExecuteResponse, error) {
+	p.batches = append(p.batches, len(reqs))
+	resps := make([]*ExecuteResponse, len(reqs))
+	for i, req := range reqs {
+		resps[i] = &ExecuteResponse{Output: bytes.ToUpper(req.Input)}
+	}
+	return resps, nil
+}
+
+func TestGRPCExecuteBatch(t *testing.T) {
+	t.Run("executes each request", func(t *testing.T) {
+		client := dispenseTestClient(t, newCountingPlugin(t)).(BatchExecutor)
+
+		resps, err := client.ExecuteBatch(context.Background(), []*ExecuteRequest{
+			{Resource: "mrn:counting:item:process", Input: []byte("a")},
+			{Resource: "mrn:counting:item:unknown"},
+			{Resource: "mrn:counting:item:process", Input: []byte("fail")},
+		})
+		require.NoError(t, err)
+		require.Len(t, resps, 3)
+		assert.JSONEq(t, `"a"`, string(resps[0].Output))
+		assert.Equal(t, "unknown resource: mrn:counting:item:unknown", resps[1].Error)
+		assert.Equal(t, "item rejected", resps[2].Error)
+	})
+
+	t.Run("uses BatchExecutor", func(t *testing.T) {
+		impl := &batchPlugin{BasePlugin: NewBasePlugin("batch-plugin", "1.0.0")}
+		client := dispenseTestClient(t, impl).(BatchExecutor)
+
+		resps, err := client.ExecuteBatch(context.Background(), []*ExecuteRequest{
+			{Input: []byte("a")},
+			{Input: []byte("b")},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []int{2}, impl.batches)
+		assert.Equal(t, []byte("A"), resps[0].Output)
+		assert.Equal(t, []byte("B"), resps[1].Output)
+	})
+
+	t.Run("splits and transfers large batches in chunks", func(t *testing.T) {
+		impl := &batchPlugin{BasePlugin: NewBasePlugin("batch-plugin", "1.0.0")}
+		client := dispenseTestPlugin(t, &MaschinePlugin{
+			Impl:     impl,
+			Transfer: TransferConfig{Threshold: 1024, ChunkSize: 100, MaxPayloadSize: 64 * 1024},
+		}).(BatchExecutor)
+
+		var reqs []*ExecuteRequest
+		for _, size := range []int{400, 400, 400, 4096, 10} {
+			reqs = append(reqs, &ExecuteRequest{Input: bytes.Repeat([]byte("x"), size)})
+		}
+
+		resps, err := client.ExecuteBatch(context.Background(), reqs)
+		require.NoError(t, err)
+		require.Len(t, resps, len(reqs))
+		for i, req := range reqs {
+			assert.Equal(t, bytes.ToUpper(req.Input), resps[i].Output)
+		}
+		assert.Equal(t, []int{2, 3}, impl.batches)
+	})
+	t.Run("falls back once to single executions", func(t *testing.T) {
+		client := dispenseTestPlugin(t, &MaschinePlugin{
+			Impl:     newCountingPlugin(t),
+			Transfer: TransferConfig{Threshold: 1024, ChunkSize: 100, MaxPayloadSize: 64 * 1024},
+		}).(*grpcClient)
+		legacy := &unbatchedClient{PluginClient: client.client}
+		client.client = legacy
+
+		var reqs []*ExecuteRequest
+		for _, size := range []int{400, 400, 400, 4096, 10} {
+			reqs = append(reqs, &ExecuteRequest{Resource: "mrn:counting:item:process", Input: bytes.Repeat([]byte("x"), size)})
+		}
+		for range 2 {
+			resps, err := client.ExecuteBatch(context.Background(), reqs)
+			require.NoError(t, err)
+			require.Len(t, resps, len(reqs))
+			for i, req := range reqs {
+				assert.JSONEq(t, strconv.Quote(string(req.Input)), string(resps[i].Output))
+			}
+		}
+		assert.Equal(t, int32(1), legacy.batches.Load(), "ExecuteBatch is probed once")
+		assert.Equal(t, int32(2), legacy.uploads.Load(), "only single executions upload")
+	})
+}
+
+// unbatchedClient talks to a plugin that predates ExecuteBatch
+type unbatchedClient struct {
+	pluginv1.PluginClient
+	batches atomic.Int32
+	uploads atomic.Int32
+}
+
+func (c *unbatchedClient) ExecuteBatch(ctx context.Context, in *pluginv1.ExecuteBatchRequest, opts ...grpc.CallOption) (*pluginv1.ExecuteBatchResponse, error) {
+	c.batches.Add(1)
+	return nil, status.Error(codes.Unimplemented, "method ExecuteBatch not implemented")
+}
+
+func (c *unbatchedClient) Upload(ctx context.Context, opts ...grpc.CallOption) (grpc.ClientStreamingClient[pluginv1.PayloadChunk, pluginv1.UploadResponse], error) {
+	c.uploads.Add(1)
+	return c.PluginClient.Upload(ctx, opts...)
+}
@@ -0,0 +1,92 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// CheckStatus is the outcome of a check resource
+type CheckStatus string
+
+const (
+	CheckPass CheckStatus = "pass"
+	// CheckWarn means the checked system works, but needs attention, like a
+	// certificate that expires soon
+	CheckWarn CheckStatus = "warn"
+	CheckFail CheckStatus = "fail"
+)
+
+// CheckResult is the output of a resource with category check, like a
+// compliance rule or a connectivity test
+type CheckResult struct {
+	Status CheckStatus `json:"status"`
+	// Message summarizes the outcome
+	Message string `json:"message,omitempty"`
+	// Evidence are the observations the status is based on, e.g. the
+	// expiry date of a certificate
+	Evidence map[string]string `json:"evidence,omitempty"`
+	// Remediation tells how to fix a failed or warning check
+	Remediation string `json:"remediation,omitempty"`
+	// Duration is how long the check took. RegisterCheck measures it if
+	// the CheckFunction does not set it.
+	Duration time.Duration `json:"duration"`
+}
+
+// Validate checks that the result has a known status
+func (r *CheckResult) Validate() error {
+	switch r.Status {
+	case CheckPass, CheckWarn, CheckFail:
+		return nil
+	}
+	return fmt.Errorf("invalid check status: %q", r.Status)
+}
+
+// CheckFunction runs a check resource. An error means the check could not
+// run at all; a check that ran and found a problem returns a result with
+// CheckFail.
+type CheckFunction func(ctx context.Context, req *TypedExecuteRequest) (*CheckResult, error)
+
+// DecodeCheckResult decodes the output of a check resource
+func DecodeCheckResult(resp *ExecuteResponse) (*CheckResult, error) {
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	var result CheckResult
+	if err := json.Unmarshal(resp.Output, &result); err != nil {
+		return nil, fmt.Errorf("failed to decode check result: %w", err)
+	}
+	if err := result.Validate(); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// RegisterCheck registers fn as handler for the given check resource. The
+// CheckResult is always JSON encoded, so hosts can decode it with
+// DecodeCheckResult.
+func (p *BasePlugin) RegisterCheck(resource string, fn CheckFunction, description string) error {
+	if fn == nil {
+		return fmt.Errorf("check for resource %s is nil", resource)
+	}
+	return p.RegisterSimpleFunction(resource, func(ctx context.Context, req *TypedExecuteRequest) (any, error) {
+		start := time.Now()
+		result, err := fn(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if result == nil {
+			return nil, errors.New("check returned no result")
+		}
+		if err := result.Validate(); err != nil {
+			return nil, err
+		}
+		if result.Duration == 0 {
+			result.Duration = time.Since(start)
+		}
+		// the codec is not negotiated, check results are always JSON
+		return json.Marshal(result)
+	}, description)
+}
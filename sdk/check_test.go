@@ -0,0 +1,63 @@
+package sdk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterCheck(t *testing.T) {
+	p := NewBasePlugin("tls-plugin", "1.0.0")
+	require.NoError(t, p.RegisterCheck("mrn:tls:cert:check", func(ctx context.Context, req *TypedExecuteRequest) (*CheckResult, error) {
+		var host string
+		if _, err := req.GetParameter("host", &host); err != nil {
+			return nil, err
+		}
+		switch host {
+		case "expiring.example.com":
+			time.Sleep(time.Millisecond)
+			return &CheckResult{
+				Status:      CheckWarn,
+				Message:     "certificate expires in 5 days",
+				Evidence:    map[string]string{"notAfter": "2026-10-23"},
+				Remediation: "Renew the certificate",
+			}, nil
+		case "invalid.example.com":
+			return &CheckResult{Status: "ok"}, nil
+		case "nil.example.com":
+			return nil, nil
+		}
+		return &CheckResult{Status: CheckPass, Duration: time.Second}, nil
+	}, "Certificate expiry"))
+	assert.EqualError(t, p.RegisterCheck("mrn:tls:cert:other", nil, ""), "check for resource mrn:tls:cert:other is nil")
+	client := dispenseTestClient(t, p)
+
+	check := func(host string) (*CheckResult, error) {
+		req := &ExecuteRequest{Resource: "mrn:tls:cert:check"}
+		require.NoError(t, req.SetParameter("host", ContentTypeText, host))
+		// check results are JSON regardless of the accepted content types
+		resp, err := client.Execute(ContextWithAccept(context.Background(), ContentTypeCBOR), req)
+		require.NoError(t, err)
+		return DecodeCheckResult(resp)
+	}
+
+	result, err := check("expiring.example.com")
+	require.NoError(t, err)
+	assert.Equal(t, CheckWarn, result.Status)
+	assert.Equal(t, "certificate expires in 5 days", result.Message)
+	assert.Equal(t, map[string]string{"notAfter": "2026-10-23"}, result.Evidence)
+	assert.Equal(t, "Renew the certificate", result.Remediation)
+	assert.GreaterOrEqual(t, result.Duration, time.Millisecond)
+
+	result, err = check("example.com")
+	require.NoError(t, err)
+	assert.Equal(t, time.Second, result.Duration, "reported durations are kept")
+
+	_, err = check("invalid.example.com")
+	assert.EqualError(t, err, `invalid check status: "ok"`)
+	_, err = check("nil.example.com")
+	assert.EqualError(t, err, "check returned no result")
+}
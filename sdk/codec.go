@@ -0,0 +1,357 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Content types of the built-in codecs. Payloads without content type are
+// JSON, parameters that are not valid JSON are plain strings.
+const (
+	ContentTypeJSON        = "application/json"
+	ContentTypeCBOR        = "application/cbor"
+	ContentTypeMsgPack     = "application/msgpack"
+	ContentTypeProtoStruct = "application/x-protobuf-struct"
+	ContentTypeText        = "text/plain"
+	ContentTypeBinary      = "application/octet-stream"
+)
+
+// Codec encodes Go values into payloads of a content type and back
+type Codec interface {
+	ContentType() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+var codecs = struct {
+	sync.RWMutex
+	byType map[string]Codec
+}{byType: make(map[string]Codec)}
+
+func init() {
+	RegisterCodec(jsonCodec{})
+	RegisterCodec(cborCodec{})
+	RegisterCodec(msgpackCodec{})
+	RegisterCodec(structCodec{})
+	RegisterCodec(textCodec{})
+	RegisterCodec(binaryCodec{})
+}
+
+// RegisterCodec adds c to the codec registry, replacing the codec of the
+// same content type
+func RegisterCodec(c Codec) {
+	codecs.Lock()
+	defer codecs.Unlock()
+	codecs.byType[mediaType(c.ContentType())] = c
+}
+
+// LookupCodec returns the codec for a content type. Parameters of the
+// content type like charset are ignored; an empty content type is JSON.
+func LookupCodec(contentType string) (Codec, bool) {
+	if contentType == "" {
+		contentType = ContentTypeJSON
+	}
+	codecs.RLock()
+	defer codecs.RUnlock()
+	c, found := codecs.byType[mediaType(contentType)]
+	return c, found
+}
+
+// ContentTypes returns the sorted content types of all registered codecs
+func ContentTypes() []string {
+	codecs.RLock()
+	defer codecs.RUnlock()
+	return sortedKeys(codecs.byType)
+}
+
+// NegotiateCodec returns the codec of the first content type in accept
+// that is registered, or the JSON codec if there is none
+func NegotiateCodec(accept []string) Codec {
+	for _, contentType := range accept {
+		if c, found := LookupCodec(contentType); found && contentType != "" {
+			return c
+		}
+	}
+	c, _ := LookupCodec(ContentTypeJSON)
+	return c
+}
+
+// mediaType strips the parameters of a content type
+func mediaType(contentType string) string {
+	t, _, _ := strings.Cut(contentType, ";")
+	return strings.ToLower(strings.TrimSpace(t))
+}
+
+// Encode encodes v with the codec of contentType
+func Encode(contentType string, v any) ([]byte, error) {
+	c, found := LookupCodec(contentType)
+	if !found {
+		return nil, fmt.Errorf("unsupported content type: %s", contentType)
+	}
+	return c.Marshal(v)
+}
+
+// Decode decodes data with the codec of contentType into v
+func Decode(contentType string, data []byte, v any) error {
+	c, found := LookupCodec(contentType)
+	if !found {
+		return fmt.Errorf("unsupported content type: %s", contentType)
+	}
+	return c.Unmarshal(data, v)
+}
+
+// SetInput encodes v with the codec of contentType into the input
+func (r *ExecuteRequest) SetInput(contentType string, v any) error {
+	data, err := Encode(contentType, v)
+	if err != nil {
+		return fmt.Errorf("failed to encode input: %w", err)
+	}
+	r.Input = data
+	r.ContentType = contentType
+	return nil
+}
+
+// SetParameter encodes v with the codec of contentType into the parameter
+// name
+func (r *ExecuteRequest) SetParameter(name, contentType string, v any) error {
+	data, err := Encode(contentType, v)
+	if err != nil {
+		return fmt.Errorf("failed to encode parameter %s: %w", name, err)
+	}
+	if r.Parameters == nil {
+		r.Parameters = make(map[string][]byte)
+	}
+	if r.ParameterContentTypes == nil {
+		r.ParameterContentTypes = make(map[string]string)
+	}
+	r.Parameters[name] = data
+	r.ParameterContentTypes[name] = contentType
+	return nil
+}
+
+// DecodeOutput decodes the output into v with the codec of its content type
+func (r *ExecuteResponse) DecodeOutput(v any) error {
+	if len(r.Output) == 0 {
+		return nil
+	}
+	if err := Decode(r.ContentType, r.Output, v); err != nil {
+		return fmt.Errorf("failed to decode output: %w", err)
+	}
+	return nil
+}
+
+// acceptHeader is the gRPC metadata with the content types the host
+// accepts for outputs
+const acceptHeader = "maschine-accept"
+
+type acceptContextKey struct{}
+
+// ContextWithAccept returns a copy of ctx with the content types the host
+// accepts for outputs, in order of preference. The plugin encodes outputs
+// with the first one it has a codec for, see AcceptFromContext.
+func ContextWithAccept(ctx context.Context, contentTypes ...string) context.Context {
+	return context.WithValue(ctx, acceptContextKey{}, contentTypes)
+}
+
+// AcceptFromContext returns the content types the host accepts for outputs
+func AcceptFromContext(ctx context.Context) []string {
+	accept, _ := ctx.Value(acceptContextKey{}).([]string)
+	return accept
+}
+
+// outgoingAccept sends the accepted content types of ctx to the plugin
+func outgoingAccept(ctx context.Context) context.Context {
+	accept := AcceptFromContext(ctx)
+	if len(accept) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, acceptHeader, strings.Join(accept, ","))
+}
+
+// incomingAccept makes the content types accepted by the host available
+// with AcceptFromContext
+func incomingAccept(ctx context.Context) context.Context {
+	var accept []string
+	for _, v := range metadata.ValueFromIncomingContext(ctx, acceptHeader) {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				accept = append(accept, t)
+			}
+		}
+	}
+	if len(accept) == 0 {
+		return ctx
+	}
+	return ContextWithAccept(ctx, accept...)
+}
+
+// encodeOutput encodes the result of a SimpleFunction with the codec
+// negotiated for ctx. A []byte result is passed through without content
+// type.
+func encodeOutput(ctx context.Context, result any) ([]byte, string, error) {
+	switch v := result.(type) {
+	case nil:
+		return nil, "", nil
+	case []byte:
+		return v, "", nil
+	}
+	c := NegotiateCodec(AcceptFromContext(ctx))
+	data, err := c.Marshal(result)
+	return data, c.ContentType(), err
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string { return ContentTypeJSON }
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// cborDecMode decodes CBOR maps into map[string]any like encoding/json
+var cborDecMode, _ = cbor.DecOptions{DefaultMapType: reflect.TypeOf(map[string]any(nil))}.DecMode()
+
+// cborCodec encodes values as CBOR. Struct fields use their json tags
+// unless they have cbor tags.
+type cborCodec struct{}
+
+func (cborCodec) ContentType() string { return ContentTypeCBOR }
+
+func (cborCodec) Marshal(v any) ([]byte, error) {
+	return cbor.Marshal(v)
+}
+
+func (cborCodec) Unmarshal(data []byte, v any) error {
+	return cborDecMode.Unmarshal(data, v)
+}
+
+// msgpackCodec encodes values as MessagePack. Struct fields use their json
+// tags.
+type msgpackCodec struct{}
+
+func (msgpackCodec) ContentType() string { return ContentTypeMsgPack }
+
+func (msgpackCodec) Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (msgpackCodec) Unmarshal(data []byte, v any) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag("json")
+	return dec.Decode(v)
+}
+
+// structCodec encodes values as google.protobuf.Struct. Values other than
+// *structpb.Struct are converted through JSON and must be JSON objects.
+type structCodec struct{}
+
+func (structCodec) ContentType() string { return ContentTypeProtoStruct }
+
+func (structCodec) Marshal(v any) ([]byte, error) {
+	s, ok := v.(*structpb.Struct)
+	if !ok {
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		s = &structpb.Struct{}
+		if err := protojson.Unmarshal(data, s); err != nil {
+			return nil, fmt.Errorf("value is not an object: %w", err)
+		}
+	}
+	return proto.Marshal(s)
+}
+
+func (structCodec) Unmarshal(data []byte, v any) error {
+	s := &structpb.Struct{}
+	if err := proto.Unmarshal(data, s); err != nil {
+		return err
+	}
+	if target, ok := v.(*structpb.Struct); ok {
+		proto.Reset(target)
+		proto.Merge(target, s)
+		return nil
+	}
+	data, err := protojson.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// textCodec encodes strings as UTF-8 text
+type textCodec struct{}
+
+func (textCodec) ContentType() string { return ContentTypeText }
+
+func (textCodec) Marshal(v any) ([]byte, error) {
+	switch v := v.(type) {
+	case string:
+		return []byte(v), nil
+	case []byte:
+		return v, nil
+	case fmt.Stringer:
+		return []byte(v.String()), nil
+	}
+	return nil, fmt.Errorf("cannot encode %T as text", v)
+}
+
+func (textCodec) Unmarshal(data []byte, v any) error {
+	switch v := v.(type) {
+	case *string:
+		*v = string(data)
+	case *[]byte:
+		*v = append([]byte(nil), data...)
+	case *any:
+		*v = string(data)
+	default:
+		return fmt.Errorf("cannot decode text into %T", v)
+	}
+	return nil
+}
+
+// binaryCodec passes raw bytes through
+type binaryCodec struct{}
+
+func (binaryCodec) ContentType() string { return ContentTypeBinary }
+
+func (binaryCodec) Marshal(v any) ([]byte, error) {
+	if data, ok := v.([]byte); ok {
+		return data, nil
+	}
+	return nil, fmt.Errorf("cannot encode %T as binary", v)
+}
+
+func (binaryCodec) Unmarshal(data []byte, v any) error {
+	switch v := v.(type) {
+	case *[]byte:
+		*v = append([]byte(nil), data...)
+	case *any:
+		*v = append([]byte(nil), data...)
+	default:
+		return fmt.Errorf("cannot decode binary into %T", v)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package sdk
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+type invoice struct {
+	Number string   `json:"number"`
+	Total  float64  `json:"total"`
+	Lines  []string `json:"lines,omitempty"`
+}
+
+func TestCodecs(t *testing.T) {
+	want := invoice{Number: "INV-7", Total: 12.5, Lines: []string{"paper", "ink"}}
+
+	for _, contentType := range []string{ContentTypeJSON, ContentTypeCBOR, ContentTypeMsgPack, ContentTypeProtoStruct} {
+		t.Run(contentType, func(t *testing.T) {
+			data, err := Encode(contentType, want)
+			require.NoError(t, err)
+
+			var got invoice
+			require.NoError(t, Decode(contentType, data, &got))
+			assert.Equal(t, want, got)
+
+			// generic values use the json field names
+			var generic map[string]any
+			require.NoError(t, Decode(contentType, data, &generic))
+			assert.Equal(t, "INV-7", generic["number"])
+		})
+	}
+
+	t.Run("text", func(t *testing.T) {
+		data, err := Encode(ContentTypeText+"; charset=utf-8", "Dear Bob")
+		require.NoError(t, err)
+		var got string
+		require.NoError(t, Decode(ContentTypeText, data, &got))
+		assert.Equal(t, "Dear Bob", got)
+
+		_, err = Encode(ContentTypeText, want)
+		assert.EqualError(t, err, "cannot encode sdk.invoice as text")
+	})
+
+	t.Run("binary", func(t *testing.T) {
+		data, err := Encode(ContentTypeBinary, []byte{0, 1, 2})
+		require.NoError(t, err)
+		var got []byte
+		require.NoError(t, Decode(ContentTypeBinary, data, &got))
+		assert.Equal(t, []byte{0, 1, 2}, got)
+	})
+
+	t.Run("struct", func(t *testing.T) {
+		s, err := structpb.NewStruct(map[string]any{"number": "INV-8"})
+		require.NoError(t, err)
+		data, err := Encode(ContentTypeProtoStruct, s)
+		require.NoError(t, err)
+
+		got := &structpb.Struct{}
+		require.NoError(t, Decode(ContentTypeProtoStruct, data, got))
+		assert.Equal(t, "INV-8", got.Fields["number"].GetStringValue())
+
+		_, err = Encode(ContentTypeProtoStruct, []string{"not", "an", "object"})
+		assert.ErrorContains(t, err, "value is not an object")
+	})
+
+	_, err := Encode("application/xml", want)
+	assert.EqualError(t, err, "unsupported content type: application/xml")
+}
+
+func TestNegotiateCodec(t *testing.T) {
+	assert.Equal(t, ContentTypeJSON, NegotiateCodec(nil).ContentType())
+	assert.Equal(t, ContentTypeCBOR, NegotiateCodec([]string{"application/xml", "Application/CBOR", ContentTypeJSON}).ContentType())
+	assert.Equal(t, ContentTypeJSON, NegotiateCodec([]string{"application/xml"}).ContentType())
+	assert.Contains(t, ContentTypes(), ContentTypeMsgPack)
+}
+
+func TestTypedRequestContentTypes(t *testing.T) {
+	req := &ExecuteRequest{Parameters: map[string][]byte{"subject": []byte("Invoice")}}
+	require.NoError(t, req.SetInput(ContentTypeMsgPack, invoice{Number: "INV-7", Total: 12.5}))
+	require.NoError(t, req.SetParameter("copies", ContentTypeCBOR, 3))
+	require.NoError(t, req.SetParameter("note", ContentTypeText, "urgent"))
+	typed := &TypedExecuteRequest{ExecuteRequest: req}
+
+	var input invoice
+	require.NoError(t, typed.GetInput(&input))
+	assert.Equal(t, invoice{Number: "INV-7", Total: 12.5}, input)
+
+	var copies int
+	found, err := typed.GetParameter("copies", &copies)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, 3, copies)
+
+	var params struct {
+		Subject string `json:"subject"`
+		Copies  int    `json:"copies"`
+		Note    string `json:"note"`
+	}
+	require.NoError(t, typed.GetParameters(&params))
+	assert.Equal(t, "Invoice", params.Subject)
+	assert.Equal(t, 3, params.Copies)
+	assert.Equal(t, "urgent", params.Note)
+}
+
+func TestGRPCContentNegotiation(t *testing.T) {
+	p := NewBasePlugin("invoice-plugin", "1.0.0")
+	require.NoError(t, p.RegisterSimpleFunction("mrn:invoice:invoice:total", func(ctx context.Context, req *TypedExecuteRequest) (any, error) {
+		var in invoice
+		if err := req.GetInput(&in); err != nil {
+			return nil, err
+		}
+		in.Total *= 2
+		return in, nil
+	}, "Double the total"))
+	client := dispenseTestClient(t, p)
+
+	req := &ExecuteRequest{Resource: "mrn:invoice:invoice:total"}
+	require.NoError(t, req.SetInput(ContentTypeCBOR, invoice{Number: "INV-7", Total: 12.5}))
+
+	resp, err := client.Execute(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, ContentTypeJSON, resp.ContentType)
+	assert.JSONEq(t, `{"number": "INV-7", "total": 25}`, string(resp.Output))
+
+	ctx := ContextWithAccept(context.Background(), "application/xml", ContentTypeMsgPack)
+	resp, err = client.Execute(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, ContentTypeMsgPack, resp.ContentType)
+	var out invoice
+	require.NoError(t, resp.DecodeOutput(&out))
+	assert.Equal(t, invoice{Number: "INV-7", Total: 25}, out)
+
+	resps, err := client.(BatchExecutor).ExecuteBatch(ctx, []*ExecuteRequest{req})
+	require.NoError(t, err)
+	assert.Equal(t, ContentTypeMsgPack, resps[0].ContentType)
+}
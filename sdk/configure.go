@@ -0,0 +1,183 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"maschine.io/plugin-sdk/sdk/manifest"
+)
+
+// Configurer is implemented by plugins that initialize clients, connection
+// pools or other state once after the handshake. The host calls Configure
+// after every (re)start of the plugin process, before any Execute call.
+type Configurer interface {
+	Configure(context.Context, *ConfigureRequest) error
+}
+
+// ConfigureRequest carries the resolved plugin configuration
+type ConfigureRequest struct {
+	// Environment contains the values of the environment variables declared
+	// in the manifest
+	Environment map[string]string
+	// Credentials maps credential set names to their field values
+	Credentials map[string]map[string]string
+}
+
+// plainConfigureRequest has the fields of ConfigureRequest but none of its methods
+type plainConfigureRequest ConfigureRequest
+
+// String formats the request with all credential values redacted
+func (r *ConfigureRequest) String() string {
+	return fmt.Sprintf("%+v", r.redacted())
+}
+
+// GoString formats the request with all credential values redacted
+func (r *ConfigureRequest) GoString() string {
+	return fmt.Sprintf("%#v", r.redacted())
+}
+
+func (r *ConfigureRequest) redacted() *plainConfigureRequest {
+	if r == nil {
+		return nil
+	}
+	c := plainConfigureRequest(*r)
+	if r.Credentials != nil {
+		c.Credentials = make(map[string]map[string]string, len(r.Credentials))
+		for set, fields := range r.Credentials {
+			c.Credentials[set] = make(map[string]string, len(fields))
+			for k := range fields {
+				c.Credentials[set][k] = Redacted
+			}
+		}
+	}
+	return &c
+}
+
+// ValidateConfiguration validates req against the configuration declared in
+// the manifest and returns a copy with environment defaults filled in. All
+// violations are returned together as manifest.ValidationErrors with fields
+// named "environment.<name>" and "credentials.<set>.<field>".
+func ValidateConfiguration(cfg manifest.Configuration, req *ConfigureRequest) (*ConfigureRequest, error) {
+	var errors manifest.ValidationErrors
+
+	result := &ConfigureRequest{
+		Environment: make(map[string]string, len(cfg.Environment)),
+		Credentials: req.Credentials,
+	}
+
+	declaredEnv := make(map[string]bool, len(cfg.Environment))
+	for _, env := range cfg.Environment {
+		declaredEnv[env.Name] = true
+		field := "environment." + env.Name
+
+		value, found := req.Environment[env.Name]
+		if !found || value == "" {
+			switch {
+			case env.Required:
+				errors = append(errors, manifest.ValidationError{Field: field, Message: "is required"})
+			case env.Default != "":
+				result.Environment[env.Name] = env.Default
+			}
+			continue
+		}
+
+		result.Environment[env.Name] = value
+		if len(env.Enum) > 0 && !inEnum(value, env.Enum) {
+			errors = append(errors, manifest.ValidationError{
+				Field:   field,
+				Message: fmt.Sprintf("must be one of %v", env.Enum),
+			})
+		}
+	}
+	for _, name := range sortedKeys(req.Environment) {
+		if !declaredEnv[name] {
+			errors = append(errors, manifest.ValidationError{Field: "environment." + name, Message: "is not declared"})
+		}
+	}
+
+	declaredSets := make(map[string]bool, len(cfg.Credentials))
+	for _, set := range cfg.Credentials {
+		declaredSets[set.Name] = true
+		values, found := req.Credentials[set.Name]
+		if !found {
+			continue
+		}
+		errors = append(errors, validateCredentialSet(set, values)...)
+	}
+	for _, name := range sortedKeys(req.Credentials) {
+		if !declaredSets[name] {
+			errors = append(errors, manifest.ValidationError{Field: "credentials." + name, Message: "is not declared"})
+		}
+	}
+
+	if len(errors) > 0 {
+		return nil, errors
+	}
+	return result, nil
+}
+
+func validateCredentialSet(set manifest.CredentialSet, values map[string]string) manifest.ValidationErrors {
+	var errors manifest.ValidationErrors
+
+	declared := make(map[string]bool, len(set.Fields))
+	for _, f := range set.Fields {
+		declared[f.Name] = true
+		field := fmt.Sprintf("credentials.%s.%s", set.Name, f.Name)
+
+		value, found := values[f.Name]
+		if !found || value == "" {
+			if f.Required {
+				errors = append(errors, manifest.ValidationError{Field: field, Message: "is required"})
+			}
+			continue
+		}
+
+		// messages never contain the value, credentials may be secret
+		switch f.Type {
+		case "number":
+			if _, err := strconv.ParseFloat(value, 64); err != nil {
+				errors = append(errors, manifest.ValidationError{Field: field, Message: "must be a number"})
+			}
+		case "boolean":
+			if _, err := strconv.ParseBool(value); err != nil {
+				errors = append(errors, manifest.ValidationError{Field: field, Message: "must be a boolean"})
+			}
+		}
+		if f.Pattern != "" {
+			re, err := regexp.Compile(f.Pattern)
+			if err != nil {
+				errors = append(errors, manifest.ValidationError{
+					Field:   field,
+					Message: fmt.Sprintf("invalid pattern %q in manifest: %v", f.Pattern, err),
+				})
+			} else if !re.MatchString(value) {
+				errors = append(errors, manifest.ValidationError{
+					Field:   field,
+					Message: fmt.Sprintf("must match pattern %s", f.Pattern),
+				})
+			}
+		}
+	}
+	for _, name := range sortedKeys(values) {
+		if !declared[name] {
+			errors = append(errors, manifest.ValidationError{
+				Field:   fmt.Sprintf("credentials.%s.%s", set.Name, name),
+				Message: "is not declared",
+			})
+		}
+	}
+
+	return errors
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
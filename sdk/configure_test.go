@@ -0,0 +1,133 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maschine.io/plugin-sdk/sdk/manifest"
+)
+
+func testConfiguration() manifest.Configuration {
+	return manifest.Configuration{
+		Environment: []manifest.EnvVar{
+			{Name: "LOG_LEVEL", Default: "info", Enum: []string{"debug", "info"}},
+			{Name: "SMTP_POOL_SIZE", Required: true},
+		},
+		Credentials: []manifest.CredentialSet{
+			{
+				Name: "smtp",
+				Fields: []manifest.CredentialField{
+					{Name: "server", Type: "string", Required: true, Pattern: "^[^:]+:[0-9]+$"},
+					{Name: "port", Type: "number"},
+					{Name: "password", Type: "string", Required: true, Secret: true},
+				},
+			},
+		},
+	}
+}
+
+func TestValidateConfiguration(t *testing.T) {
+	t.Run("valid with defaults", func(t *testing.T) {
+		cfg, err := ValidateConfiguration(testConfiguration(), &ConfigureRequest{
+			Environment: map[string]string{"SMTP_POOL_SIZE": "4"},
+			Credentials: map[string]map[string]string{
+				"smtp": {"server": "mail.example.com:587", "password": "configured-password"},
+			},
+		})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"LOG_LEVEL": "info", "SMTP_POOL_SIZE": "4"}, cfg.Environment)
+	})
+
+	t.Run("lists every offending field", func(t *testing.T) {
+		_, err := ValidateConfiguration(testConfiguration(), &ConfigureRequest{
+			Environment: map[string]string{"LOG_LEVEL": "trace", "OTHER": "x"},
+			Credentials: map[string]map[string]string{
+				"smtp": {"server": "mail.example.com", "port": "abc", "user": "bob"},
+				"imap": {"server": "imap.example.com:993"},
+			},
+		})
+		require.Error(t, err)
+		assert.Equal(t, manifest.ValidationErrors{
+			{Field: "environment.LOG_LEVEL", Message: "must be one of [debug info]"},
+			{Field: "environment.SMTP_POOL_SIZE", Message: "is required"},
+			{Field: "environment.OTHER", Message: "is not declared"},
+			{Field: "credentials.smtp.server", Message: "must match pattern ^[^:]+:[0-9]+$"},
+			{Field: "credentials.smtp.port", Message: "must be a number"},
+			{Field: "credentials.smtp.password", Message: "is required"},
+			{Field: "credentials.smtp.user", Message: "is not declared"},
+			{Field: "credentials.imap", Message: "is not declared"},
+		}, err)
+	})
+}
+
+func TestConfigureRequestFormatting(t *testing.T) {
+	req := &ConfigureRequest{Credentials: map[string]map[string]string{"smtp": {"password": "configured-password"}}}
+	for _, format := range []string{"%v", "%+v", "%#v"} {
+		assert.NotContains(t, fmt.Sprintf(format, req), "configured-password", format)
+	}
+}
+
+// poolPlugin records the configuration it receives
+type poolPlugin struct {
+	*BasePlugin
+	configured *ConfigureRequest
+	reject     error
+}
+
+func (p *poolPlugin) Configure(ctx context.Context, req *ConfigureRequest) error {
+	if p.reject != nil {
+		return p.reject
+	}
+	p.configured = req
+	return nil
+}
+
+func TestGRPCConfigure(t *testing.T) {
+	m := testMailManifest()
+	m.Configuration = testConfiguration()
+
+	impl := &poolPlugin{BasePlugin: NewBasePlugin("mail-plugin", "1.0.0")}
+	impl.SetManifest(m)
+	client := dispenseTestClient(t, impl).(Configurer)
+
+	t.Run("accepted", func(t *testing.T) {
+		err := client.Configure(context.Background(), &ConfigureRequest{
+			Environment: map[string]string{"SMTP_POOL_SIZE": "4"},
+			Credentials: map[string]map[string]string{
+				"smtp": {"server": "mail.example.com:587", "password": "configured-password"},
+			},
+		})
+		require.NoError(t, err)
+		require.NotNil(t, impl.configured)
+		assert.Equal(t, "info", impl.configured.Environment["LOG_LEVEL"])
+		assert.Equal(t, "configured-password", impl.configured.Credentials["smtp"]["password"])
+	})
+
+	t.Run("rejected by manifest", func(t *testing.T) {
+		err := client.Configure(context.Background(), &ConfigureRequest{})
+		var errs manifest.ValidationErrors
+		require.True(t, errors.As(err, &errs))
+		assert.Equal(t, manifest.ValidationErrors{
+			{Field: "environment.SMTP_POOL_SIZE", Message: "is required"},
+		}, errs)
+	})
+
+	t.Run("rejected by plugin", func(t *testing.T) {
+		impl.reject = errors.New("cannot connect to mail.example.com:587 with password configured-password")
+		err := client.Configure(context.Background(), &ConfigureRequest{
+			Environment: map[string]string{"SMTP_POOL_SIZE": "4"},
+			Credentials: map[string]map[string]string{
+				"smtp": {"server": "mail.example.com:587", "password": "configured-password"},
+			},
+		})
+		var errs manifest.ValidationErrors
+		require.True(t, errors.As(err, &errs))
+		assert.Equal(t, manifest.ValidationErrors{
+			{Message: "cannot connect to mail.example.com:587 with password [REDACTED]"},
+		}, errs)
+	})
+}
@@ -0,0 +1,414 @@
+// Package conformance certifies plugin binaries. Run launches a plugin and
+// checks that it implements the plugin protocol the way hosts expect: its
+// metadata matches its manifest, it answers health checks in time, rejects
+// unknown resources, executes the examples of its manifest, survives
+// concurrent calls and shuts down cleanly.
+//
+// Test runs the suite in a Go test, cmd/plugin-conformance from the command
+// line.
+package conformance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-plugin"
+	"maschine.io/plugin-sdk/sdk"
+	"maschine.io/plugin-sdk/sdk/host"
+	"maschine.io/plugin-sdk/sdk/manifest"
+)
+
+const (
+	// DefaultConcurrency is the default for Config.Concurrency
+	DefaultConcurrency = 8
+	// DefaultHealthCheckTimeout applies if the manifest declares no
+	// health_check_timeout
+	DefaultHealthCheckTimeout = 10 * time.Second
+	// DefaultExecuteTimeout applies if the manifest declares no
+	// execute_timeout
+	DefaultExecuteTimeout = 30 * time.Second
+	// DefaultShutdownTimeout is the default for Config.ShutdownTimeout
+	DefaultShutdownTimeout = 10 * time.Second
+)
+
+// unknownResource is a resource no plugin declares
+const unknownResource = "mrn:conformance:unknown:resource"
+
+// concurrentRounds is how many rounds of calls every worker of the
+// concurrency check makes
+const concurrentRounds = 10
+
+// Config configures a conformance run
+type Config struct {
+	// Path is the plugin binary
+	Path string
+	Args []string
+	// Manifest is the manifest the plugin is certified against. Defaults to
+	// the manifest next to the binary.
+	Manifest *manifest.PluginManifest
+	// Configuration is sent to the plugin with Configure after launch
+	Configuration *sdk.ConfigureRequest
+	// Credentials are passed with the executions of the examples
+	Credentials map[string]string
+	// DryRun runs the examples of resources that are not declared as query
+	// or check as dry runs, for plugins whose actions must not change
+	// anything in the test environment
+	DryRun bool
+	// Concurrency is the number of concurrent callers of the concurrency
+	// check. Defaults to DefaultConcurrency.
+	Concurrency int
+	// ShutdownTimeout is how long the plugin may drain on shutdown.
+	// Defaults to DefaultShutdownTimeout.
+	ShutdownTimeout time.Duration
+	// Logger receives the logs of go-plugin and the plugin. Discarded if nil.
+	Logger hclog.Logger
+}
+
+// Result is the outcome of a single conformance check
+type Result struct {
+	Name string
+	// Message describes what was checked, e.g. the reported health status
+	Message  string
+	Err      error
+	Duration time.Duration
+}
+
+// Passed reports whether the check passed
+func (r Result) Passed() bool {
+	return r.Err == nil
+}
+
+// Report is the outcome of a conformance run
+type Report struct {
+	Plugin  string
+	Version string
+	Path    string
+	// Results are in the order the checks ran
+	Results []Result
+}
+
+// Passed reports whether all checks passed
+func (r *Report) Passed() bool {
+	return r.Failed() == 0
+}
+
+// Failed returns the number of failed checks
+func (r *Report) Failed() int {
+	n := 0
+	for _, result := range r.Results {
+		if !result.Passed() {
+			n++
+		}
+	}
+	return n
+}
+
+// Write prints the report for humans, one line per check
+func (r *Report) Write(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Conformance of %s %s (%s)\n\n", r.Plugin, r.Version, r.Path)
+	for _, result := range r.Results {
+		status := "PASS"
+		if !result.Passed() {
+			status = "FAIL"
+		}
+		fmt.Fprintf(&b, "%-5s %s %s\n", status, result.Name, result.Duration.Round(time.Millisecond))
+		if result.Message != "" {
+			fmt.Fprintf(&b, "      %s\n", result.Message)
+		}
+		if result.Err != nil {
+			for _, line := range strings.Split(result.Err.Error(), "\n") {
+				fmt.Fprintf(&b, "      %s\n", line)
+			}
+		}
+	}
+	fmt.Fprintf(&b, "\n%d checks: %d passed, %d failed\n", len(r.Results), len(r.Results)-r.Failed(), r.Failed())
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// suite is a conformance run against a launched plugin
+type suite struct {
+	config   Config
+	manifest *manifest.PluginManifest
+	client   *plugin.Client
+	resource sdk.MaschineResource
+	report   *Report
+}
+
+// Run launches the plugin binary and runs all conformance checks against
+// it. Launch failures are reported as failed check. The plugin process is
+// killed when Run returns.
+func Run(ctx context.Context, cfg Config) *Report {
+	s := &suite{config: cfg, manifest: cfg.Manifest, report: &Report{Path: cfg.Path}}
+	if s.config.Concurrency <= 0 {
+		s.config.Concurrency = DefaultConcurrency
+	}
+	if s.config.ShutdownTimeout <= 0 {
+		s.config.ShutdownTimeout = DefaultShutdownTimeout
+	}
+	if s.config.Logger == nil {
+		s.config.Logger = hclog.NewNullLogger()
+	}
+
+	if !s.check("launch", func() (string, error) { return s.launch(ctx) }) {
+		return s.report
+	}
+	defer s.client.Kill()
+
+	s.check("metadata", func() (string, error) { return s.metadata(ctx) })
+	s.check("health check", func() (string, error) { return s.health(ctx) })
+	s.check("unknown resource", func() (string, error) { return s.unknown(ctx) })
+	for _, r := range s.manifest.Resources {
+		if r.Category == "trigger" {
+			continue
+		}
+		for _, e := range r.Examples {
+			s.check(fmt.Sprintf("example %s: %s", r.Type, e.Name), func() (string, error) { return s.example(ctx, r, e) })
+		}
+	}
+	s.check("concurrent calls", func() (string, error) { return s.concurrent(ctx) })
+	s.check("shutdown", func() (string, error) { return s.shutdown(ctx) })
+	return s.report
+}
+
+// Test runs the conformance checks as subtests of t
+func Test(t *testing.T, cfg Config) {
+	t.Helper()
+	report := Run(t.Context(), cfg)
+	for _, result := range report.Results {
+		t.Run(result.Name, func(t *testing.T) {
+			if result.Message != "" {
+				t.Log(result.Message)
+			}
+			if result.Err != nil {
+				t.Error(result.Err)
+			}
+		})
+	}
+}
+
+// check runs fn as the check name and records its result
+func (s *suite) check(name string, fn func() (string, error)) bool {
+	start := time.Now()
+	message, err := fn()
+	s.report.Results = append(s.report.Results, Result{Name: name, Message: message, Err: err, Duration: time.Since(start)})
+	return err == nil
+}
+
+// launch starts the plugin process with sdk.Handshake and configures it
+func (s *suite) launch(ctx context.Context) (string, error) {
+	if s.manifest == nil {
+		path, err := manifest.FindManifest(filepath.Dir(s.config.Path))
+		if err != nil {
+			return "", err
+		}
+		if s.manifest, err = manifest.Load(path); err != nil {
+			return "", err
+		}
+	}
+	s.report.Plugin = s.manifest.Plugin.ID
+	s.report.Version = s.manifest.Plugin.Version
+
+	s.client = plugin.NewClient(&plugin.ClientConfig{
+		HandshakeConfig:  sdk.Handshake,
+		Plugins:          sdk.PluginMap,
+		Cmd:              exec.Command(s.config.Path, s.config.Args...),
+		Logger:           s.config.Logger,
+		AllowedProtocols: []plugin.Protocol{plugin.ProtocolGRPC},
+		AutoMTLS:         true,
+	})
+	rpcClient, err := s.client.Client()
+	if err != nil {
+		s.client.Kill()
+		return "", err
+	}
+	raw, err := rpcClient.Dispense(sdk.PluginName)
+	if err != nil {
+		s.client.Kill()
+		return "", err
+	}
+	resource, ok := raw.(sdk.MaschineResource)
+	if !ok {
+		s.client.Kill()
+		return "", fmt.Errorf("plugin does not implement MaschineResource: %T", raw)
+	}
+	s.resource = resource
+
+	if s.config.Configuration != nil {
+		configurer, ok := resource.(sdk.Configurer)
+		if !ok {
+			s.client.Kill()
+			return "", errors.New("plugin client does not support configuration")
+		}
+		if err := configurer.Configure(ctx, s.config.Configuration); err != nil {
+			s.client.Kill()
+			return "", fmt.Errorf("failed to configure plugin: %w", err)
+		}
+	}
+	return "", nil
+}
+
+// metadata checks that GetMetadata agrees with the manifest
+func (s *suite) metadata(ctx context.Context) (string, error) {
+	md, err := s.resource.GetMetadata(ctx, &sdk.GetMetadataRequest{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get metadata: %w", err)
+	}
+	if err := host.VerifyMetadata(s.manifest, md); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s %s with %d resources", md.Name, md.Version, len(md.SupportedResources)), nil
+}
+
+// health checks that the plugin answers a health check within the
+// health_check_timeout of its manifest
+func (s *suite) health(ctx context.Context) (string, error) {
+	timeout := s.manifest.Limits.HealthCheckTimeout.Duration
+	if timeout <= 0 {
+		timeout = DefaultHealthCheckTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	resp, err := s.resource.HealthCheck(ctx, &sdk.HealthCheckRequest{})
+	if err != nil {
+		return "", fmt.Errorf("no health check response within %s: %w", timeout, err)
+	}
+	message := fmt.Sprintf("status %s", resp.Status)
+	if resp.Message != "" {
+		message += ": " + resp.Message
+	}
+	return message, nil
+}
+
+// unknown checks that executions of undeclared resources fail with an
+// error in the response
+func (s *suite) unknown(ctx context.Context) (string, error) {
+	resp, err := s.execute(ctx, &sdk.ExecuteRequest{Resource: unknownResource})
+	if err != nil {
+		return "", fmt.Errorf("call failed instead of returning an error response: %w", err)
+	}
+	if resp.Error == "" {
+		return "", fmt.Errorf("execution of %s succeeded", unknownResource)
+	}
+	return fmt.Sprintf("rejected with %q", resp.Error), nil
+}
+
+// example checks that an example of the manifest executes without error
+func (s *suite) example(ctx context.Context, r manifest.ResourceDef, e manifest.Example) (string, error) {
+	req := &sdk.ExecuteRequest{
+		Resource:    r.Type,
+		Parameters:  make(map[string][]byte, len(e.Parameters)),
+		Credentials: s.config.Credentials,
+		DryRun:      s.config.DryRun && r.Category != "query" && r.Category != "check",
+	}
+	for name, value := range e.Parameters {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode parameter %s: %w", name, err)
+		}
+		req.Parameters[name] = data
+	}
+
+	resp, err := s.execute(ctx, req)
+	if err != nil {
+		return "", err
+	}
+	if resp.Error != "" {
+		return "", errors.New(resp.Error)
+	}
+	if req.DryRun {
+		return "dry run", nil
+	}
+	return "", nil
+}
+
+// concurrent checks that the plugin survives concurrent calls of all
+// kinds and still answers afterwards
+func (s *suite) concurrent(ctx context.Context) (string, error) {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if len(errs) < 5 {
+			errs = append(errs, err)
+		}
+	}
+
+	for range s.config.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range concurrentRounds {
+				if _, err := s.resource.GetMetadata(ctx, &sdk.GetMetadataRequest{}); err != nil {
+					fail(fmt.Errorf("GetMetadata: %w", err))
+				}
+				if _, err := s.resource.HealthCheck(ctx, &sdk.HealthCheckRequest{}); err != nil {
+					fail(fmt.Errorf("HealthCheck: %w", err))
+				}
+				if _, err := s.execute(ctx, &sdk.ExecuteRequest{Resource: unknownResource}); err != nil {
+					fail(fmt.Errorf("Execute: %w", err))
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if s.client.Exited() {
+		errs = append(errs, errors.New("plugin process exited"))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d callers, %d calls each", s.config.Concurrency, 3*concurrentRounds), nil
+}
+
+// shutdown checks that the plugin drains on Shutdown and rejects
+// executions afterwards instead of crashing
+func (s *suite) shutdown(ctx context.Context) (string, error) {
+	shutdowner, ok := s.resource.(sdk.Shutdowner)
+	if !ok {
+		return "", errors.New("plugin client does not support shutdown")
+	}
+	drain, cancel := context.WithTimeout(ctx, s.config.ShutdownTimeout)
+	defer cancel()
+	if err := shutdowner.Shutdown(drain); err != nil {
+		return "", fmt.Errorf("shutdown failed: %w", err)
+	}
+
+	_, err := s.execute(ctx, &sdk.ExecuteRequest{Resource: unknownResource})
+	if !errors.Is(err, sdk.ErrShuttingDown) {
+		return "", fmt.Errorf("execution after shutdown returned %v, expected %v", err, sdk.ErrShuttingDown)
+	}
+	if s.client.Exited() {
+		return "", errors.New("plugin process exited before the host stopped it")
+	}
+	return "", nil
+}
+
+// execute runs req within the execute_timeout of the manifest
+func (s *suite) execute(ctx context.Context, req *sdk.ExecuteRequest) (*sdk.ExecuteResponse, error) {
+	timeout := s.manifest.Limits.ExecuteTimeout.Duration
+	if timeout <= 0 {
+		timeout = DefaultExecuteTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return s.resource.Execute(ctx, req)
+}
@@ -0,0 +1,149 @@
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/hashicorp/go-plugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maschine.io/plugin-sdk/sdk"
+	"maschine.io/plugin-sdk/sdk/manifest"
+)
+
+// envTestPlugin makes the test binary serve a test plugin instead of
+// running the tests: "conforming" or "broken"
+const envTestPlugin = "MASCHINE_CONFORMANCE_TEST_PLUGIN"
+
+func TestMain(m *testing.M) {
+	if kind := os.Getenv(envTestPlugin); kind != "" {
+		impl := conformingPlugin()
+		if kind == "broken" {
+			impl = brokenPlugin()
+		}
+		plugin.Serve(&plugin.ServeConfig{
+			HandshakeConfig: sdk.Handshake,
+			Plugins: map[string]plugin.Plugin{
+				sdk.PluginName: &sdk.MaschinePlugin{Impl: impl},
+			},
+			GRPCServer:  sdk.GRPCServer(),
+			TLSProvider: sdk.TLSProvider,
+		})
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func testManifest() *manifest.PluginManifest {
+	m := manifest.New("greeter", "io.maschine.plugins.greeter")
+	m.Plugin.Description = "Greets people"
+	m.Resources = []manifest.ResourceDef{{
+		Type:        "mrn:greeter:greeting:send",
+		Name:        "Send greeting",
+		Description: "Greets someone",
+		Category:    "action",
+		Parameters: []manifest.Parameter{
+			{Name: "name", Type: "string", Required: true, Description: "Who to greet"},
+		},
+		Examples: []manifest.Example{
+			{Name: "Greet Ada", Parameters: map[string]any{"name": "Ada"}},
+		},
+	}}
+	return m
+}
+
+func greet(ctx context.Context, req *sdk.TypedExecuteRequest) (any, error) {
+	var name string
+	if _, err := req.GetParameter("name", &name); err != nil {
+		return nil, err
+	}
+	return "Hello " + name, nil
+}
+
+func conformingPlugin() sdk.MaschineResource {
+	p := sdk.NewBasePlugin("greeter", "0.1.0")
+	p.RegisterSimpleFunction("mrn:greeter:greeting:send", greet, "Greets someone")
+	return p
+}
+
+// acceptingPlugin succeeds for resources it does not know
+type acceptingPlugin struct {
+	*sdk.BasePlugin
+}
+
+func (p *acceptingPlugin) Execute(ctx context.Context, req *sdk.ExecuteRequest) (*sdk.ExecuteResponse, error) {
+	if req.Resource != "mrn:greeter:greeting:send" {
+		return &sdk.ExecuteResponse{}, nil
+	}
+	return p.BasePlugin.Execute(ctx, req)
+}
+
+// brokenPlugin reports another version, fails its example and accepts
+// unknown resources
+func brokenPlugin() sdk.MaschineResource {
+	p := sdk.NewBasePlugin("greeter", "0.2.0")
+	p.RegisterSimpleFunction("mrn:greeter:greeting:send", func(ctx context.Context, req *sdk.TypedExecuteRequest) (any, error) {
+		return nil, errors.New("greeting service unavailable")
+	}, "Greets someone")
+	return &acceptingPlugin{BasePlugin: p}
+}
+
+func runTestPlugin(t *testing.T, kind string) *Report {
+	t.Helper()
+	t.Setenv(envTestPlugin, kind)
+	return Run(context.Background(), Config{Path: os.Args[0], Manifest: testManifest()})
+}
+
+func TestConformingPlugin(t *testing.T) {
+	report := runTestPlugin(t, "conforming")
+
+	var names []string
+	for _, r := range report.Results {
+		names = append(names, r.Name)
+		assert.NoError(t, r.Err, r.Name)
+	}
+	assert.Equal(t, []string{
+		"launch",
+		"metadata",
+		"health check",
+		"unknown resource",
+		"example mrn:greeter:greeting:send: Greet Ada",
+		"concurrent calls",
+		"shutdown",
+	}, names)
+	assert.True(t, report.Passed())
+
+	var out bytes.Buffer
+	require.NoError(t, report.Write(&out))
+	assert.Contains(t, out.String(), "Conformance of io.maschine.plugins.greeter 0.1.0")
+	assert.Contains(t, out.String(), "PASS  metadata")
+	assert.Contains(t, out.String(), "      greeter 0.1.0 with 1 resources\n")
+	assert.Contains(t, out.String(), "7 checks: 7 passed, 0 failed\n")
+}
+
+func TestBrokenPlugin(t *testing.T) {
+	report := runTestPlugin(t, "broken")
+
+	failed := make(map[string]string)
+	for _, r := range report.Results {
+		if r.Err != nil {
+			failed[r.Name] = r.Err.Error()
+		}
+	}
+	assert.Len(t, failed, 3)
+	assert.Contains(t, failed["metadata"], "plugin.version")
+	assert.Equal(t, "execution of mrn:conformance:unknown:resource succeeded", failed["unknown resource"])
+	assert.Equal(t, "greeting service unavailable", failed["example mrn:greeter:greeting:send: Greet Ada"])
+	assert.False(t, report.Passed())
+	assert.Equal(t, 3, report.Failed())
+}
+
+func TestLaunchFailure(t *testing.T) {
+	report := Run(context.Background(), Config{Path: "/nonexistent/plugin"})
+	require.Len(t, report.Results, 1)
+	assert.Equal(t, "launch", report.Results[0].Name)
+	assert.Error(t, report.Results[0].Err)
+}
@@ -0,0 +1,320 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	pluginv1 "maschine.io/plugin-sdk/proto/plugin/v1"
+)
+
+// ErrEventsNotSupported is returned by Subscribe if the plugin does not
+// implement EventSource
+var ErrEventsNotSupported = errors.New("plugin does not support events")
+
+// Event is emitted by a trigger resource, like a mail that arrived in an
+// inbox or a file that was dropped into a directory. The host starts state
+// machine executions for it.
+type Event struct {
+	// ID is assigned by the SDK when the event is emitted
+	ID string
+	// Resource is the trigger resource that emitted the event
+	Resource string
+	Payload  []byte
+	// ContentType is the content type of Payload, empty for JSON
+	ContentType string
+	// DedupeID identifies the occurrence the event reports, e.g. the ID of
+	// a mail. The host drops events whose dedupe ID it has seen before, so
+	// plugins can emit an occurrence again after a restart.
+	DedupeID string
+	// Time is set to the time of emission if unset
+	Time       time.Time
+	Attributes map[string]string
+}
+
+// SetPayload encodes v with the codec of contentType into the payload
+func (e *Event) SetPayload(contentType string, v any) error {
+	data, err := Encode(contentType, v)
+	if err != nil {
+		return fmt.Errorf("failed to encode payload: %w", err)
+	}
+	e.Payload = data
+	e.ContentType = contentType
+	return nil
+}
+
+// DecodePayload decodes the payload into v with the codec of its content
+// type
+func (e *Event) DecodePayload(v any) error {
+	if len(e.Payload) == 0 {
+		return nil
+	}
+	if err := Decode(e.ContentType, e.Payload, v); err != nil {
+		return fmt.Errorf("failed to decode payload: %w", err)
+	}
+	return nil
+}
+
+// EmitFunc sends an event to the host. It returns once the event was handed
+// to the subscription, or with the error of ctx. Events that were not
+// acknowledged by the host are sent again with the next subscription.
+type EmitFunc func(ctx context.Context, e *Event) error
+
+// EventSource is implemented by plugins with trigger resources
+type EventSource interface {
+	// Watch emits the events of a trigger resource until ctx is canceled.
+	// An error ends the subscription; the host subscribes again later.
+	Watch(ctx context.Context, resource string, emit EmitFunc) error
+}
+
+// EventStream receives the events of a subscription
+type EventStream interface {
+	Recv() (*Event, error)
+}
+
+// EventSubscriber is implemented by the gRPC client, so hosts can type
+// assert a dispensed MaschineResource to EventSubscriber
+type EventSubscriber interface {
+	// Subscribe watches the trigger resources until ctx is canceled
+	Subscribe(ctx context.Context, resources []string) (EventStream, error)
+	// Acknowledge confirms that the events were received. Events that are
+	// not acknowledged are sent again with the next subscription.
+	Acknowledge(ctx context.Context, ids ...string) error
+}
+
+// eventOutbox keeps the emitted events until the host acknowledged them
+type eventOutbox struct {
+	mu      sync.Mutex
+	pending map[string]*pluginv1.Event
+	closed  chan struct{}
+	once    sync.Once
+}
+
+func newEventOutbox() *eventOutbox {
+	return &eventOutbox{
+		pending: make(map[string]*pluginv1.Event),
+		closed:  make(chan struct{}),
+	}
+}
+
+// add keeps an event until it is acknowledged
+func (o *eventOutbox) add(e *pluginv1.Event) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.pending[e.Id] = e
+}
+
+// acknowledge removes acknowledged events. Unknown IDs are ignored.
+func (o *eventOutbox) acknowledge(ids []string) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, id := range ids {
+		delete(o.pending, id)
+	}
+}
+
+// unacknowledged returns the pending events of the resources, oldest first
+func (o *eventOutbox) unacknowledged(resources []string) []*pluginv1.Event {
+	watched := make(map[string]bool, len(resources))
+	for _, r := range resources {
+		watched[r] = true
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var result []*pluginv1.Event
+	for _, e := range o.pending {
+		if watched[e.Resource] {
+			result = append(result, e)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Time.AsTime().Before(result[j].Time.AsTime())
+	})
+	return result
+}
+
+// close ends all subscriptions, e.g. when the plugin shuts down
+func (o *eventOutbox) close() {
+	o.once.Do(func() { close(o.closed) })
+}
+
+func (s *grpcServer) Subscribe(req *pluginv1.SubscribeRequest, stream pluginv1.Plugin_SubscribeServer) (err error) {
+	defer recoverPanic("Subscribe", &err)
+
+	source, ok := lookup[EventSource](s.Impl)
+	if !ok {
+		return status.Error(codes.Unimplemented, ErrEventsNotSupported.Error())
+	}
+	if len(req.Resources) == 0 {
+		return status.Error(codes.InvalidArgument, "no trigger resources to watch")
+	}
+	select {
+	case <-s.events.closed:
+		return status.Error(codes.Unavailable, ErrShuttingDown.Error())
+	default:
+	}
+	// the client waits for the header to know the subscription started
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(stream.Context())
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer cancel()
+
+	events := make(chan *pluginv1.Event)
+	failed := make(chan error, len(req.Resources))
+	emit := func(emitCtx context.Context, e *Event) error {
+		pbEvent, err := s.newEvent(e)
+		if err != nil {
+			return err
+		}
+		s.events.add(pbEvent)
+		select {
+		case events <- pbEvent:
+			return nil
+		case <-emitCtx.Done():
+			return emitCtx.Err()
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	for _, resource := range req.Resources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := watch(ctx, source, resource, emit)
+			if err == nil || ctx.Err() != nil {
+				return
+			}
+			// recovered panics are already statuses
+			if _, ok := status.FromError(err); !ok {
+				err = status.Errorf(codes.Aborted, "trigger %s failed: %v", resource, err)
+			}
+			failed <- err
+		}()
+	}
+
+	for _, e := range s.events.unacknowledged(req.Resources) {
+		if err := stream.Send(e); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case e := <-events:
+			if err := stream.Send(e); err != nil {
+				return err
+			}
+		case err := <-failed:
+			return err
+		case <-s.events.closed:
+			return status.Error(codes.Unavailable, ErrShuttingDown.Error())
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// watch runs Watch of the event source. A panic fails the watch.
+func watch(ctx context.Context, source EventSource, resource string, emit EmitFunc) (err error) {
+	defer recoverPanic("Watch", &err)
+	return source.Watch(ctx, resource, func(ctx context.Context, e *Event) error {
+		if e.Resource == "" {
+			e.Resource = resource
+		}
+		return emit(ctx, e)
+	})
+}
+
+// newEvent assigns ID and time to an emitted event and converts it
+func (s *grpcServer) newEvent(e *Event) (*pluginv1.Event, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	e.ID = id
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	return &pluginv1.Event{
+		Id:          e.ID,
+		Resource:    e.Resource,
+		Payload:     e.Payload,
+		ContentType: e.ContentType,
+		DedupeId:    e.DedupeID,
+		Time:        timestamppb.New(e.Time),
+		Attributes:  e.Attributes,
+	}, nil
+}
+
+func (s *grpcServer) Acknowledge(ctx context.Context, req *pluginv1.AcknowledgeRequest) (_ *pluginv1.AcknowledgeResponse, err error) {
+	defer recoverPanic("Acknowledge", &err)
+
+	s.events.acknowledge(req.EventIds)
+	return &pluginv1.AcknowledgeResponse{}, nil
+}
+
+// Subscribe watches the trigger resources of the plugin. It returns
+// ErrEventsNotSupported if the plugin does not implement EventSource.
+func (c *grpcClient) Subscribe(ctx context.Context, resources []string) (EventStream, error) {
+	stream, err := c.client.Subscribe(ctx, &pluginv1.SubscribeRequest{Resources: resources})
+	if err != nil {
+		return nil, eventError(err)
+	}
+	// the plugin sends the header once it started watching. A call that
+	// failed right away has no header, its error is returned by Recv.
+	md, err := stream.Header()
+	if err == nil && md == nil {
+		_, err = stream.Recv()
+	}
+	if err != nil {
+		return nil, eventError(err)
+	}
+	return &eventStream{stream: stream}, nil
+}
+
+// Acknowledge confirms that the host received the events
+func (c *grpcClient) Acknowledge(ctx context.Context, ids ...string) error {
+	_, err := c.client.Acknowledge(ctx, &pluginv1.AcknowledgeRequest{EventIds: ids})
+	return clientError(err)
+}
+
+// eventError converts the error of a subscription
+func eventError(err error) error {
+	if status.Code(err) == codes.Unimplemented {
+		return ErrEventsNotSupported
+	}
+	return clientError(err)
+}
+
+// eventStream converts the events received by the gRPC client
+type eventStream struct {
+	stream pluginv1.Plugin_SubscribeClient
+}
+
+func (s *eventStream) Recv() (*Event, error) {
+	e, err := s.stream.Recv()
+	if err != nil {
+		return nil, eventError(err)
+	}
+	return &Event{
+		ID:          e.Id,
+		Resource:    e.Resource,
+		Payload:     e.Payload,
+		ContentType: e.ContentType,
+		DedupeID:    e.DedupeId,
+		Time:        e.Time.AsTime(),
+		Attributes:  e.Attributes,
+	}, nil
+}
@@ -0,0 +1,117 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// inboxPlugin emits a mail event for every subject sent to mails
+func inboxPlugin(t *testing.T, mails <-chan string) *BasePlugin {
+	t.Helper()
+	p := NewBasePlugin("inbox-plugin", "1.0.0")
+	require.NoError(t, p.RegisterTrigger("mrn:mail:imap:received", func(ctx context.Context, emit EmitFunc) error {
+		for {
+			select {
+			case subject := <-mails:
+				if subject == "fail" {
+					return errors.New("imap connection lost")
+				}
+				e := &Event{DedupeID: subject, Attributes: map[string]string{"folder": "INBOX"}}
+				if err := e.SetPayload(ContentTypeText, subject); err != nil {
+					return err
+				}
+				if err := emit(ctx, e); err != nil {
+					return err
+				}
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}, "New mails"))
+	return p
+}
+
+func TestGRPCSubscribe(t *testing.T) {
+	mails := make(chan string, 1)
+	client := dispenseTestClient(t, inboxPlugin(t, mails)).(EventSubscriber)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.Subscribe(ctx, []string{"mrn:mail:imap:received"})
+	require.NoError(t, err)
+
+	mails <- "Invoice"
+	first, err := stream.Recv()
+	require.NoError(t, err)
+	assert.NotEmpty(t, first.ID)
+	assert.Equal(t, "mrn:mail:imap:received", first.Resource)
+	assert.Equal(t, "Invoice", first.DedupeID)
+	assert.Equal(t, map[string]string{"folder": "INBOX"}, first.Attributes)
+	assert.WithinDuration(t, time.Now(), first.Time, time.Minute)
+	var subject string
+	require.NoError(t, first.DecodePayload(&subject))
+	assert.Equal(t, "Invoice", subject)
+
+	mails <- "Reminder"
+	second, err := stream.Recv()
+	require.NoError(t, err)
+	require.NoError(t, client.Acknowledge(context.Background(), second.ID))
+	cancel()
+
+	// the unacknowledged event is sent again with the next subscription
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	stream, err = client.Subscribe(ctx, []string{"mrn:mail:imap:received"})
+	require.NoError(t, err)
+	resent, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, first.ID, resent.ID)
+	assert.Equal(t, "Invoice", resent.DedupeID)
+
+	// a failing trigger ends the subscription
+	mails <- "fail"
+	_, err = stream.Recv()
+	assert.ErrorContains(t, err, "trigger mrn:mail:imap:received failed: imap connection lost")
+}
+
+func TestGRPCSubscribeNotSupported(t *testing.T) {
+	// hides the Watch method of BasePlugin
+	impl := struct{ MaschineResource }{NewBasePlugin("smtp-plugin", "1.0.0")}
+	client := dispenseTestClient(t, impl).(EventSubscriber)
+	_, err := client.Subscribe(context.Background(), []string{"mrn:mail:imap:received"})
+	assert.ErrorIs(t, err, ErrEventsNotSupported)
+}
+
+func TestGRPCSubscribeShutdown(t *testing.T) {
+	client := dispenseTestClient(t, inboxPlugin(t, nil))
+	stream, err := client.(EventSubscriber).Subscribe(context.Background(), []string{"mrn:mail:imap:received"})
+	require.NoError(t, err)
+
+	require.NoError(t, client.(Shutdowner).Shutdown(context.Background()))
+	_, err = stream.Recv()
+	assert.ErrorIs(t, err, ErrShuttingDown)
+}
+
+func TestBasePluginTrigger(t *testing.T) {
+	p := inboxPlugin(t, nil)
+	assert.EqualError(t, p.RegisterTrigger("mrn:mail:imap:received", func(ctx context.Context, emit EmitFunc) error {
+		return nil
+	}, "New mails"), "function already registered: mrn:mail:imap:received")
+	assert.EqualError(t, p.RegisterPlanFunction("mrn:mail:imap:received", func(ctx context.Context, req *TypedExecuteRequest) ([]Change, error) {
+		return nil, nil
+	}), "function not registered: mrn:mail:imap:received")
+
+	md, err := p.GetMetadata(context.Background(), &GetMetadataRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mrn:mail:imap:received"}, md.SupportedResources)
+
+	resp, err := p.Execute(context.Background(), &ExecuteRequest{Resource: "mrn:mail:imap:received"})
+	require.NoError(t, err)
+	assert.Equal(t, "resource mrn:mail:imap:received is a trigger and cannot be executed", resp.Error)
+
+	assert.EqualError(t, p.Watch(context.Background(), "mrn:mail:smtp:send", nil), "unknown trigger: mrn:mail:smtp:send")
+}
@@ -1,20 +1,41 @@
 package sdk
 
 import (
+	"bytes"
 	"context"
+	"sync/atomic"
 	
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 	pluginv1 "maschine.io/plugin-sdk/proto/plugin/v1"
+	"maschine.io/plugin-sdk/sdk/manifest"
+)
+
+var (
+	_ MaschineResource = (*grpcClient)(nil)
+	_ ManifestProvider = (*grpcClient)(nil)
+	_ Configurer       = (*grpcClient)(nil)
+	_ Shutdowner       = (*grpcClient)(nil)
+	_ BatchExecutor    = (*grpcClient)(nil)
+	_ SessionClient    = (*grpcClient)(nil)
+	_ Planner          = (*grpcClient)(nil)
+	_ EventSubscriber  = (*grpcClient)(nil)
 )
 
 // grpcClient is an implementation of MaschineResource that talks over RPC
 type grpcClient struct {
-	client pluginv1.PluginClient
+	client   pluginv1.PluginClient
+	transfer TransferConfig
+	// batchUnimplemented is set once the plugin answered ExecuteBatch with
+	// Unimplemented, later batches are executed one by one right away
+	batchUnimplemented atomic.Bool
 }
 
 func (c *grpcClient) GetMetadata(ctx context.Context, req *GetMetadataRequest) (*GetMetadataResponse, error) {
 	resp, err := c.client.GetMetadata(ctx, &pluginv1.GetMetadataRequest{})
 	if err != nil {
-		return nil, err
+		return nil, clientError(err)
 	}
 	
 	return &GetMetadataResponse{
@@ -26,32 +47,104 @@ func (c *grpcClient) GetMetadata(ctx context.Context, req *GetMetadataRequest) (
 }
 
 func (c *grpcClient) Execute(ctx context.Context, req *ExecuteRequest) (*ExecuteResponse, error) {
-	resp, err := c.client.Execute(ctx, &pluginv1.ExecuteRequest{
-		Resource:    req.Resource,
-		Input:       req.Input,
-		Parameters:  req.Parameters,
-		Credentials: req.Credentials,
-		Context:     req.Context,
-	})
+	pbReq := toProtoRequest(req)
+	
+	// Requests above the threshold are uploaded in chunks
+	if proto.Size(pbReq) > c.transfer.Threshold {
+		id, err := c.upload(ctx, pbReq)
+		if err != nil {
+			return nil, err
+		}
+		pbReq = &pluginv1.ExecuteRequest{PayloadId: id}
+	}
+	
+	resp, err := c.client.Execute(outgoingLog(outgoingAttempt(outgoingAccept(ctx))), pbReq)
 	if err != nil {
-		return nil, err
+		return nil, clientError(err)
 	}
 	
-	return &ExecuteResponse{
-		Output:   resp.Output,
-		Error:    resp.Error,
-		Metadata: resp.Metadata,
-	}, nil
+	// Responses above the threshold are downloaded in chunks
+	if resp.PayloadId != "" {
+		if resp, err = c.download(ctx, resp.PayloadId); err != nil {
+			return nil, err
+		}
+	}
+	
+	return fromProtoResponse(resp), nil
 }
 
 func (c *grpcClient) HealthCheck(ctx context.Context, req *HealthCheckRequest) (*HealthCheckResponse, error) {
-	resp, err := c.client.HealthCheck(ctx, &pluginv1.HealthCheckRequest{})
+	resp, err := c.client.HealthCheck(ctx, &pluginv1.HealthCheckRequest{Probe: pluginv1.HealthProbe(req.Probe)})
 	if err != nil {
-		return nil, err
+		return nil, clientError(err)
 	}
 	
-	return &HealthCheckResponse{
-		Healthy: resp.Healthy,
-		Message: resp.Message,
-	}, nil
-}
\ No newline at end of file
+	return fromProtoHealth(resp), nil
+}
+
+// GetManifest returns the manifest reported by the plugin. It returns
+// ErrManifestNotProvided if the plugin does not provide one.
+func (c *grpcClient) GetManifest(ctx context.Context) (*manifest.PluginManifest, error) {
+	resp, err := c.client.GetManifest(ctx, &pluginv1.GetManifestRequest{})
+	if status.Code(err) == codes.Unimplemented {
+		return nil, ErrManifestNotProvided
+	}
+	if err != nil {
+		return nil, clientError(err)
+	}
+	
+	return manifest.Read(bytes.NewReader(resp.Manifest))
+}
+
+// Configure sends the configuration to the plugin. A rejected configuration
+// is returned as manifest.ValidationErrors.
+func (c *grpcClient) Configure(ctx context.Context, req *ConfigureRequest) error {
+	pbReq := &pluginv1.ConfigureRequest{
+		Environment: req.Environment,
+	}
+	for _, name := range sortedKeys(req.Credentials) {
+		pbReq.Credentials = append(pbReq.Credentials, &pluginv1.CredentialValues{
+			Name:   name,
+			Values: req.Credentials[name],
+		})
+	}
+	
+	resp, err := c.client.Configure(ctx, pbReq)
+	if err != nil {
+		return clientError(err)
+	}
+	if len(resp.Errors) == 0 {
+		return nil
+	}
+	
+	errors := make(manifest.ValidationErrors, 0, len(resp.Errors))
+	for _, e := range resp.Errors {
+		errors = append(errors, manifest.ValidationError{Field: e.Field, Message: e.Message})
+	}
+	return errors
+}
+
+func toProtoRequest(req *ExecuteRequest) *pluginv1.ExecuteRequest {
+	return &pluginv1.ExecuteRequest{
+		Resource:              req.Resource,
+		Input:                 req.Input,
+		Parameters:            req.Parameters,
+		Credentials:           req.Credentials,
+		Context:               req.Context,
+		SessionId:             req.SessionID,
+		DryRun:                req.DryRun,
+		IdempotencyKey:        req.IdempotencyKey,
+		ContentType:           req.ContentType,
+		ParameterContentTypes: req.ParameterContentTypes,
+		ExecutionId:           req.ExecutionID,
+	}
+}
+
+func fromProtoResponse(resp *pluginv1.ExecuteResponse) *ExecuteResponse {
+	return &ExecuteResponse{
+		Output:      resp.Output,
+		Error:       resp.Error,
+		Metadata:    resp.Metadata,
+		ContentType: resp.ContentType,
+	}
+}
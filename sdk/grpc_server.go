@@ -2,8 +2,15 @@ package sdk
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"sync"
 	
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	pluginv1 "maschine.io/plugin-sdk/proto/plugin/v1"
+	"maschine.io/plugin-sdk/sdk/logger"
+	"maschine.io/plugin-sdk/sdk/manifest"
 )
 
 // grpcServer is the server that GRPCServer will construct
@@ -11,9 +18,38 @@ type grpcServer struct {
 	pluginv1.UnimplementedPluginServer
 	// This is our real implementation
 	Impl MaschineResource
+	
+	transfer TransferConfig
+	payloads *payloadStore
+	
+	// batchConcurrency limits concurrent executions of ExecuteEach
+	batchConcurrency int
+	sessions         *sessionManager
+	events           *eventOutbox
+	
+	mu            sync.Mutex
+	removeSecrets func()
+	
+	// in-flight executions, see begin and end
+	draining bool
+	active   int
+	inflight sync.WaitGroup
 }
 
-func (s *grpcServer) GetMetadata(ctx context.Context, req *pluginv1.GetMetadataRequest) (*pluginv1.GetMetadataResponse, error) {
+func newGRPCServer(impl MaschineResource, transfer TransferConfig) *grpcServer {
+	transfer = transfer.withDefaults()
+	return &grpcServer{
+		Impl:     impl,
+		transfer: transfer,
+		payloads: newPayloadStore(transfer.PayloadTTL),
+		sessions: newSessionManager(0),
+		events:   newEventOutbox(),
+	}
+}
+
+func (s *grpcServer) GetMetadata(ctx context.Context, req *pluginv1.GetMetadataRequest) (_ *pluginv1.GetMetadataResponse, err error) {
+	defer recoverPanic("GetMetadata", &err)
+	
 	resp, err := s.Impl.GetMetadata(ctx, &GetMetadataRequest{})
 	if err != nil {
 		return nil, err
@@ -27,36 +63,179 @@ func (s *grpcServer) GetMetadata(ctx context.Context, req *pluginv1.GetMetadataR
 	}, nil
 }
 
-func (s *grpcServer) Execute(ctx context.Context, req *pluginv1.ExecuteRequest) (*pluginv1.ExecuteResponse, error) {
-	resp, err := s.Impl.Execute(ctx, &ExecuteRequest{
-		Resource:    req.Resource,
-		Input:       req.Input,
-		Parameters:  req.Parameters,
-		Credentials: req.Credentials,
-		Context:     req.Context,
-	})
+func (s *grpcServer) Execute(ctx context.Context, req *pluginv1.ExecuteRequest) (_ *pluginv1.ExecuteResponse, err error) {
+	defer recoverPanic("Execute", &err)
+	
+	if !s.begin() {
+		return nil, status.Error(codes.Unavailable, ErrShuttingDown.Error())
+	}
+	defer s.end()
+	
+	req, err = s.resolveRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkDryRun(ctx, req); err != nil {
+		return nil, err
+	}
+	
+	ctx, release, err := s.withSession(incomingLog(incomingAttempt(incomingAccept(ctx))), req.SessionId)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	defer release()
+	
+	r := fromProtoRequest(req)
+	resp, err := s.Impl.Execute(withExecutionLogger(ctx, r), r)
 	if err != nil {
 		// If Execute returns an error, wrap it in the response
-		return &pluginv1.ExecuteResponse{
-			Error: err.Error(),
-		}, nil
+		return toProtoResponse(errorResponse(err)), nil
 	}
 	
-	return &pluginv1.ExecuteResponse{
-		Output:   resp.Output,
-		Error:    resp.Error,
-		Metadata: resp.Metadata,
-	}, nil
+	return s.offloadResponse(toProtoResponse(resp))
 }
 
-func (s *grpcServer) HealthCheck(ctx context.Context, req *pluginv1.HealthCheckRequest) (*pluginv1.HealthCheckResponse, error) {
-	resp, err := s.Impl.HealthCheck(ctx, &HealthCheckRequest{})
+func (s *grpcServer) HealthCheck(ctx context.Context, req *pluginv1.HealthCheckRequest) (_ *pluginv1.HealthCheckResponse, err error) {
+	defer recoverPanic("HealthCheck", &err)
+	
+	resp, err := s.Impl.HealthCheck(ctx, &HealthCheckRequest{Probe: HealthProbe(req.Probe)})
 	if err != nil {
 		return nil, err
 	}
 	
-	return &pluginv1.HealthCheckResponse{
-		Healthy: resp.Healthy,
-		Message: resp.Message,
+	return toProtoHealth(resp.withStatus()), nil
+}
+
+func (s *grpcServer) GetManifest(ctx context.Context, req *pluginv1.GetManifestRequest) (_ *pluginv1.GetManifestResponse, err error) {
+	defer recoverPanic("GetManifest", &err)
+	
+	provider, ok := lookup[ManifestProvider](s.Impl)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, ErrManifestNotProvided.Error())
+	}
+	
+	m, err := provider.GetManifest(ctx)
+	if errors.Is(err, ErrManifestNotProvided) {
+		return nil, status.Error(codes.Unimplemented, err.Error())
+	}
+	if err != nil {
+		return nil, err
+	}
+	
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to encode manifest: %v", err)
+	}
+	
+	return &pluginv1.GetManifestResponse{
+		Manifest: data,
 	}, nil
-}
\ No newline at end of file
+}
+
+func (s *grpcServer) Configure(ctx context.Context, req *pluginv1.ConfigureRequest) (_ *pluginv1.ConfigureResponse, err error) {
+	defer recoverPanic("Configure", &err)
+	
+	cfg := &ConfigureRequest{
+		Environment: req.Environment,
+		Credentials: make(map[string]map[string]string, len(req.Credentials)),
+	}
+	for _, c := range req.Credentials {
+		cfg.Credentials[c.Name] = c.Values
+	}
+	
+	var m *manifest.PluginManifest
+	if provider, ok := lookup[ManifestProvider](s.Impl); ok {
+		if m, err = provider.GetManifest(ctx); err != nil && !errors.Is(err, ErrManifestNotProvided) {
+			return nil, err
+		}
+	}
+	
+	// Secret credentials are redacted from the plugin logs as long as they
+	// are configured, and from the error messages returned to the host.
+	// Without manifest, all credentials are treated as secret.
+	var secretKeys map[string]bool
+	if m != nil {
+		secretKeys = SecretCredentialKeys(m.Configuration)
+	}
+	var secrets []string
+	for set, values := range cfg.Credentials {
+		for field, v := range values {
+			if secretKeys == nil || secretKeys[CredentialKey(set, field)] {
+				secrets = append(secrets, v)
+			}
+		}
+	}
+	s.setConfiguredSecrets(secrets)
+	scrubber := NewScrubber(secrets...)
+	
+	// Validate against the manifest if the plugin provides one
+	if m != nil {
+		if cfg, err = ValidateConfiguration(m.Configuration, cfg); err != nil {
+			return configureErrorResponse(err, scrubber), nil
+		}
+	}
+	
+	configurer, ok := lookup[Configurer](s.Impl)
+	if !ok {
+		return &pluginv1.ConfigureResponse{}, nil
+	}
+	if err := configurer.Configure(ctx, cfg); err != nil {
+		return configureErrorResponse(err, scrubber), nil
+	}
+	return &pluginv1.ConfigureResponse{}, nil
+}
+
+// setConfiguredSecrets replaces the secrets of the previous configuration
+// that are redacted from the plugin logs
+func (s *grpcServer) setConfiguredSecrets(secrets []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	
+	if s.removeSecrets != nil {
+		s.removeSecrets()
+	}
+	s.removeSecrets = logger.AddSecrets(secrets...)
+}
+
+// configureErrorResponse converts validation errors into field errors. Any
+// other error is reported as a single error without field.
+func configureErrorResponse(err error, scrubber *Scrubber) *pluginv1.ConfigureResponse {
+	var ve manifest.ValidationErrors
+	if !errors.As(err, &ve) {
+		ve = manifest.ValidationErrors{{Message: err.Error()}}
+	}
+	
+	resp := &pluginv1.ConfigureResponse{}
+	for _, e := range ve {
+		resp.Errors = append(resp.Errors, &pluginv1.FieldError{
+			Field:   e.Field,
+			Message: scrubber.Scrub(e.Message),
+		})
+	}
+	return resp
+}
+
+func fromProtoRequest(req *pluginv1.ExecuteRequest) *ExecuteRequest {
+	return &ExecuteRequest{
+		Resource:              req.Resource,
+		Input:                 req.Input,
+		Parameters:            req.Parameters,
+		Credentials:           req.Credentials,
+		Context:               req.Context,
+		SessionID:             req.SessionId,
+		DryRun:                req.DryRun,
+		IdempotencyKey:        req.IdempotencyKey,
+		ContentType:           req.ContentType,
+		ParameterContentTypes: req.ParameterContentTypes,
+		ExecutionID:           req.ExecutionId,
+	}
+}
+
+func toProtoResponse(resp *ExecuteResponse) *pluginv1.ExecuteResponse {
+	return &pluginv1.ExecuteResponse{
+		Output:      resp.Output,
+		Error:       resp.Error,
+		Metadata:    resp.Metadata,
+		ContentType: resp.ContentType,
+	}
+}
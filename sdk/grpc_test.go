@@ -0,0 +1,54 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/go-plugin"
+	"github.com/stretchr/testify/require"
+)
+
+// dispenseTestClient serves impl over an in-memory go-plugin gRPC connection
+// and returns the dispensed client
+func dispenseTestClient(t *testing.T, impl MaschineResource) MaschineResource {
+	t.Helper()
+	return dispenseTestPlugin(t, &MaschinePlugin{Impl: impl})
+}
+
+// dispenseTestPlugin serves p over an in-memory go-plugin gRPC connection
+// and returns the dispensed client
+func dispenseTestPlugin(t *testing.T, p *MaschinePlugin) MaschineResource {
+	t.Helper()
+
+	client, _ := plugin.TestPluginGRPCConn(t, false, map[string]plugin.Plugin{
+		PluginName: p,
+	})
+	// closing the client shuts the server down through its controller, a
+	// second Stop races with it
+	t.Cleanup(func() { client.Close() })
+
+	raw, err := client.Dispense(PluginName)
+	require.NoError(t, err)
+	return raw.(MaschineResource)
+}
+
+func TestGRPCGetManifest(t *testing.T) {
+	p := NewBasePlugin("mail-plugin", "1.0.0")
+	client := dispenseTestClient(t, p).(ManifestProvider)
+
+	_, err := client.GetManifest(context.Background())
+	require.ErrorIs(t, err, ErrManifestNotProvided)
+
+	m := testMailManifest()
+	p.SetManifest(m)
+
+	reported, err := client.GetManifest(context.Background())
+	require.NoError(t, err)
+
+	expected, err := json.Marshal(m)
+	require.NoError(t, err)
+	actual, err := json.Marshal(reported)
+	require.NoError(t, err)
+	require.JSONEq(t, string(expected), string(actual))
+}
@@ -0,0 +1,368 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	pluginv1 "maschine.io/plugin-sdk/proto/plugin/v1"
+)
+
+// DefaultDependencyCheckTimeout is the default for DependencyCheck.Timeout
+const DefaultDependencyCheckTimeout = 5 * time.Second
+
+// HealthProbe selects what a health check verifies
+type HealthProbe int
+
+const (
+	// ProbeReadiness checks that the plugin can serve executions, including
+	// its dependencies
+	ProbeReadiness HealthProbe = iota
+	// ProbeLiveness checks that the plugin process works. Only dependency
+	// checks marked as Liveness run.
+	ProbeLiveness
+)
+
+// HealthStatus is the result of a health check
+type HealthStatus int
+
+const (
+	// HealthUnknown is reported by plugins that only set Healthy
+	HealthUnknown HealthStatus = iota
+	HealthHealthy
+	// HealthDegraded means the plugin serves executions, but a non-critical
+	// dependency failed
+	HealthDegraded
+	HealthUnhealthy
+)
+
+func (s HealthStatus) String() string {
+	switch s {
+	case HealthHealthy:
+		return "healthy"
+	case HealthDegraded:
+		return "degraded"
+	case HealthUnhealthy:
+		return "unhealthy"
+	}
+	return "unknown"
+}
+
+// DependencyHealth is the result of a dependency check
+type DependencyHealth struct {
+	Name     string
+	Status   HealthStatus
+	Message  string
+	Latency  time.Duration
+	Critical bool
+}
+
+// DependencyCheck checks an external system the plugin depends on, like an
+// SMTP server or an API
+type DependencyCheck struct {
+	Name string
+	// Critical dependencies make the plugin unhealthy if they fail, others
+	// only degrade it
+	Critical bool
+	// Liveness checks also run for liveness probes. Most dependencies only
+	// matter for readiness.
+	Liveness bool
+	// Timeout limits the check. Defaults to DefaultDependencyCheckTimeout.
+	Timeout time.Duration
+	// Check returns an error if the dependency is not available
+	Check func(ctx context.Context) error
+}
+
+// HealthChecks is a registry of named dependency checks. The zero value is
+// an empty registry.
+type HealthChecks struct {
+	mu     sync.RWMutex
+	checks []DependencyCheck
+}
+
+// Add registers a dependency check
+func (h *HealthChecks) Add(c DependencyCheck) error {
+	if c.Name == "" {
+		return errors.New("check name is required")
+	}
+	if c.Check == nil {
+		return fmt.Errorf("check %s is nil", c.Name)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, existing := range h.checks {
+		if existing.Name == c.Name {
+			return fmt.Errorf("check already registered: %s", c.Name)
+		}
+	}
+	h.checks = append(h.checks, c)
+	return nil
+}
+
+// Run runs the checks of the probe concurrently and reports the worst
+// status. The message lists the failed checks.
+func (h *HealthChecks) Run(ctx context.Context, probe HealthProbe) *HealthCheckResponse {
+	h.mu.RLock()
+	var checks []DependencyCheck
+	for _, c := range h.checks {
+		if probe == ProbeReadiness || c.Liveness {
+			checks = append(checks, c)
+		}
+	}
+	h.mu.RUnlock()
+
+	results := make([]DependencyHealth, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = runCheck(ctx, c)
+		}()
+	}
+	wg.Wait()
+
+	resp := &HealthCheckResponse{Status: HealthHealthy, Dependencies: results}
+	var failed []string
+	for _, r := range results {
+		if r.Status == HealthHealthy {
+			continue
+		}
+		failed = append(failed, r.Name+": "+r.Message)
+		if r.Status > resp.Status {
+			resp.Status = r.Status
+		}
+	}
+	resp.Healthy = resp.Status != HealthUnhealthy
+	if len(failed) > 0 {
+		resp.Message = resp.Status.String() + ": " + strings.Join(failed, "; ")
+	}
+	return resp
+}
+
+// runCheck runs a single check with its timeout. Panics fail the check.
+func runCheck(ctx context.Context, c DependencyCheck) (result DependencyHealth) {
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = DefaultDependencyCheckTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result = DependencyHealth{Name: c.Name, Status: HealthHealthy, Critical: c.Critical}
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if v := recover(); v != nil {
+				done <- fmt.Errorf("check panicked: %v", v)
+			}
+		}()
+		done <- c.Check(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("check timed out after %s", timeout)
+	}
+	result.Latency = time.Since(start)
+
+	if err != nil {
+		result.Message = err.Error()
+		result.Status = HealthDegraded
+		if c.Critical {
+			result.Status = HealthUnhealthy
+		}
+	}
+	return result
+}
+
+// withStatus sets the status of responses of plugins that only set Healthy
+// and keeps Healthy consistent with the status
+func (r *HealthCheckResponse) withStatus() *HealthCheckResponse {
+	if r.Status == HealthUnknown {
+		r.Status = HealthUnhealthy
+		if r.Healthy {
+			r.Status = HealthHealthy
+		}
+	}
+	r.Healthy = r.Status != HealthUnhealthy
+	return r
+}
+
+func toProtoHealth(resp *HealthCheckResponse) *pluginv1.HealthCheckResponse {
+	result := &pluginv1.HealthCheckResponse{
+		Healthy: resp.Healthy,
+		Message: resp.Message,
+		Status:  pluginv1.HealthStatus(resp.Status),
+	}
+	for _, d := range resp.Dependencies {
+		result.Dependencies = append(result.Dependencies, &pluginv1.DependencyHealth{
+			Name:     d.Name,
+			Status:   pluginv1.HealthStatus(d.Status),
+			Message:  d.Message,
+			Latency:  durationpb.New(d.Latency),
+			Critical: d.Critical,
+		})
+	}
+	return result
+}
+
+func fromProtoHealth(resp *pluginv1.HealthCheckResponse) *HealthCheckResponse {
+	result := &HealthCheckResponse{
+		Healthy: resp.Healthy,
+		Message: resp.Message,
+		Status:  HealthStatus(resp.Status),
+	}
+	for _, d := range resp.Dependencies {
+		result.Dependencies = append(result.Dependencies, DependencyHealth{
+			Name:     d.Name,
+			Status:   HealthStatus(d.Status),
+			Message:  d.Message,
+			Latency:  d.Latency.AsDuration(),
+			Critical: d.Critical,
+		})
+	}
+	return result.withStatus()
+}
+
+// Service names of the standard gRPC health service. The plugin service
+// and the empty name report readiness. "plugin" is the name go-plugin pings
+// the plugin with and reports liveness, so that failing dependencies do not
+// get the plugin process killed.
+const (
+	HealthServiceLiveness = "liveness"
+	healthServicePlugin   = "plugin"
+)
+
+// healthWatchInterval is how often Watch calls of the standard gRPC health
+// service check the plugin
+var healthWatchInterval = 5 * time.Second
+
+// healthTarget is the plugin the standard gRPC health service of a server
+// reports on. go-plugin registers its own health service, so the SDK
+// answers the calls with interceptors instead. The plugin is set once
+// MaschinePlugin.GRPCServer registered it.
+type healthTarget struct {
+	mu     sync.RWMutex
+	server *grpcServer
+}
+
+// healthTargets maps the servers created by GRPCServer to their targets
+// until MaschinePlugin.GRPCServer takes the target over
+var healthTargets sync.Map
+
+func (t *healthTarget) get() *grpcServer {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.server
+}
+
+func (t *healthTarget) set(s *grpcServer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.server = s
+}
+
+// probe returns the probe of a service name of the standard gRPC health
+// service, or false if the SDK does not answer for it
+func healthProbe(service string) (HealthProbe, bool) {
+	switch service {
+	case "", pluginv1.Plugin_ServiceDesc.ServiceName:
+		return ProbeReadiness, true
+	case HealthServiceLiveness, healthServicePlugin:
+		return ProbeLiveness, true
+	}
+	return 0, false
+}
+
+// servingStatus runs the probe and converts its result
+func (t *healthTarget) servingStatus(ctx context.Context, probe HealthProbe) grpc_health_v1.HealthCheckResponse_ServingStatus {
+	resp, err := t.get().HealthCheck(ctx, &pluginv1.HealthCheckRequest{Probe: pluginv1.HealthProbe(probe)})
+	if err != nil || !resp.Healthy {
+		return grpc_health_v1.HealthCheckResponse_NOT_SERVING
+	}
+	return grpc_health_v1.HealthCheckResponse_SERVING
+}
+
+// interceptors answers Check and Watch of the standard gRPC health service
+func (t *healthTarget) interceptors() ServerInterceptors {
+	return ServerInterceptors{
+		Unary: []grpc.UnaryServerInterceptor{
+			func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+				check, ok := req.(*grpc_health_v1.HealthCheckRequest)
+				if !ok || info.FullMethod != grpc_health_v1.Health_Check_FullMethodName || t.get() == nil {
+					return handler(ctx, req)
+				}
+				probe, ok := healthProbe(check.Service)
+				if !ok {
+					return handler(ctx, req)
+				}
+				return &grpc_health_v1.HealthCheckResponse{Status: t.servingStatus(ctx, probe)}, nil
+			},
+		},
+		Stream: []grpc.StreamServerInterceptor{
+			func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+				if info.FullMethod != grpc_health_v1.Health_Watch_FullMethodName || t.get() == nil {
+					return handler(srv, ss)
+				}
+				req := &grpc_health_v1.HealthCheckRequest{}
+				if err := ss.RecvMsg(req); err != nil {
+					return err
+				}
+				probe, ok := healthProbe(req.Service)
+				if !ok {
+					return handler(srv, &replayServerStream{ServerStream: ss, msg: req})
+				}
+				return t.watch(ss, probe)
+			},
+		},
+	}
+}
+
+// watch sends the serving status whenever it changes until the client
+// cancels the call
+func (t *healthTarget) watch(ss grpc.ServerStream, probe HealthProbe) error {
+	ticker := time.NewTicker(healthWatchInterval)
+	defer ticker.Stop()
+
+	last := grpc_health_v1.HealthCheckResponse_SERVICE_UNKNOWN
+	for {
+		if status := t.servingStatus(ss.Context(), probe); status != last {
+			if err := ss.SendMsg(&grpc_health_v1.HealthCheckResponse{Status: status}); err != nil {
+				return err
+			}
+			last = status
+		}
+		select {
+		case <-ss.Context().Done():
+			return ss.Context().Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// replayServerStream returns a message that was already received once more
+type replayServerStream struct {
+	grpc.ServerStream
+	msg proto.Message
+}
+
+func (s *replayServerStream) RecvMsg(m any) error {
+	if s.msg == nil {
+		return s.ServerStream.RecvMsg(m)
+	}
+	proto.Merge(m.(proto.Message), s.msg)
+	s.msg = nil
+	return nil
+}
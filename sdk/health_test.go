@@ -0,0 +1,189 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// dependency is a check whose result is switched by the test
+type dependency struct {
+	err atomic.Value
+}
+
+func (d *dependency) fail(err error) {
+	d.err.Store(&err)
+}
+
+func (d *dependency) check(ctx context.Context) error {
+	if err, ok := d.err.Load().(*error); ok {
+		return *err
+	}
+	return nil
+}
+
+func TestHealthChecks(t *testing.T) {
+	var smtp, imap dependency
+	var h HealthChecks
+	require.NoError(t, h.Add(DependencyCheck{Name: "smtp", Critical: true, Check: smtp.check}))
+	require.NoError(t, h.Add(DependencyCheck{Name: "imap", Check: imap.check}))
+	require.NoError(t, h.Add(DependencyCheck{Name: "worker", Liveness: true, Check: func(ctx context.Context) error { return nil }}))
+	assert.EqualError(t, h.Add(DependencyCheck{Name: "smtp", Check: smtp.check}), "check already registered: smtp")
+	assert.EqualError(t, h.Add(DependencyCheck{Name: "pop3"}), "check pop3 is nil")
+
+	resp := h.Run(context.Background(), ProbeReadiness)
+	assert.Equal(t, HealthHealthy, resp.Status)
+	assert.True(t, resp.Healthy)
+	assert.Empty(t, resp.Message)
+	require.Len(t, resp.Dependencies, 3)
+	assert.Equal(t, "smtp", resp.Dependencies[0].Name)
+	assert.True(t, resp.Dependencies[0].Critical)
+
+	imap.fail(errors.New("connection refused"))
+	resp = h.Run(context.Background(), ProbeReadiness)
+	assert.Equal(t, HealthDegraded, resp.Status)
+	assert.True(t, resp.Healthy, "degraded plugins still serve")
+	assert.Equal(t, "degraded: imap: connection refused", resp.Message)
+
+	smtp.fail(errors.New("authentication failed"))
+	resp = h.Run(context.Background(), ProbeReadiness)
+	assert.Equal(t, HealthUnhealthy, resp.Status)
+	assert.False(t, resp.Healthy)
+	assert.Equal(t, "unhealthy: smtp: authentication failed; imap: connection refused", resp.Message)
+
+	resp = h.Run(context.Background(), ProbeLiveness)
+	assert.Equal(t, HealthHealthy, resp.Status, "dependencies do not affect liveness")
+	require.Len(t, resp.Dependencies, 1)
+	assert.Equal(t, "worker", resp.Dependencies[0].Name)
+}
+
+func TestHealthCheckTimeoutAndPanic(t *testing.T) {
+	var h HealthChecks
+	require.NoError(t, h.Add(DependencyCheck{Name: "api", Timeout: 20 * time.Millisecond, Check: func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}}))
+	require.NoError(t, h.Add(DependencyCheck{Name: "cache", Check: func(ctx context.Context) error {
+		panic("nil client")
+	}}))
+
+	resp := h.Run(context.Background(), ProbeReadiness)
+	assert.Equal(t, HealthDegraded, resp.Status)
+	assert.Equal(t, "check timed out after 20ms", resp.Dependencies[0].Message)
+	assert.GreaterOrEqual(t, resp.Dependencies[0].Latency, 20*time.Millisecond)
+	assert.Less(t, resp.Dependencies[0].Latency, time.Second)
+	assert.Equal(t, "check panicked: nil client", resp.Dependencies[1].Message)
+}
+
+// legacyHealthPlugin only reports Healthy like plugins of older SDKs
+type legacyHealthPlugin struct {
+	*BasePlugin
+}
+
+func (p *legacyHealthPlugin) HealthCheck(ctx context.Context, req *HealthCheckRequest) (*HealthCheckResponse, error) {
+	return &HealthCheckResponse{Healthy: false, Message: "database gone"}, nil
+}
+
+func TestGRPCHealthCheck(t *testing.T) {
+	var smtp dependency
+	p := NewBasePlugin("health-plugin", "1.0.0")
+	require.NoError(t, p.AddHealthCheck(DependencyCheck{Name: "smtp", Check: smtp.check}))
+	client := dispenseTestClient(t, p)
+
+	resp, err := client.HealthCheck(context.Background(), &HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, HealthHealthy, resp.Status)
+	assert.Equal(t, "health-plugin is operational", resp.Message)
+
+	smtp.fail(errors.New("connection refused"))
+	resp, err = client.HealthCheck(context.Background(), &HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, HealthDegraded, resp.Status)
+	require.Len(t, resp.Dependencies, 1)
+	assert.Equal(t, DependencyHealth{Name: "smtp", Status: HealthDegraded, Message: "connection refused", Latency: resp.Dependencies[0].Latency}, resp.Dependencies[0])
+
+	resp, err = client.HealthCheck(context.Background(), &HealthCheckRequest{Probe: ProbeLiveness})
+	require.NoError(t, err)
+	assert.Equal(t, HealthHealthy, resp.Status)
+	assert.Empty(t, resp.Dependencies)
+
+	legacy := dispenseTestClient(t, &legacyHealthPlugin{BasePlugin: p})
+	resp, err = legacy.HealthCheck(context.Background(), &HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, HealthUnhealthy, resp.Status)
+	assert.False(t, resp.Healthy)
+}
+
+func TestStandardHealthService(t *testing.T) {
+	healthWatchInterval = 10 * time.Millisecond
+	t.Cleanup(func() { healthWatchInterval = 5 * time.Second })
+
+	var smtp dependency
+	p := NewBasePlugin("health-plugin", "1.0.0")
+	require.NoError(t, p.AddHealthCheck(DependencyCheck{Name: "smtp", Critical: true, Check: smtp.check}))
+
+	// the health service is registered like go-plugin does
+	s := GRPCServer()(nil)
+	pluginHealth := health.NewServer()
+	pluginHealth.SetServingStatus("plugin", grpc_health_v1.HealthCheckResponse_SERVING)
+	grpc_health_v1.RegisterHealthServer(s, pluginHealth)
+	require.NoError(t, (&MaschinePlugin{Impl: p}).GRPCServer(nil, s))
+	_, ok := healthTargets.Load(s)
+	assert.False(t, ok, "the server is not referenced beyond its lifetime")
+
+	lis := bufconn.Listen(1024 * 1024)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	client := grpc_health_v1.NewHealthClient(conn)
+	ctx := context.Background()
+
+	check := func(service string) grpc_health_v1.HealthCheckResponse_ServingStatus {
+		resp, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: service})
+		require.NoError(t, err)
+		return resp.Status
+	}
+
+	watchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	watch, err := client.Watch(watchCtx, &grpc_health_v1.HealthCheckRequest{})
+	require.NoError(t, err)
+	update, err := watch.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, update.Status)
+
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, check(""))
+	smtp.fail(errors.New("authentication failed"))
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, check(""))
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, check("maschine.plugin.v1.Plugin"))
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, check(HealthServiceLiveness))
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, check("plugin"), "go-plugin's ping keeps working")
+
+	update, err = watch.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, update.Status)
+
+	// other services are answered by the registered health service
+	_, err = client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: "unknown"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -0,0 +1,209 @@
+package host
+
+import (
+	"context"
+	"crypto/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"maschine.io/plugin-sdk/sdk"
+)
+
+const (
+	// DefaultBatchWindow is the default for BatchConfig.Window
+	DefaultBatchWindow = 5 * time.Millisecond
+	// DefaultBatchSize is the default for BatchConfig.MaxSize
+	DefaultBatchSize = 100
+)
+
+// ExecuteBatch runs many executions of the plugin in one call. It returns
+// one response per request in the same order; failed executions report
+// their error in the response. Executions the host rejects, like those of
+// trigger resources, fail in their response without affecting the others.
+func (p *Plugin) ExecuteBatch(ctx context.Context, reqs []*sdk.ExecuteRequest) ([]*sdk.ExecuteResponse, error) {
+	resps := make([]*sdk.ExecuteResponse, len(reqs))
+	// index maps the prepared requests to their position in reqs
+	var prepared []*sdk.ExecuteRequest
+	var index []int
+	for i, req := range reqs {
+		req, err := p.prepare(req)
+		if err != nil {
+			resps[i] = &sdk.ExecuteResponse{Error: err.Error()}
+			continue
+		}
+		if req.ExecutionID == "" {
+			// the executions share the context of the call, each gets its
+			// own ID
+			identified := *req
+			identified.ExecutionID = rand.Text()
+			req = &identified
+		}
+		prepared = append(prepared, req)
+		index = append(index, i)
+	}
+	if len(prepared) == 0 {
+		return resps, nil
+	}
+	if err := p.limit(ctx, prepared...); err != nil {
+		return nil, err
+	}
+
+	proc, err := p.running(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = p.logContext(p.accept(ctx))
+	var sent []*sdk.ExecuteResponse
+	if batcher, ok := proc.resource.(sdk.BatchExecutor); ok {
+		if sent, err = batcher.ExecuteBatch(ctx, prepared); err != nil {
+			return nil, p.crashError(proc, err)
+		}
+	} else {
+		sent = sdk.ExecuteEach(ctx, proc.resource, prepared, 0)
+	}
+	for i, resp := range sent {
+		resps[index[i]] = p.validateOutput(sdk.ContextWithExecutionID(ctx, prepared[i].ExecutionID), prepared[i], resp)
+	}
+	return resps, nil
+}
+
+// BatchConfig configures how a Batcher groups executions
+type BatchConfig struct {
+	// Window is how long the first execution of a batch waits for others
+	// to join. Defaults to DefaultBatchWindow.
+	Window time.Duration
+	// MaxSize is the number of executions that sends a batch before the
+	// window passed. Defaults to DefaultBatchSize.
+	MaxSize int
+}
+
+// Batcher groups individual executions into ExecuteBatch calls. Executions
+// started within the window of the first one are sent together.
+type Batcher struct {
+	plugin *Plugin
+	config BatchConfig
+
+	mu      sync.Mutex
+	pending []*batchCall
+	timer   *time.Timer
+}
+
+type batchCall struct {
+	ctx  context.Context
+	req  *sdk.ExecuteRequest
+	resp *sdk.ExecuteResponse
+	err  error
+	done chan struct{}
+}
+
+// NewBatcher creates a Batcher for the executions of p
+func NewBatcher(p *Plugin, cfg BatchConfig) *Batcher {
+	if cfg.Window <= 0 {
+		cfg.Window = DefaultBatchWindow
+	}
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = DefaultBatchSize
+	}
+	return &Batcher{plugin: p, config: cfg}
+}
+
+// Execute adds the execution to the next batch and waits for its response.
+// The execution keeps the execution ID of ctx. If ctx is done first,
+// Execute returns and the execution is left out of its batch unless the
+// batch was already sent; a sent batch is canceled once the contexts of all
+// its executions are done.
+func (b *Batcher) Execute(ctx context.Context, req *sdk.ExecuteRequest) (*sdk.ExecuteResponse, error) {
+	if id := sdk.ExecutionIDFromContext(ctx); id != "" && req.ExecutionID == "" {
+		identified := *req
+		identified.ExecutionID = id
+		req = &identified
+	}
+	call := &batchCall{ctx: ctx, req: req, done: make(chan struct{})}
+
+	b.mu.Lock()
+	b.pending = append(b.pending, call)
+	switch {
+	case len(b.pending) >= b.config.MaxSize:
+		calls := b.take()
+		go b.send(calls)
+	case len(b.pending) == 1:
+		b.timer = time.AfterFunc(b.config.Window, b.flush)
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.resp, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// flush sends the pending executions
+func (b *Batcher) flush() {
+	b.mu.Lock()
+	calls := b.take()
+	b.mu.Unlock()
+	b.send(calls)
+}
+
+// take removes and returns the pending executions. b.mu must be held.
+func (b *Batcher) take() []*batchCall {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	calls := b.pending
+	b.pending = nil
+	return calls
+}
+
+// send runs a batch and hands the responses to the waiting executions. The
+// batch is not bound to the context of any single execution, it is
+// canceled once the contexts of all executions are done.
+func (b *Batcher) send(calls []*batchCall) {
+	// executions whose caller stopped waiting are not sent
+	waiting := calls[:0]
+	for _, call := range calls {
+		if err := call.ctx.Err(); err != nil {
+			call.err = err
+			close(call.done)
+			continue
+		}
+		waiting = append(waiting, call)
+	}
+	calls = waiting
+	if len(calls) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var left atomic.Int32
+	left.Store(int32(len(calls)))
+	for _, call := range calls {
+		stop := context.AfterFunc(call.ctx, func() {
+			if left.Add(-1) == 0 {
+				cancel()
+			}
+		})
+		defer stop()
+	}
+
+	reqs := make([]*sdk.ExecuteRequest, len(calls))
+	for i, call := range calls {
+		reqs[i] = call.req
+	}
+
+	resps, err := b.plugin.ExecuteBatch(ctx, reqs)
+	for i, call := range calls {
+		if err != nil {
+			call.err = err
+		} else {
+			call.resp = resps[i]
+		}
+		close(call.done)
+	}
+}
@@ -0,0 +1,104 @@
+package host
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maschine.io/plugin-sdk/sdk"
+)
+
+func TestBatcher(t *testing.T) {
+	p := launchTestPlugin(t, Config{})
+	b := NewBatcher(p, BatchConfig{Window: 20 * time.Millisecond, MaxSize: 4})
+
+	var wg sync.WaitGroup
+	resps := make([]*sdk.ExecuteResponse, 10)
+	errs := make([]error, 10)
+	for i := range resps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resps[i], errs[i] = b.Execute(context.Background(), &sdk.ExecuteRequest{
+				Resource:   "mrn:test:resource:action",
+				Parameters: map[string][]byte{"param1": []byte(fmt.Sprintf("item-%d", i))},
+			})
+		}()
+	}
+	wg.Wait()
+
+	for i := range resps {
+		require.NoError(t, errs[i])
+		assert.JSONEq(t, strconv.Quote(fmt.Sprintf("item-%d", i)), string(resps[i].Output))
+	}
+}
+
+func TestExecuteBatchRejected(t *testing.T) {
+	p := launchTestPlugin(t, Config{})
+
+	resps, err := p.ExecuteBatch(context.Background(), []*sdk.ExecuteRequest{
+		{Resource: "mrn:test:resource:trigger"},
+		{Resource: "mrn:test:resource:action", Parameters: map[string][]byte{"param1": []byte("sent")}},
+		{Resource: "mrn:test:resource:query", DryRun: true},
+	})
+	require.NoError(t, err)
+	require.Len(t, resps, 3)
+	assert.Equal(t, "resource mrn:test:resource:trigger is a trigger and cannot be executed", resps[0].Error)
+	assert.JSONEq(t, `"sent"`, string(resps[1].Output), "rejected executions do not fail the batch")
+	assert.Equal(t, "resource is read-only: mrn:test:resource:query does not support dry runs", resps[2].Error)
+}
+
+func TestBatcherContext(t *testing.T) {
+	p := launchTestPlugin(t, Config{})
+	b := NewBatcher(p, BatchConfig{Window: 100 * time.Millisecond})
+	req := &sdk.ExecuteRequest{Resource: "mrn:test:resource:query"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, err := b.Execute(ctx, req)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	}()
+	resp, err := b.Execute(context.Background(), req)
+	wg.Wait()
+	require.NoError(t, err)
+	assert.Equal(t, "1", string(resp.Output), "the execution of the expired context is not sent")
+}
+
+func TestBatcherExecutionIDs(t *testing.T) {
+	log, buf := newTestLogger(hclog.Trace)
+	p := launchTestPlugin(t, Config{Logger: log})
+	b := NewBatcher(p, BatchConfig{Window: 20 * time.Millisecond})
+	req := &sdk.ExecuteRequest{Resource: "mrn:test:resource:action", Parameters: map[string][]byte{"param1": []byte(`"log"`)}}
+
+	var wg sync.WaitGroup
+	for _, id := range []string{"exec-1", "exec-2"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := b.Execute(sdk.ContextWithExecutionID(context.Background(), id), req)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	_, err := p.ExecuteBatch(context.Background(), []*sdk.ExecuteRequest{req, req})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return len(buf.entries("mail sent")) == 4 }, 5*time.Second, 10*time.Millisecond)
+	ids := make(map[any]bool)
+	for _, entry := range buf.entries("mail sent") {
+		ids[entry["execution_id"]] = true
+	}
+	assert.Len(t, ids, 4, "every execution of a batch has its own ID")
+	assert.True(t, ids["exec-1"] && ids["exec-2"], "the Batcher keeps the IDs of the callers")
+}
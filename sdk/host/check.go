@@ -0,0 +1,144 @@
+package host
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"maschine.io/plugin-sdk/sdk"
+)
+
+// Check runs a resource declared with category check and returns its
+// result. The duration is measured by the host if the plugin did not
+// report it.
+func (p *Plugin) Check(ctx context.Context, req *sdk.ExecuteRequest) (*sdk.CheckResult, error) {
+	if p.category(req.Resource) != "check" {
+		return nil, fmt.Errorf("resource %s is not declared as check", req.Resource)
+	}
+
+	start := time.Now()
+	resp, err := p.Execute(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	result, err := sdk.DecodeCheckResult(resp)
+	if err != nil {
+		return nil, fmt.Errorf("check %s failed: %w", req.Resource, err)
+	}
+	if result.Duration == 0 {
+		result.Duration = time.Since(start)
+	}
+	return result, nil
+}
+
+// CheckOutcome is the outcome of a single check of a CheckReport
+type CheckOutcome struct {
+	Resource string
+	// Name is the human-readable name of the resource
+	Name string
+	// Result is nil if the check could not run
+	Result *sdk.CheckResult
+	Err    error
+}
+
+// Status returns the status of the check. Checks that could not run
+// failed.
+func (o CheckOutcome) Status() sdk.CheckStatus {
+	if o.Err != nil {
+		return sdk.CheckFail
+	}
+	return o.Result.Status
+}
+
+// CheckReport is the outcome of all checks of a plugin
+type CheckReport struct {
+	Plugin string
+	// Checks are in the order of the manifest
+	Checks []CheckOutcome
+}
+
+// RunChecks runs all resources declared with category check concurrently.
+// requests holds the request of a check by resource, e.g. with its
+// parameters and credentials; other checks run without parameters.
+func (p *Plugin) RunChecks(ctx context.Context, requests map[string]*sdk.ExecuteRequest) *CheckReport {
+	report := &CheckReport{Plugin: p.manifest.Plugin.ID}
+	for _, r := range p.manifest.Resources {
+		if r.Category == "check" {
+			report.Checks = append(report.Checks, CheckOutcome{Resource: r.Type, Name: r.Name})
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := range report.Checks {
+		outcome := &report.Checks[i]
+		req, found := requests[outcome.Resource]
+		if !found {
+			req = &sdk.ExecuteRequest{Resource: outcome.Resource}
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			outcome.Result, outcome.Err = p.Check(ctx, req)
+		}()
+	}
+	wg.Wait()
+	return report
+}
+
+// Count returns the number of checks with the status
+func (r *CheckReport) Count(status sdk.CheckStatus) int {
+	n := 0
+	for _, c := range r.Checks {
+		if c.Status() == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Passed reports whether no check failed. Warnings do not fail a report.
+func (r *CheckReport) Passed() bool {
+	return r.Count(sdk.CheckFail) == 0
+}
+
+// Write prints the report for humans, one block per check
+func (r *CheckReport) Write(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Checks of %s\n\n", r.Plugin)
+	for _, c := range r.Checks {
+		name := c.Name
+		if name == "" {
+			name = c.Resource
+		}
+		fmt.Fprintf(&b, "%-5s %s (%s)", strings.ToUpper(string(c.Status())), name, c.Resource)
+		if c.Err != nil {
+			fmt.Fprintf(&b, "\n      error: %v\n", c.Err)
+			continue
+		}
+		fmt.Fprintf(&b, " %s\n", c.Result.Duration.Round(time.Millisecond))
+		if c.Result.Message != "" {
+			fmt.Fprintf(&b, "      %s\n", c.Result.Message)
+		}
+		keys := make([]string, 0, len(c.Result.Evidence))
+		for k := range c.Result.Evidence {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(&b, "      %s: %s\n", k, c.Result.Evidence[k])
+		}
+		if c.Result.Remediation != "" && c.Result.Status != sdk.CheckPass {
+			fmt.Fprintf(&b, "      remediation: %s\n", c.Result.Remediation)
+		}
+	}
+	fmt.Fprintf(&b, "\n%d checks: %d passed, %d warnings, %d failed\n",
+		len(r.Checks), r.Count(sdk.CheckPass), r.Count(sdk.CheckWarn), r.Count(sdk.CheckFail))
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,81 @@
+package host
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maschine.io/plugin-sdk/sdk"
+)
+
+// checkRequest requests the status from the test check
+func checkRequest(status string) *sdk.ExecuteRequest {
+	return &sdk.ExecuteRequest{
+		Resource:   "mrn:test:resource:check",
+		Parameters: map[string][]byte{"status": []byte(status)},
+	}
+}
+
+func TestCheck(t *testing.T) {
+	p := launchTestPlugin(t, Config{})
+
+	result, err := p.Check(context.Background(), checkRequest("warn"))
+	require.NoError(t, err)
+	assert.Equal(t, sdk.CheckWarn, result.Status)
+	assert.Equal(t, "status warn", result.Message)
+	assert.Equal(t, map[string]string{"requested": "warn"}, result.Evidence)
+	assert.Equal(t, "request another status", result.Remediation)
+	assert.Positive(t, result.Duration)
+
+	_, err = p.Check(context.Background(), checkRequest("unknown"))
+	assert.EqualError(t, err, `check mrn:test:resource:check failed: invalid check status: "unknown"`)
+
+	_, err = p.Check(context.Background(), &sdk.ExecuteRequest{Resource: "mrn:test:resource:query"})
+	assert.EqualError(t, err, "resource mrn:test:resource:query is not declared as check")
+}
+
+func TestRunChecks(t *testing.T) {
+	p := launchTestPlugin(t, Config{})
+
+	report := p.RunChecks(context.Background(), nil)
+	require.Len(t, report.Checks, 1)
+	assert.Equal(t, "Test Check", report.Checks[0].Name)
+	assert.Error(t, report.Checks[0].Err, "the check needs a status")
+	assert.False(t, report.Passed())
+
+	report = p.RunChecks(context.Background(), map[string]*sdk.ExecuteRequest{
+		"mrn:test:resource:check": checkRequest("pass"),
+	})
+	assert.True(t, report.Passed())
+	assert.Equal(t, 1, report.Count(sdk.CheckPass))
+
+	report.Checks = append(report.Checks,
+		CheckOutcome{Resource: "mrn:test:cert:check", Name: "Certificate", Result: &sdk.CheckResult{
+			Status:      sdk.CheckWarn,
+			Evidence:    map[string]string{"expires": "2026-11-01", "issuer": "Test CA"},
+			Remediation: "Renew the certificate",
+		}},
+		CheckOutcome{Resource: "mrn:test:smtp:check", Name: "SMTP", Err: assert.AnError},
+	)
+	report.Checks[0].Result.Duration = 0
+
+	var out strings.Builder
+	require.NoError(t, report.Write(&out))
+	assert.Equal(t, `Checks of io.test.plugin
+
+PASS  Test Check (mrn:test:resource:check) 0s
+      status pass
+      requested: pass
+WARN  Certificate (mrn:test:cert:check) 0s
+      expires: 2026-11-01
+      issuer: Test CA
+      remediation: Renew the certificate
+FAIL  SMTP (mrn:test:smtp:check)
+      error: assert.AnError general error for testing
+
+3 checks: 1 passed, 1 warnings, 1 failed
+`, out.String())
+	assert.False(t, report.Passed())
+}
@@ -0,0 +1,98 @@
+package host
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultStderrBufferSize is the default for Config.StderrBufferSize
+const DefaultStderrBufferSize = 64 << 10
+
+// crashWait is how long a failed call waits for the plugin process to exit
+// before the failure is reported as a crash
+const crashWait = time.Second
+
+// CrashError is returned if the plugin process exited during a call
+type CrashError struct {
+	Path string
+	// Stderr holds the last output of the plugin process on stderr, at most
+	// Config.StderrBufferSize bytes
+	Stderr string
+	Err    error
+}
+
+func (e *CrashError) Error() string {
+	return fmt.Sprintf("plugin %s crashed: %v", e.Path, e.Err)
+}
+
+func (e *CrashError) Unwrap() error {
+	return e.Err
+}
+
+// crashError returns a *CrashError if err was caused by the exit of the
+// plugin process, otherwise err
+func (p *Plugin) crashError(proc process, err error) error {
+	if status.Code(err) != codes.Unavailable {
+		return err
+	}
+
+	deadline := time.Now().Add(crashWait)
+	for !proc.client.Exited() {
+		if time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return &CrashError{Path: p.config.Path, Stderr: proc.stderr.String(), Err: err}
+}
+
+// ringBuffer keeps the last size bytes written to it
+type ringBuffer struct {
+	mu   sync.Mutex
+	buf  []byte
+	size int
+	next int
+	full bool
+}
+
+func newRingBuffer(size int) *ringBuffer {
+	return &ringBuffer{buf: make([]byte, size), size: size}
+}
+
+func (b *ringBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	n := len(p)
+	if n >= b.size {
+		copy(b.buf, p[n-b.size:])
+		b.next, b.full = 0, true
+		return n, nil
+	}
+
+	copied := copy(b.buf[b.next:], p)
+	if copied < n {
+		copy(b.buf, p[copied:])
+		b.full = true
+	}
+	b.next = (b.next + n) % b.size
+	if b.next == 0 {
+		b.full = true
+	}
+	return n, nil
+}
+
+// String returns the buffered bytes in the order they were written
+func (b *ringBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.full {
+		return string(b.buf[:b.next])
+	}
+	return string(b.buf[b.next:]) + string(b.buf[:b.next])
+}
@@ -0,0 +1,66 @@
+package host
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maschine.io/plugin-sdk/sdk"
+)
+
+func TestRingBuffer(t *testing.T) {
+	b := newRingBuffer(8)
+	assert.Equal(t, "", b.String())
+
+	b.Write([]byte("abc"))
+	assert.Equal(t, "abc", b.String())
+
+	b.Write([]byte("defgh"))
+	assert.Equal(t, "abcdefgh", b.String())
+
+	b.Write([]byte("ij"))
+	assert.Equal(t, "cdefghij", b.String())
+
+	b.Write([]byte("0123456789"))
+	assert.Equal(t, "23456789", b.String())
+
+	b.Write([]byte("xyz"))
+	assert.Equal(t, "56789xyz", b.String())
+}
+
+func TestPluginCrash(t *testing.T) {
+	p := launchTestPlugin(t, Config{})
+
+	_, err := p.Execute(context.Background(), &sdk.ExecuteRequest{
+		Resource:   "mrn:test:resource:action",
+		Parameters: map[string][]byte{"param1": []byte("crash")},
+	})
+	var crash *CrashError
+	require.True(t, errors.As(err, &crash), "unexpected error: %v", err)
+	assert.Contains(t, crash.Stderr, "lost connection to smtp.example.com")
+
+	// the plugin is restarted for the next call
+	resp, err := p.Execute(context.Background(), &sdk.ExecuteRequest{
+		Resource:   "mrn:test:resource:action",
+		Parameters: map[string][]byte{"param1": []byte("ok")},
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `"ok"`, string(resp.Output))
+}
+
+func TestPluginPanic(t *testing.T) {
+	p := launchTestPlugin(t, Config{})
+
+	_, err := p.Execute(context.Background(), &sdk.ExecuteRequest{
+		Resource:   "mrn:test:resource:action",
+		Parameters: map[string][]byte{"param1": []byte("panic")},
+	})
+	var panicErr *sdk.PanicError
+	require.True(t, errors.As(err, &panicErr), "unexpected error: %v", err)
+	assert.Equal(t, "unexpected mail header", panicErr.Value)
+	assert.True(t, strings.Contains(panicErr.Stack, "host_test.go"))
+	assert.False(t, p.client.Exited())
+}
@@ -0,0 +1,216 @@
+package host
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"maschine.io/plugin-sdk/sdk"
+)
+
+// DefaultResubscribeInterval is the default for
+// SubscribeConfig.ResubscribeInterval
+const DefaultResubscribeInterval = 5 * time.Second
+
+// DefaultDedupeWindow is the default for SubscribeConfig.DedupeWindow
+const DefaultDedupeWindow = 24 * time.Hour
+
+// EventHandler handles an event of a trigger resource, usually by starting
+// a state machine execution. Events whose handler failed are delivered
+// again with the next subscription, e.g. after a restart of the host.
+type EventHandler func(ctx context.Context, e *sdk.Event) error
+
+// SubscribeConfig configures the delivery of events
+type SubscribeConfig struct {
+	// Handlers handle the events by trigger resource. Every resource must
+	// be declared with category trigger in the manifest.
+	Handlers map[string]EventHandler
+	// Journal records the received events until they are handled. Defaults
+	// to a MemoryJournal; use a FileJournal to redeliver events after a
+	// restart of the host.
+	Journal Journal
+	// DedupeWindow is how long handled events are kept to drop duplicates.
+	// Defaults to DefaultDedupeWindow.
+	DedupeWindow time.Duration
+	// ResubscribeInterval is how long to wait before subscribing again
+	// after the subscription failed, e.g. because the plugin crashed.
+	// Defaults to DefaultResubscribeInterval.
+	ResubscribeInterval time.Duration
+}
+
+// Subscription delivers the events of trigger resources to their handlers
+// until it is closed
+type Subscription struct {
+	plugin    *Plugin
+	config    SubscribeConfig
+	resources []string
+	logger    hclog.Logger
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// Subscribe watches the trigger resources of cfg.Handlers until ctx is
+// canceled or the subscription is closed. Events are recorded in the
+// journal and acknowledged before they are handled, so events the plugin
+// emits again are dropped by their dedupe ID. Events are handled one at a
+// time in the order they arrive. If the subscription fails, the plugin is
+// restarted if needed and subscribed again.
+func (p *Plugin) Subscribe(ctx context.Context, cfg SubscribeConfig) (*Subscription, error) {
+	if len(cfg.Handlers) == 0 {
+		return nil, errors.New("no event handlers")
+	}
+	resources := make([]string, 0, len(cfg.Handlers))
+	for resource, handler := range cfg.Handlers {
+		if handler == nil {
+			return nil, fmt.Errorf("event handler for resource %s is nil", resource)
+		}
+		if p.category(resource) != "trigger" {
+			return nil, fmt.Errorf("resource %s is not declared as trigger", resource)
+		}
+		resources = append(resources, resource)
+	}
+	sort.Strings(resources)
+
+	if cfg.Journal == nil {
+		cfg.Journal = NewMemoryJournal()
+	}
+	if cfg.DedupeWindow <= 0 {
+		cfg.DedupeWindow = DefaultDedupeWindow
+	}
+	if cfg.ResubscribeInterval <= 0 {
+		cfg.ResubscribeInterval = DefaultResubscribeInterval
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	s := &Subscription{
+		plugin:    p,
+		config:    cfg,
+		resources: resources,
+		logger:    p.logger(),
+		cancel:    cancel,
+		done:      make(chan struct{}),
+	}
+
+	subscriber, stream, proc, err := s.subscribe(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	go s.run(ctx, subscriber, stream, proc)
+	return s, nil
+}
+
+// Close stops the subscription and waits until the current event is handled
+func (s *Subscription) Close() {
+	s.cancel()
+	<-s.done
+}
+
+// subscribe opens a subscription in the running plugin process
+func (s *Subscription) subscribe(ctx context.Context) (sdk.EventSubscriber, sdk.EventStream, process, error) {
+	proc, err := s.plugin.running(ctx)
+	if err != nil {
+		return nil, nil, process{}, err
+	}
+	subscriber, ok := proc.resource.(sdk.EventSubscriber)
+	if !ok {
+		return nil, nil, process{}, sdk.ErrEventsNotSupported
+	}
+	stream, err := subscriber.Subscribe(ctx, s.resources)
+	if err != nil {
+		return nil, nil, process{}, s.plugin.crashError(proc, err)
+	}
+	return subscriber, stream, proc, nil
+}
+
+func (s *Subscription) run(ctx context.Context, subscriber sdk.EventSubscriber, stream sdk.EventStream, proc process) {
+	defer close(s.done)
+
+	for {
+		s.redeliver(ctx)
+		err := s.receive(ctx, subscriber, stream, proc)
+		if ctx.Err() != nil {
+			return
+		}
+		s.logger.Warn("event subscription failed", "error", err)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(s.config.ResubscribeInterval):
+			}
+			subscriber, stream, proc, err = s.subscribe(ctx)
+			if err == nil {
+				break
+			}
+			if ctx.Err() != nil || errors.Is(err, ErrClosed) || errors.Is(err, sdk.ErrEventsNotSupported) {
+				s.logger.Error("event subscription stopped", "error", err)
+				return
+			}
+			s.logger.Warn("failed to subscribe to events", "error", err)
+		}
+	}
+}
+
+// receive handles the events of a stream until it fails
+func (s *Subscription) receive(ctx context.Context, subscriber sdk.EventSubscriber, stream sdk.EventStream, proc process) error {
+	for {
+		e, err := stream.Recv()
+		if err != nil {
+			return s.plugin.crashError(proc, err)
+		}
+
+		// events that cannot be recorded are not acknowledged, so the
+		// plugin sends them again
+		fresh, err := s.config.Journal.Append(e)
+		if err != nil {
+			s.logger.Error("failed to record event", "resource", e.Resource, "event_id", e.ID, "error", err)
+			continue
+		}
+		if err := subscriber.Acknowledge(ctx, e.ID); err != nil {
+			s.logger.Warn("failed to acknowledge event", "resource", e.Resource, "event_id", e.ID, "error", err)
+		}
+		if !fresh {
+			s.logger.Debug("dropped duplicate event", "resource", e.Resource, "event_id", e.ID, "dedupe_id", e.DedupeID)
+			continue
+		}
+		s.deliver(ctx, e)
+	}
+}
+
+// redeliver hands the events that were not handled yet to their handlers
+func (s *Subscription) redeliver(ctx context.Context) {
+	pending, err := s.config.Journal.Pending()
+	if err != nil {
+		s.logger.Error("failed to read pending events", "error", err)
+		return
+	}
+	for _, e := range pending {
+		if ctx.Err() != nil {
+			return
+		}
+		s.deliver(ctx, e)
+	}
+}
+
+// deliver runs the handler of an event and records it as handled if the
+// handler succeeded
+func (s *Subscription) deliver(ctx context.Context, e *sdk.Event) {
+	handler, found := s.config.Handlers[e.Resource]
+	if !found {
+		// recorded by another subscription of the journal
+		return
+	}
+	if err := handler(ctx, e); err != nil {
+		s.logger.Warn("event handler failed", "resource", e.Resource, "event_id", e.ID, "error", err)
+		return
+	}
+	if err := s.config.Journal.Done(e, s.config.DedupeWindow); err != nil {
+		s.logger.Error("failed to record handled event", "resource", e.Resource, "event_id", e.ID, "error", err)
+	}
+}
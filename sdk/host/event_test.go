@@ -0,0 +1,184 @@
+package host
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maschine.io/plugin-sdk/sdk"
+)
+
+// handledEvents collects the events delivered to a handler
+type handledEvents struct {
+	mu       sync.Mutex
+	subjects []string
+	fail     map[string]bool
+}
+
+func (h *handledEvents) handle(ctx context.Context, e *sdk.Event) error {
+	var subject string
+	if err := e.DecodePayload(&subject); err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subjects = append(h.subjects, subject)
+	if h.fail[e.DedupeID] {
+		return errors.New("state machine not deployed")
+	}
+	return nil
+}
+
+func (h *handledEvents) handled() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]string(nil), h.subjects...)
+}
+
+func TestSubscribe(t *testing.T) {
+	p := launchTestPlugin(t, Config{})
+	journal, err := NewFileJournal(t.TempDir())
+	require.NoError(t, err)
+
+	// the first mail fails and stays in the journal
+	events := &handledEvents{fail: map[string]bool{"mail-1": true}}
+	sub, err := p.Subscribe(context.Background(), SubscribeConfig{
+		Handlers: map[string]EventHandler{"mrn:test:resource:trigger": events.handle},
+		Journal:  journal,
+	})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return len(events.handled()) == 2 }, 5*time.Second, 10*time.Millisecond)
+	sub.Close()
+	assert.Equal(t, []string{"subject of mail-1", "subject of mail-2"}, events.handled())
+
+	pending, err := journal.Pending()
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, "mail-1", pending[0].DedupeID)
+
+	// after a restart of the host, the failed mail is delivered again and
+	// the mails emitted again by the plugin are dropped
+	events = &handledEvents{}
+	sub, err = p.Subscribe(context.Background(), SubscribeConfig{
+		Handlers: map[string]EventHandler{"mrn:test:resource:trigger": events.handle},
+		Journal:  journal,
+	})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return len(events.handled()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	sub.Close()
+	assert.Equal(t, []string{"subject of mail-1"}, events.handled())
+
+	pending, err = journal.Pending()
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+// countingJournal counts the received events
+type countingJournal struct {
+	Journal
+	received atomic.Int32
+}
+
+func (j *countingJournal) Append(e *sdk.Event) (bool, error) {
+	j.received.Add(1)
+	return j.Journal.Append(e)
+}
+
+func TestSubscribeResubscribesAfterCrash(t *testing.T) {
+	p := launchTestPlugin(t, Config{})
+	events := &handledEvents{}
+	journal := &countingJournal{Journal: NewMemoryJournal()}
+	sub, err := p.Subscribe(context.Background(), SubscribeConfig{
+		Handlers:            map[string]EventHandler{"mrn:test:resource:trigger": events.handle},
+		Journal:             journal,
+		ResubscribeInterval: 10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	defer sub.Close()
+	require.Eventually(t, func() bool { return len(events.handled()) == 2 }, 5*time.Second, 10*time.Millisecond)
+
+	// the restarted plugin emits the mails again, they are dropped
+	_, err = p.Execute(context.Background(), &sdk.ExecuteRequest{
+		Resource:   "mrn:test:resource:action",
+		Parameters: map[string][]byte{"param1": []byte(`"crash"`)},
+	})
+	var crash *CrashError
+	require.ErrorAs(t, err, &crash)
+
+	require.Eventually(t, func() bool { return journal.received.Load() == 4 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"subject of mail-1", "subject of mail-2"}, events.handled())
+}
+
+func TestSubscribeRejectsUndeclaredTriggers(t *testing.T) {
+	p := launchTestPlugin(t, Config{})
+	handler := func(ctx context.Context, e *sdk.Event) error { return nil }
+
+	_, err := p.Subscribe(context.Background(), SubscribeConfig{
+		Handlers: map[string]EventHandler{"mrn:test:resource:action": handler},
+	})
+	assert.EqualError(t, err, "resource mrn:test:resource:action is not declared as trigger")
+
+	_, err = p.Execute(context.Background(), &sdk.ExecuteRequest{Resource: "mrn:test:resource:trigger"})
+	assert.EqualError(t, err, "resource mrn:test:resource:trigger is a trigger and cannot be executed")
+}
+
+func TestJournals(t *testing.T) {
+	files, err := NewFileJournal(t.TempDir())
+	require.NoError(t, err)
+
+	for name, journal := range map[string]Journal{"memory": NewMemoryJournal(), "file": files} {
+		t.Run(name, func(t *testing.T) {
+			now := time.Now()
+			older := &sdk.Event{ID: "1", Resource: "mrn:mail:imap:received", DedupeID: "mail-1", Time: now.Add(-time.Minute)}
+			newer := &sdk.Event{ID: "2", Resource: "mrn:mail:imap:received", Time: now}
+
+			fresh, err := journal.Append(newer)
+			require.NoError(t, err)
+			assert.True(t, fresh)
+			fresh, err = journal.Append(older)
+			require.NoError(t, err)
+			assert.True(t, fresh)
+
+			// same dedupe ID, other ID
+			fresh, err = journal.Append(&sdk.Event{ID: "3", Resource: "mrn:mail:imap:received", DedupeID: "mail-1"})
+			require.NoError(t, err)
+			assert.False(t, fresh)
+			// same ID without dedupe ID
+			fresh, err = journal.Append(&sdk.Event{ID: "2", Resource: "mrn:mail:imap:received"})
+			require.NoError(t, err)
+			assert.False(t, fresh)
+			// dedupe IDs are scoped to the resource
+			fresh, err = journal.Append(&sdk.Event{ID: "4", Resource: "mrn:file:dir:dropped", DedupeID: "mail-1", Time: now.Add(time.Minute)})
+			require.NoError(t, err)
+			assert.True(t, fresh)
+
+			pending, err := journal.Pending()
+			require.NoError(t, err)
+			require.Len(t, pending, 3)
+			assert.Equal(t, "1", pending[0].ID)
+			assert.Equal(t, "2", pending[1].ID)
+
+			require.NoError(t, journal.Done(older, time.Hour))
+			require.NoError(t, journal.Done(newer, -time.Second))
+			pending, err = journal.Pending()
+			require.NoError(t, err)
+			require.Len(t, pending, 1)
+			assert.Equal(t, "4", pending[0].ID)
+
+			// handled events are dropped until they expire
+			fresh, err = journal.Append(&sdk.Event{ID: "5", Resource: "mrn:mail:imap:received", DedupeID: "mail-1"})
+			require.NoError(t, err)
+			assert.False(t, fresh)
+			fresh, err = journal.Append(&sdk.Event{ID: "2", Resource: "mrn:mail:imap:received"})
+			require.NoError(t, err)
+			assert.True(t, fresh)
+		})
+	}
+}
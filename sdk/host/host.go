@@ -0,0 +1,444 @@
+// Package host launches Maschine plugins and talks to them on behalf of the
+// state machine.
+package host
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-plugin"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"maschine.io/plugin-sdk/sdk"
+	"maschine.io/plugin-sdk/sdk/manifest"
+)
+
+// Config configures how a plugin is launched
+type Config struct {
+	// Path is the path to the plugin executable
+	Path string
+	// Args are passed to the plugin executable
+	Args []string
+	// Manifest is the on-disk manifest of the plugin. If nil, the manifest
+	// is searched next to the executable with manifest.FindManifest.
+	Manifest *manifest.PluginManifest
+	// Logger receives the host side logs of the plugin and the logs the
+	// plugin writes to stderr, tagged with the plugin ID and, for logs of
+	// executions, the resource and the execution ID
+	Logger hclog.Logger
+	// LogLevel overrides the level of the plugin's logs, see
+	// Plugin.SetLogLevel. hclog.NoLevel keeps the level the plugin is
+	// started with.
+	LogLevel hclog.Level
+	// Transfer configures message size limits and chunked transfer of
+	// large payloads
+	Transfer sdk.TransferConfig
+	// Interceptors are installed on the gRPC connection to the plugin
+	Interceptors sdk.ClientInterceptors
+	// TLS replaces the automatic mutual TLS of the plugin connection with
+	// operator-supplied certificates
+	TLS *TLSConfig
+	// DisableAutoMTLS turns off the automatic mutual TLS of the plugin
+	// connection if TLS is not set. Only for plugins that cannot serve TLS.
+	DisableAutoMTLS bool
+	// Accept are the content types the host accepts for outputs, in order
+	// of preference. Plugins fall back to JSON. sdk.ContextWithAccept
+	// overrides it per call.
+	Accept []string
+	// PlanMode turns executions of resources that are not declared as query
+	// or check into dry runs, so a whole state machine can be previewed.
+	// The output of dry runs is the JSON encoded []sdk.Change, see
+	// sdk.DecodePlan.
+	PlanMode bool
+	// QueryCache caches the results of resources declared as query.
+	// Disabled by default.
+	QueryCache QueryCacheConfig
+	// Retry overrides the retry policies declared in the manifest.
+	// Executions in sessions and batches are not retried.
+	Retry RetryConfig
+	// RateLimit configures how the rate limits declared in the manifest
+	// are enforced
+	RateLimit RateLimitConfig
+	// OutputValidation validates outputs against the output schemas
+	// declared in the manifest. Disabled by default.
+	OutputValidation OutputValidation
+	// ShutdownTimeout is how long Close waits for in-flight executions
+	// before the plugin process is killed. Defaults to DefaultShutdownTimeout.
+	ShutdownTimeout time.Duration
+	// StderrBufferSize is how many bytes of the plugin's stderr are kept
+	// for CrashError. Defaults to DefaultStderrBufferSize.
+	StderrBufferSize int
+	// Configuration is sent to the plugin with Configure after every start
+	// of the plugin process. Use ResolveEnvironment to collect the declared
+	// environment variables.
+	Configuration *sdk.ConfigureRequest
+}
+
+// TLSConfig holds the operator-supplied certificates of host and plugin,
+// signed by the same CA
+type TLSConfig struct {
+	// Host is the certificate the host authenticates with
+	Host sdk.TLSFiles
+	// Plugin is passed to the plugin process, which picks it up with
+	// sdk.TLSProvider
+	Plugin sdk.TLSFiles
+}
+
+// DefaultShutdownTimeout is the default for Config.ShutdownTimeout
+const DefaultShutdownTimeout = 30 * time.Second
+
+// Plugin is a launched plugin process. It is restarted and reconfigured
+// automatically if the process exited.
+type Plugin struct {
+	config   Config
+	manifest *manifest.PluginManifest
+
+	mu            sync.Mutex
+	client        *plugin.Client
+	resource      sdk.MaschineResource
+	stderr        *ringBuffer
+	configuration *sdk.ConfigureRequest
+	closed        bool
+
+	// queries is nil if the query cache is disabled
+	queries  *queryCache
+	limiter  *rateLimiter
+	logLevel atomic.Int32
+}
+
+// ErrClosed is returned for calls to a plugin that was shut down
+var ErrClosed = errors.New("plugin is closed")
+
+// LoadError is returned if a plugin cannot be loaded
+type LoadError struct {
+	Path string
+	Err  error
+}
+
+func (e *LoadError) Error() string {
+	return fmt.Sprintf("failed to load plugin %s: %v", e.Path, e.Err)
+}
+
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
+
+// Launch starts the plugin process, dispenses its MaschineResource and
+// verifies the plugin against its on-disk manifest. Any mismatch is returned
+// as *LoadError and the plugin process is killed.
+func Launch(ctx context.Context, cfg Config) (*Plugin, error) {
+	m := cfg.Manifest
+	if m == nil {
+		path, err := manifest.FindManifest(filepath.Dir(cfg.Path))
+		if err != nil {
+			return nil, &LoadError{Path: cfg.Path, Err: err}
+		}
+		if m, err = manifest.Load(path); err != nil {
+			return nil, &LoadError{Path: cfg.Path, Err: err}
+		}
+	}
+
+	p := &Plugin{
+		config:        cfg,
+		manifest:      m,
+		configuration: cfg.Configuration,
+		queries:       newQueryCache(cfg.QueryCache),
+		limiter:       newRateLimiter(),
+	}
+	p.logLevel.Store(int32(cfg.LogLevel))
+	if err := p.start(ctx); err != nil {
+		return nil, &LoadError{Path: cfg.Path, Err: err}
+	}
+	return p, nil
+}
+
+// ResolveEnvironment returns the values of all environment variables
+// declared in the manifest that are set according to lookup, usually
+// os.LookupEnv
+func ResolveEnvironment(m *manifest.PluginManifest, lookup func(string) (string, bool)) map[string]string {
+	env := make(map[string]string, len(m.Configuration.Environment))
+	for _, e := range m.Configuration.Environment {
+		if value, found := lookup(e.Name); found {
+			env[e.Name] = value
+		}
+	}
+	return env
+}
+
+// process is the state of the running plugin process
+type process struct {
+	client   *plugin.Client
+	resource sdk.MaschineResource
+	stderr   *ringBuffer
+}
+
+func (p *Plugin) start(ctx context.Context) error {
+	size := p.config.StderrBufferSize
+	if size <= 0 {
+		size = DefaultStderrBufferSize
+	}
+	stderr := newRingBuffer(size)
+	cfg, err := p.clientConfig(stderr)
+	if err != nil {
+		return err
+	}
+	client := plugin.NewClient(cfg)
+
+	rpcClient, err := client.Client()
+	if err != nil {
+		client.Kill()
+		return err
+	}
+
+	raw, err := rpcClient.Dispense(sdk.PluginName)
+	if err != nil {
+		client.Kill()
+		return err
+	}
+
+	resource, ok := raw.(sdk.MaschineResource)
+	if !ok {
+		client.Kill()
+		return fmt.Errorf("plugin does not implement MaschineResource: %T", raw)
+	}
+
+	if err := VerifyPlugin(ctx, resource, p.manifest); err != nil {
+		client.Kill()
+		return err
+	}
+
+	if p.configuration != nil {
+		if err := configure(ctx, resource, p.configuration); err != nil {
+			client.Kill()
+			return err
+		}
+	}
+
+	p.client = client
+	p.resource = resource
+	p.stderr = stderr
+	return nil
+}
+
+func configure(ctx context.Context, res sdk.MaschineResource, req *sdk.ConfigureRequest) error {
+	configurer, ok := res.(sdk.Configurer)
+	if !ok {
+		return fmt.Errorf("plugin does not support configuration")
+	}
+	if err := configurer.Configure(ctx, req); err != nil {
+		return fmt.Errorf("plugin rejected configuration: %w", err)
+	}
+	return nil
+}
+
+func (p *Plugin) clientConfig(stderr io.Writer) (*plugin.ClientConfig, error) {
+	cfg := &plugin.ClientConfig{
+		HandshakeConfig: handshakeConfig(p.manifest),
+		Plugins: map[string]plugin.Plugin{
+			sdk.PluginName: &sdk.MaschinePlugin{Transfer: p.config.Transfer},
+		},
+		Cmd:              exec.Command(p.config.Path, p.config.Args...),
+		Logger:           newLaunchLogger(p.config.Logger, p.config.Path),
+		Stderr:           io.MultiWriter(stderr, newLogForwarder(p)),
+		SyncStderr:       io.MultiWriter(stderr, newLogForwarder(p)),
+		AllowedProtocols: []plugin.Protocol{plugin.ProtocolGRPC},
+		GRPCDialOptions:  append(p.config.Transfer.DialOptions(), p.config.Interceptors.DialOptions()...),
+		AutoMTLS:         !p.config.DisableAutoMTLS,
+	}
+	if env := p.logEnv(); env != nil {
+		// the override must come after the environment of the host
+		cfg.Cmd.Env = append(os.Environ(), env...)
+		cfg.SkipHostEnv = true
+	}
+
+	if p.config.TLS != nil {
+		tlsConfig, err := p.config.TLS.Host.ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load host certificate: %w", err)
+		}
+		cfg.TLSConfig = tlsConfig
+		cfg.AutoMTLS = false
+		cfg.Cmd.Env = append(cfg.Cmd.Env, p.config.TLS.Plugin.Environ()...)
+	}
+	return cfg, nil
+}
+
+// handshakeConfig returns the handshake declared in the manifest or
+// sdk.Handshake if the manifest does not declare one
+func handshakeConfig(m *manifest.PluginManifest) plugin.HandshakeConfig {
+	hc := m.Runtime.HandshakeConfig
+	if hc.MagicCookieKey == "" {
+		return sdk.Handshake
+	}
+	return plugin.HandshakeConfig{
+		ProtocolVersion:  uint(hc.ProtocolVersion),
+		MagicCookieKey:   hc.MagicCookieKey,
+		MagicCookieValue: hc.MagicCookieValue,
+	}
+}
+
+// Manifest returns the on-disk manifest the plugin was verified against
+func (p *Plugin) Manifest() *manifest.PluginManifest {
+	return p.manifest
+}
+
+// Resource returns the MaschineResource dispensed by the plugin
+func (p *Plugin) Resource() sdk.MaschineResource {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.resource
+}
+
+// Configure sends the configuration to the plugin. The configuration is
+// kept and sent again whenever the plugin process is restarted. A rejected
+// configuration wraps the manifest.ValidationErrors reported by the plugin.
+func (p *Plugin) Configure(ctx context.Context, req *sdk.ConfigureRequest) error {
+	proc, err := p.running(ctx)
+	if err != nil {
+		return err
+	}
+	if err := configure(ctx, proc.resource, req); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.configuration = req
+	return nil
+}
+
+// Restart kills the plugin process, starts it again and reconfigures it
+func (p *Plugin) Restart(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return ErrClosed
+	}
+	p.client.Kill()
+	return p.start(ctx)
+}
+
+// running returns the plugin process and restarts it first if it exited
+func (p *Plugin) running(ctx context.Context) (process, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return process{}, ErrClosed
+	}
+	if p.client.Exited() {
+		p.client.Kill()
+		if err := p.start(ctx); err != nil {
+			return process{}, fmt.Errorf("failed to restart plugin %s: %w", p.config.Path, err)
+		}
+	}
+	return process{client: p.client, resource: p.resource, stderr: p.stderr}, nil
+}
+
+// Execute runs a resource of the plugin. If the plugin process exits during
+// the call, the error is a *CrashError with the last output of the process.
+func (p *Plugin) Execute(ctx context.Context, req *sdk.ExecuteRequest) (*sdk.ExecuteResponse, error) {
+	req, err := p.prepare(req)
+	if err != nil {
+		return nil, err
+	}
+	ctx = p.logContext(p.accept(ctx))
+	var resp *sdk.ExecuteResponse
+	if p.cacheable(req) {
+		resp, err = p.executeQuery(ctx, req)
+	} else {
+		resp, err = p.execute(ctx, req)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p.validateOutput(ctx, req, resp), nil
+}
+
+// attempt runs a single attempt of an execution
+func (p *Plugin) attempt(ctx context.Context, req *sdk.ExecuteRequest) (*sdk.ExecuteResponse, error) {
+	if err := p.limit(ctx, req); err != nil {
+		return nil, err
+	}
+	proc, err := p.running(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := proc.resource.Execute(ctx, req)
+	if err != nil {
+		return nil, p.crashError(proc, err)
+	}
+	return resp, nil
+}
+
+// logger returns the logger for host side logs about the plugin
+func (p *Plugin) logger() hclog.Logger {
+	logger := p.config.Logger
+	if logger == nil {
+		logger = hclog.Default()
+	}
+	return logger.With("plugin", p.manifest.Plugin.ID)
+}
+
+// accept adds Config.Accept to ctx unless ctx has accepted content types
+func (p *Plugin) accept(ctx context.Context) context.Context {
+	if len(p.config.Accept) == 0 || len(sdk.AcceptFromContext(ctx)) > 0 {
+		return ctx
+	}
+	return sdk.ContextWithAccept(ctx, p.config.Accept...)
+}
+
+// Shutdown asks the plugin to stop accepting executions and to finish the
+// in-flight ones, then stops the plugin process. The process is killed
+// without waiting any longer once the deadline of ctx passed. The returned
+// error reports executions that did not finish in time; the process is
+// stopped in any case.
+func (p *Plugin) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	p.closed = true
+	client, res := p.client, p.resource
+	p.mu.Unlock()
+
+	var err error
+	if shutdowner, ok := res.(sdk.Shutdowner); ok && !client.Exited() {
+		err = shutdowner.Shutdown(ctx)
+		if status.Code(err) == codes.Unimplemented {
+			// plugins built with older SDKs are stopped right away
+			err = nil
+		}
+	}
+
+	client.Kill()
+	return err
+}
+
+// Close shuts the plugin down, waiting at most Config.ShutdownTimeout for
+// in-flight executions
+func (p *Plugin) Close() error {
+	timeout := p.config.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = DefaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return p.Shutdown(ctx)
+}
+
+// Kill stops the plugin process immediately
+func (p *Plugin) Kill() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	p.client.Kill()
+}
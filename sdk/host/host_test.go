@@ -0,0 +1,206 @@
+package host
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"testing"
+
+	"github.com/hashicorp/go-plugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maschine.io/plugin-sdk/sdk"
+	"maschine.io/plugin-sdk/sdk/logger"
+	"maschine.io/plugin-sdk/sdk/manifest"
+)
+
+// envTestPlugin makes the test binary serve testPlugin instead of running
+// the tests, so that Launch can start it as a plugin process
+const envTestPlugin = "MASCHINE_HOST_TEST_PLUGIN"
+
+// testPluginIdempotent serves the test plugin wrapped with sdk.WithIdempotency
+const testPluginIdempotent = "idempotent"
+
+func TestMain(m *testing.M) {
+	if mode := os.Getenv(envTestPlugin); mode != "" {
+		impl := testPlugin()
+		if mode == testPluginIdempotent {
+			impl = sdk.WithIdempotency(impl, sdk.IdempotencyConfig{})
+		}
+		plugin.Serve(&plugin.ServeConfig{
+			HandshakeConfig: sdk.Handshake,
+			Plugins: map[string]plugin.Plugin{
+				sdk.PluginName: &sdk.MaschinePlugin{Impl: impl},
+			},
+			GRPCServer:  sdk.GRPCServer(),
+			TLSProvider: sdk.TLSProvider,
+		})
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// sessionTestPlugin keeps sessions without state
+type sessionTestPlugin struct {
+	*sdk.BasePlugin
+}
+
+func (p *sessionTestPlugin) OpenSession(ctx context.Context, s *sdk.Session) error {
+	return nil
+}
+
+func (p *sessionTestPlugin) CloseSession(ctx context.Context, s *sdk.Session) error {
+	return nil
+}
+
+// testPlugin implements the resource of testManifest. Its behavior is
+// selected with param1.
+func testPlugin() sdk.MaschineResource {
+	p := sdk.NewBasePlugin("test-plugin", "0.1.0")
+	p.SetManifest(testManifest())
+	p.RegisterSimpleFunction("mrn:test:resource:action", func(ctx context.Context, req *sdk.TypedExecuteRequest) (any, error) {
+		var mode string
+		if _, err := req.GetParameter("param1", &mode); err != nil {
+			return nil, err
+		}
+		switch mode {
+		case "crash":
+			// panics outside of the call are not recovered and kill the process
+			go panic("lost connection to smtp.example.com")
+			<-ctx.Done()
+		case "log":
+			l := logger.FromContext(ctx)
+			l.Debug("connecting", "server", "smtp.example.com")
+			l.Info("mail sent")
+		case "panic":
+			panic("unexpected mail header")
+		case "session":
+			if s, ok := sdk.SessionFromContext(ctx); ok {
+				return s.ID, nil
+			}
+		case "flaky":
+			// succeeds on the third attempt
+			if attempt := sdk.AttemptFromContext(ctx); attempt < 3 {
+				return nil, sdk.WithErrorType(fmt.Errorf("attempt %d: connection refused", attempt), sdk.ErrorTypeUnavailable)
+			}
+			return req.IdempotencyKey != "", nil
+		case "flaky-once":
+			// succeeds on the second attempt
+			if attempt := sdk.AttemptFromContext(ctx); attempt < 2 {
+				return nil, sdk.WithErrorType(fmt.Errorf("attempt %d: connection refused", attempt), sdk.ErrorTypeUnavailable)
+			}
+			return sdk.AttemptFromContext(ctx), nil
+		}
+		return mode, nil
+	}, "A test resource")
+	p.RegisterPlanFunction("mrn:test:resource:action", func(ctx context.Context, req *sdk.TypedExecuteRequest) ([]sdk.Change, error) {
+		var mode string
+		if _, err := req.GetParameter("param1", &mode); err != nil {
+			return nil, err
+		}
+		return []sdk.Change{{Action: "update", Target: mode}}, nil
+	})
+	var queries atomic.Int32
+	p.RegisterSimpleFunction("mrn:test:resource:query", func(ctx context.Context, req *sdk.TypedExecuteRequest) (any, error) {
+		// counts the executions that reached the plugin
+		return queries.Add(1), nil
+	}, "A test query")
+	p.RegisterCheck("mrn:test:resource:check", func(ctx context.Context, req *sdk.TypedExecuteRequest) (*sdk.CheckResult, error) {
+		var status string
+		if _, err := req.GetParameter("status", &status); err != nil {
+			return nil, err
+		}
+		if status == "broken" {
+			return nil, errors.New("cannot reach checked system")
+		}
+		return &sdk.CheckResult{
+			Status:      sdk.CheckStatus(status),
+			Message:     "status " + status,
+			Evidence:    map[string]string{"requested": status},
+			Remediation: "request another status",
+		}, nil
+	}, "A test check")
+	p.RegisterTrigger("mrn:test:resource:trigger", func(ctx context.Context, emit sdk.EmitFunc) error {
+		// every subscription emits the same mails, the host drops the
+		// duplicates
+		for _, id := range []string{"mail-1", "mail-2"} {
+			e := &sdk.Event{DedupeID: id}
+			if err := e.SetPayload(sdk.ContentTypeText, "subject of "+id); err != nil {
+				return err
+			}
+			if err := emit(ctx, e); err != nil {
+				return err
+			}
+		}
+		<-ctx.Done()
+		return nil
+	}, "A test trigger")
+	return &sessionTestPlugin{BasePlugin: p}
+}
+
+// launchTestPlugin launches the test binary as plugin process
+func launchTestPlugin(t *testing.T, cfg Config) *Plugin {
+	t.Helper()
+	if os.Getenv(envTestPlugin) == "" {
+		t.Setenv(envTestPlugin, "1")
+	}
+
+	cfg.Path = os.Args[0]
+	cfg.Manifest = testManifest()
+	p, err := Launch(context.Background(), cfg)
+	require.NoError(t, err)
+	t.Cleanup(p.Kill)
+	return p
+}
+
+func TestResolveEnvironment(t *testing.T) {
+	m := testManifest()
+	m.Configuration.Environment = []manifest.EnvVar{
+		{Name: "SMTP_POOL_SIZE"},
+		{Name: "LOG_LEVEL"},
+	}
+
+	env := map[string]string{"SMTP_POOL_SIZE": "4", "HOME": "/root"}
+	lookup := func(name string) (string, bool) {
+		v, found := env[name]
+		return v, found
+	}
+
+	assert.Equal(t, map[string]string{"SMTP_POOL_SIZE": "4"}, ResolveEnvironment(m, lookup))
+}
+
+func TestHandshakeConfig(t *testing.T) {
+	m := testManifest()
+	assert.Equal(t, sdk.Handshake, handshakeConfig(m))
+
+	m.Runtime.HandshakeConfig = manifest.HandshakeConfig{
+		ProtocolVersion:  2,
+		MagicCookieKey:   "KEY",
+		MagicCookieValue: "value",
+	}
+	hc := handshakeConfig(m)
+	assert.Equal(t, uint(2), hc.ProtocolVersion)
+	assert.Equal(t, "KEY", hc.MagicCookieKey)
+}
+
+func TestAccept(t *testing.T) {
+	p := launchTestPlugin(t, Config{Accept: []string{sdk.ContentTypeText}})
+	req := &sdk.ExecuteRequest{
+		Resource:   "mrn:test:resource:action",
+		Parameters: map[string][]byte{"param1": []byte("ok")},
+	}
+
+	resp, err := p.Execute(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, sdk.ContentTypeText, resp.ContentType)
+	assert.Equal(t, "ok", string(resp.Output))
+
+	resp, err = p.Execute(sdk.ContextWithAccept(context.Background(), sdk.ContentTypeCBOR), req)
+	require.NoError(t, err)
+	assert.Equal(t, sdk.ContentTypeCBOR, resp.ContentType)
+	var out string
+	require.NoError(t, resp.DecodeOutput(&out))
+	assert.Equal(t, "ok", out)
+}
@@ -0,0 +1,232 @@
+package host
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"maschine.io/plugin-sdk/sdk"
+)
+
+// Journal records the events received by a subscription until they are
+// handled. Handled events are kept for a while to drop duplicates.
+type Journal interface {
+	// Append records a received event. It returns false if the event was
+	// recorded before, by its dedupe ID or, without one, by its ID.
+	Append(e *sdk.Event) (bool, error)
+	// Done records that the event was handled. It is kept for ttl to drop
+	// duplicates.
+	Done(e *sdk.Event, ttl time.Duration) error
+	// Pending returns the recorded events that were not handled yet, oldest
+	// first
+	Pending() ([]*sdk.Event, error)
+}
+
+// journalEntry is the state of a recorded event
+type journalEntry struct {
+	Event *sdk.Event `json:"event"`
+	Done  bool       `json:"done"`
+	// Expires is when a handled event is forgotten
+	Expires time.Time `json:"expires,omitempty"`
+}
+
+func (e journalEntry) expired(now time.Time) bool {
+	return e.Done && now.After(e.Expires)
+}
+
+// journalKey identifies an event for deduplication. Dedupe IDs are scoped
+// to the resource.
+func journalKey(e *sdk.Event) string {
+	if e.DedupeID != "" {
+		return e.Resource + "\x00dedupe\x00" + e.DedupeID
+	}
+	return e.Resource + "\x00id\x00" + e.ID
+}
+
+// sortEvents sorts events by time, oldest first
+func sortEvents(events []*sdk.Event) {
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+}
+
+// MemoryJournal is a Journal in memory. Events that were not handled are
+// lost when the host exits.
+type MemoryJournal struct {
+	mu      sync.Mutex
+	entries map[string]journalEntry
+}
+
+// NewMemoryJournal creates an empty MemoryJournal
+func NewMemoryJournal() *MemoryJournal {
+	return &MemoryJournal{entries: make(map[string]journalEntry)}
+}
+
+// Append records a received event. Expired events are removed.
+func (j *MemoryJournal) Append(e *sdk.Event) (bool, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now()
+	for k, entry := range j.entries {
+		if entry.expired(now) {
+			delete(j.entries, k)
+		}
+	}
+
+	key := journalKey(e)
+	if _, found := j.entries[key]; found {
+		return false, nil
+	}
+	j.entries[key] = journalEntry{Event: e}
+	return true, nil
+}
+
+// Done records that the event was handled
+func (j *MemoryJournal) Done(e *sdk.Event, ttl time.Duration) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.entries[journalKey(e)] = journalEntry{Event: e, Done: true, Expires: time.Now().Add(ttl)}
+	return nil
+}
+
+// Pending returns the events that were not handled yet
+func (j *MemoryJournal) Pending() ([]*sdk.Event, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	var result []*sdk.Event
+	for _, entry := range j.entries {
+		if !entry.Done {
+			result = append(result, entry.Event)
+		}
+	}
+	sortEvents(result)
+	return result, nil
+}
+
+// FileJournal is a Journal that keeps every event in a JSON file in a
+// directory, so events that were not handled are delivered again after a
+// restart of the host
+type FileJournal struct {
+	dir string
+}
+
+// NewFileJournal creates a FileJournal in dir. The directory is created if
+// it does not exist.
+func NewFileJournal(dir string) (*FileJournal, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create event journal: %w", err)
+	}
+	return &FileJournal{dir: dir}, nil
+}
+
+// path returns the file of an event. Keys are hashed, so they can contain
+// any character.
+func (j *FileJournal) path(e *sdk.Event) string {
+	sum := sha256.Sum256([]byte(journalKey(e)))
+	return filepath.Join(j.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// read returns the entry stored in path
+func (j *FileJournal) read(path string) (journalEntry, error) {
+	var entry journalEntry
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return entry, err
+	}
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return entry, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return entry, nil
+}
+
+// write replaces the entry stored in path atomically, so a crash never
+// leaves a partial event
+func (j *FileJournal) write(path string, entry journalEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(j.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// Append records a received event. An expired event of the same key is
+// replaced.
+func (j *FileJournal) Append(e *sdk.Event) (bool, error) {
+	path := j.path(e)
+	entry, err := j.read(path)
+	if err == nil && !entry.expired(time.Now()) {
+		return false, nil
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return false, err
+	}
+	if err := j.write(path, journalEntry{Event: e}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Done records that the event was handled
+func (j *FileJournal) Done(e *sdk.Event, ttl time.Duration) error {
+	return j.write(j.path(e), journalEntry{Event: e, Done: true, Expires: time.Now().Add(ttl)})
+}
+
+// Pending returns the events that were not handled yet. Expired events are
+// removed.
+func (j *FileJournal) Pending() ([]*sdk.Event, error) {
+	files, err := os.ReadDir(j.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var result []*sdk.Event
+	for _, f := range files {
+		if f.IsDir() || strings.HasPrefix(f.Name(), ".") || filepath.Ext(f.Name()) != ".json" {
+			continue
+		}
+		path := filepath.Join(j.dir, f.Name())
+		entry, err := j.read(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if entry.expired(now) {
+			if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return nil, err
+			}
+			continue
+		}
+		if !entry.Done {
+			result = append(result, entry.Event)
+		}
+	}
+	sortEvents(result)
+	return result, nil
+}
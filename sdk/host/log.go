@@ -0,0 +1,181 @@
+package host
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-hclog"
+	"maschine.io/plugin-sdk/sdk"
+	"maschine.io/plugin-sdk/sdk/logger"
+)
+
+// SetLogLevel overrides the level of the plugin's logs at runtime. The
+// plugin switches to it with the next call, without a restart, and
+// restarted plugin processes start with it. hclog.NoLevel removes the
+// override; the plugin keeps the last requested level until it restarts.
+func (p *Plugin) SetLogLevel(level hclog.Level) {
+	p.logLevel.Store(int32(level))
+}
+
+// LogLevel returns the level of the plugin's logs set with Config.LogLevel
+// or SetLogLevel, hclog.NoLevel if the plugin uses its own level
+func (p *Plugin) LogLevel() hclog.Level {
+	return hclog.Level(p.logLevel.Load())
+}
+
+// logContext assigns an execution ID to the calls in ctx unless ctx has
+// one, and requests the overridden log level from the plugin
+func (p *Plugin) logContext(ctx context.Context) context.Context {
+	if sdk.ExecutionIDFromContext(ctx) == "" {
+		ctx = sdk.ContextWithExecutionID(ctx, rand.Text())
+	}
+	if level := p.LogLevel(); level != hclog.NoLevel {
+		ctx = sdk.ContextWithLogLevel(ctx, level)
+	}
+	return ctx
+}
+
+// logEnv passes the overridden log level to a new plugin process
+func (p *Plugin) logEnv() []string {
+	level := p.LogLevel()
+	if level == hclog.NoLevel {
+		return nil
+	}
+	return []string{logger.EnvLogLevel + "=" + level.String()}
+}
+
+// launchLogger is the logger of go-plugin. It drops the stderr lines
+// go-plugin re-emits itself, they are forwarded by logForwarder instead.
+type launchLogger struct {
+	hclog.Logger
+	// stderr is the name of the logger go-plugin re-emits stderr with
+	stderr string
+}
+
+// newLaunchLogger returns the logger of go-plugin for the plugin at path.
+// Without l, it logs like the default logger of go-plugin.
+func newLaunchLogger(l hclog.Logger, path string) *launchLogger {
+	if l == nil {
+		l = hclog.New(&hclog.LoggerOptions{Name: "plugin", Output: hclog.DefaultOutput, Level: hclog.Trace})
+	}
+	return &launchLogger{Logger: l, stderr: filepath.Base(path)}
+}
+
+func (l *launchLogger) Named(name string) hclog.Logger {
+	if name == l.stderr {
+		return hclog.NewNullLogger()
+	}
+	return l.Logger.Named(name)
+}
+
+// logForwarder parses the plugin's stderr and re-emits its lines through
+// the host logger. The stderr of the plugin process and the stderr
+// go-plugin syncs over gRPC each have their own logForwarder. JSON lines
+// written by hclog keep their level, message and fields, other lines are
+// logged as debug messages unless they start with a level like "[WARN]".
+type logForwarder struct {
+	plugin *Plugin
+
+	mu   sync.Mutex
+	line []byte
+}
+
+func newLogForwarder(p *Plugin) *logForwarder {
+	return &logForwarder{plugin: p}
+}
+
+func (f *logForwarder) Write(b []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	n := len(b)
+	for len(b) > 0 {
+		i := bytes.IndexByte(b, '\n')
+		if i < 0 {
+			f.line = append(f.line, b...)
+			break
+		}
+		f.line = append(f.line, b[:i]...)
+		f.forward(f.line)
+		f.line = f.line[:0]
+		b = b[i+1:]
+	}
+	return n, nil
+}
+
+// forward re-emits a line of the plugin
+func (f *logForwarder) forward(line []byte) {
+	if len(bytes.TrimSpace(line)) == 0 {
+		return
+	}
+	level, msg, args := parseLogLine(line)
+	if override := f.plugin.LogLevel(); override != hclog.NoLevel && level < override {
+		return
+	}
+	f.plugin.logger().Log(level, msg, args...)
+}
+
+// logFields are the fields hclog writes for every JSON line
+var logFields = []string{"@level", "@message", "@module", "@timestamp", "@caller"}
+
+// logTags are the fields that identify an execution, they are emitted
+// before all other fields
+var logTags = []string{"resource", "execution_id"}
+
+// parseLogLine maps a line of the plugin to the level, message and fields
+// it is re-emitted with
+func parseLogLine(line []byte) (hclog.Level, string, []any) {
+	var entry map[string]any
+	if err := json.Unmarshal(line, &entry); err != nil {
+		return textLevel(string(line)), string(line), nil
+	}
+	msg, ok := entry["@message"].(string)
+	if !ok {
+		// JSON written by something else than hclog
+		return hclog.Debug, string(line), nil
+	}
+
+	level := hclog.Info
+	if s, ok := entry["@level"].(string); ok {
+		if l := hclog.LevelFromString(s); l != hclog.NoLevel && l != hclog.Off {
+			level = l
+		}
+	}
+
+	var args []any
+	if module, ok := entry["@module"].(string); ok && module != "" {
+		args = append(args, "module", module)
+	}
+	for _, key := range logTags {
+		if value, ok := entry[key]; ok {
+			args = append(args, key, value)
+		}
+	}
+	keys := make([]string, 0, len(entry))
+	for key := range entry {
+		if !slices.Contains(logFields, key) && !slices.Contains(logTags, key) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		args = append(args, key, entry[key])
+	}
+	return level, msg, args
+}
+
+// textLevel infers the level of a line that is not JSON from its prefix
+func textLevel(line string) hclog.Level {
+	for _, level := range []hclog.Level{hclog.Trace, hclog.Debug, hclog.Info, hclog.Warn, hclog.Error} {
+		if strings.HasPrefix(line, "["+strings.ToUpper(level.String())+"]") {
+			return level
+		}
+	}
+	return hclog.Debug
+}
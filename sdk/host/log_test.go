@@ -0,0 +1,177 @@
+package host
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maschine.io/plugin-sdk/sdk"
+	"maschine.io/plugin-sdk/sdk/logger"
+)
+
+// logBuffer collects the lines of a JSON logger
+type logBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// entries returns the decoded lines with the message msg
+func (b *logBuffer) entries(msg string) []map[string]any {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var entries []map[string]any
+	for _, line := range strings.Split(b.buf.String(), "\n") {
+		var entry map[string]any
+		if json.Unmarshal([]byte(line), &entry) == nil && entry["@message"] == msg {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+func newTestLogger(level hclog.Level) (hclog.Logger, *logBuffer) {
+	buf := &logBuffer{}
+	return hclog.New(&hclog.LoggerOptions{Name: "host", Output: buf, Level: level, JSONFormat: true}), buf
+}
+
+func TestLogForwarding(t *testing.T) {
+	log, buf := newTestLogger(hclog.Trace)
+	t.Setenv(logger.EnvLogLevel, "info")
+	p := launchTestPlugin(t, Config{Logger: log})
+
+	req := &sdk.ExecuteRequest{Resource: "mrn:test:resource:action", Parameters: map[string][]byte{"param1": []byte(`"log"`)}}
+	ctx := sdk.ContextWithExecutionID(context.Background(), "exec-1")
+	_, err := p.Execute(ctx, req)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return len(buf.entries("mail sent")) == 1 }, 5*time.Second, 10*time.Millisecond)
+	entry := buf.entries("mail sent")[0]
+	assert.Equal(t, "info", entry["@level"])
+	assert.Equal(t, "host", entry["@module"])
+	assert.Equal(t, "io.test.plugin", entry["plugin"])
+	assert.Equal(t, "mrn:test:resource:action", entry["resource"])
+	assert.Equal(t, "exec-1", entry["execution_id"])
+	assert.Empty(t, buf.entries("connecting"), "debug logs are below the level of the plugin")
+
+	// the override reaches the running plugin with the next execution
+	p.SetLogLevel(hclog.Debug)
+	assert.Equal(t, hclog.Debug, p.LogLevel())
+	_, err = p.Execute(context.Background(), req)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return len(buf.entries("connecting")) == 1 }, 5*time.Second, 10*time.Millisecond)
+	entry = buf.entries("connecting")[0]
+	assert.Equal(t, "debug", entry["@level"])
+	assert.Equal(t, "smtp.example.com", entry["server"])
+	assert.NotEmpty(t, entry["execution_id"], "executions without ID get one")
+	assert.NotEqual(t, "exec-1", entry["execution_id"])
+}
+
+func TestLogEnv(t *testing.T) {
+	t.Setenv(logger.EnvLogLevel, "info")
+	p := &Plugin{config: Config{Path: "/usr/lib/maschine/mail-plugin"}, manifest: testManifest()}
+
+	cfg, err := p.clientConfig(io.Discard)
+	require.NoError(t, err)
+	assert.False(t, cfg.SkipHostEnv)
+	assert.Empty(t, cfg.Cmd.Env)
+
+	p.SetLogLevel(hclog.Trace)
+	cfg, err = p.clientConfig(io.Discard)
+	require.NoError(t, err)
+	assert.True(t, cfg.SkipHostEnv)
+	assert.Equal(t, logger.EnvLogLevel+"=trace", cfg.Cmd.Env[len(cfg.Cmd.Env)-1])
+	assert.Contains(t, cfg.Cmd.Env, logger.EnvLogLevel+"=info")
+}
+
+func TestLogForwarder(t *testing.T) {
+	log, buf := newTestLogger(hclog.Trace)
+	p := &Plugin{config: Config{Logger: log}, manifest: testManifest()}
+	f := newLogForwarder(p)
+
+	// go-plugin writes lines and their line breaks separately
+	for _, line := range []string{
+		`{"@level":"warn","@message":"slow server","@module":"mail-plugin","@timestamp":"2026-10-18T10:00:00.000000Z","resource":"mrn:mail:smtp:send","latency":3}`,
+		`{"@level":"debug","@message":"connecting","@timestamp":"2026-10-18T10:00:00.000000Z"}`,
+		`[ERROR] lost connection`,
+		`plain output`,
+	} {
+		_, err := f.Write([]byte(line))
+		require.NoError(t, err)
+		_, err = f.Write([]byte("\n"))
+		require.NoError(t, err)
+	}
+
+	entries := buf.entries("slow server")
+	require.Len(t, entries, 1)
+	assert.Equal(t, "warn", entries[0]["@level"])
+	assert.Equal(t, "mail-plugin", entries[0]["module"])
+	assert.Equal(t, "mrn:mail:smtp:send", entries[0]["resource"])
+	assert.Equal(t, float64(3), entries[0]["latency"])
+	assert.Equal(t, "io.test.plugin", entries[0]["plugin"])
+	assert.Len(t, buf.entries("connecting"), 1)
+	assert.Equal(t, "error", buf.entries("[ERROR] lost connection")[0]["@level"])
+	assert.Equal(t, "debug", buf.entries("plain output")[0]["@level"])
+
+	// lines below the overridden level are dropped
+	p.SetLogLevel(hclog.Warn)
+	_, err := f.Write([]byte(`{"@level":"info","@message":"mail sent"}` + "\n" + `{"@level":"error","@message":"mail rejected"}` + "\n"))
+	require.NoError(t, err)
+	assert.Empty(t, buf.entries("mail sent"))
+	assert.Len(t, buf.entries("mail rejected"), 1)
+}
+
+func TestParseLogLine(t *testing.T) {
+	tests := []struct {
+		line  string
+		level hclog.Level
+		msg   string
+		args  []any
+	}{
+		{
+			line:  `{"@level":"trace","@message":"m","execution_id":"exec-1","b":1,"a":"x","resource":"r"}`,
+			level: hclog.Trace, msg: "m",
+			args: []any{"resource", "r", "execution_id", "exec-1", "a", "x", "b", float64(1)},
+		},
+		{line: `{"@level":"info","@message":"m"}`, level: hclog.Info, msg: "m"},
+		{line: `{"@level":"warn","@message":"m"}`, level: hclog.Warn, msg: "m"},
+		{line: `{"@level":"error","@message":"m"}`, level: hclog.Error, msg: "m"},
+		{line: `{"@level":"verbose","@message":"m"}`, level: hclog.Info, msg: "m"},
+		{line: `{"message":"m"}`, level: hclog.Debug, msg: `{"message":"m"}`},
+		{line: `[WARN] m`, level: hclog.Warn, msg: `[WARN] m`},
+		{line: `m`, level: hclog.Debug, msg: `m`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			level, msg, args := parseLogLine([]byte(tt.line))
+			assert.Equal(t, tt.level, level)
+			assert.Equal(t, tt.msg, msg)
+			assert.Equal(t, tt.args, args)
+		})
+	}
+}
+
+func TestLaunchLogger(t *testing.T) {
+	log, buf := newTestLogger(hclog.Trace)
+	l := newLaunchLogger(log, "/usr/lib/maschine/mail-plugin")
+
+	l.Named("mail-plugin").Info("forwarded by go-plugin")
+	l.Named("stdio").Info("logged by go-plugin")
+	assert.Empty(t, buf.entries("forwarded by go-plugin"))
+	assert.Len(t, buf.entries("logged by go-plugin"), 1)
+}
@@ -0,0 +1,192 @@
+package host
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"maschine.io/plugin-sdk/sdk"
+	"maschine.io/plugin-sdk/sdk/manifest"
+)
+
+// ignoredManifestFields are set by the release tooling after the plugin is
+// built, so a plugin cannot report them
+var ignoredManifestFields = map[string]bool{
+	"$schema":            true,
+	"runtime.executable": true,
+}
+
+// ManifestMismatchError lists the differences between the on-disk manifest
+// and the manifest reported by the plugin
+type ManifestMismatchError struct {
+	Differences manifest.ValidationErrors
+}
+
+func (e *ManifestMismatchError) Error() string {
+	var msgs []string
+	for _, d := range e.Differences {
+		msgs = append(msgs, d.Error())
+	}
+	return "plugin does not match its manifest:\n" + strings.Join(msgs, "\n")
+}
+
+// VerifyPlugin checks a running plugin against its on-disk manifest. The
+// complete manifest is compared if the plugin provides it, otherwise the
+// plugin metadata is compared.
+func VerifyPlugin(ctx context.Context, res sdk.MaschineResource, onDisk *manifest.PluginManifest) error {
+	if provider, ok := res.(sdk.ManifestProvider); ok {
+		reported, err := provider.GetManifest(ctx)
+		if err == nil {
+			return VerifyManifest(onDisk, reported)
+		}
+		if !errors.Is(err, sdk.ErrManifestNotProvided) {
+			return fmt.Errorf("failed to get manifest: %w", err)
+		}
+	}
+
+	md, err := res.GetMetadata(ctx, &sdk.GetMetadataRequest{})
+	if err != nil {
+		return fmt.Errorf("failed to get metadata: %w", err)
+	}
+	return VerifyMetadata(onDisk, md)
+}
+
+// VerifyManifest compares the manifest reported by a plugin with its on-disk
+// manifest and returns *ManifestMismatchError if they differ
+func VerifyManifest(onDisk, reported *manifest.PluginManifest) error {
+	expected, err := toJSONValue(onDisk)
+	if err != nil {
+		return err
+	}
+	actual, err := toJSONValue(reported)
+	if err != nil {
+		return err
+	}
+
+	var differences manifest.ValidationErrors
+	diffJSON("", expected, actual, &differences)
+
+	if len(differences) > 0 {
+		return &ManifestMismatchError{Differences: differences}
+	}
+	return nil
+}
+
+// VerifyMetadata compares the metadata reported by a plugin with the
+// name, version and resources of its on-disk manifest
+func VerifyMetadata(onDisk *manifest.PluginManifest, md *sdk.GetMetadataResponse) error {
+	var differences manifest.ValidationErrors
+
+	if md.Name != onDisk.Plugin.Name {
+		differences = append(differences, mismatch("plugin.name", onDisk.Plugin.Name, md.Name))
+	}
+	if md.Version != onDisk.Plugin.Version {
+		differences = append(differences, mismatch("plugin.version", onDisk.Plugin.Version, md.Version))
+	}
+
+	declared := make([]string, 0, len(onDisk.Resources))
+	for _, r := range onDisk.Resources {
+		declared = append(declared, r.Type)
+	}
+	supported := append(make([]string, 0, len(md.SupportedResources)), md.SupportedResources...)
+	sort.Strings(declared)
+	sort.Strings(supported)
+	if strings.Join(declared, ",") != strings.Join(supported, ",") {
+		differences = append(differences, mismatch("resources", declared, supported))
+	}
+
+	if len(differences) > 0 {
+		return &ManifestMismatchError{Differences: differences}
+	}
+	return nil
+}
+
+func toJSONValue(m *manifest.PluginManifest) (any, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest: %w", err)
+	}
+	return value, nil
+}
+
+// diffJSON appends a difference for every path at which the decoded JSON
+// values expected and actual differ
+func diffJSON(path string, expected, actual any, differences *manifest.ValidationErrors) {
+	if ignoredManifestFields[path] {
+		return
+	}
+
+	switch e := expected.(type) {
+	case map[string]any:
+		a, ok := actual.(map[string]any)
+		if !ok {
+			break
+		}
+		keys := make(map[string]bool, len(e)+len(a))
+		for k := range e {
+			keys[k] = true
+		}
+		for k := range a {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			diffJSON(joinPath(path, k), e[k], a[k], differences)
+		}
+		return
+	case []any:
+		a, ok := actual.([]any)
+		if !ok {
+			break
+		}
+		for i := 0; i < len(e) || i < len(a); i++ {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(a):
+				*differences = append(*differences, manifest.ValidationError{Field: p, Message: "missing in plugin"})
+			case i >= len(e):
+				*differences = append(*differences, manifest.ValidationError{Field: p, Message: "missing on disk"})
+			default:
+				diffJSON(p, e[i], a[i], differences)
+			}
+		}
+		return
+	}
+
+	if !jsonEqual(expected, actual) {
+		*differences = append(*differences, mismatch(path, expected, actual))
+	}
+}
+
+func jsonEqual(a, b any) bool {
+	da, _ := json.Marshal(a)
+	db, _ := json.Marshal(b)
+	return string(da) == string(db)
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func mismatch(field string, onDisk, reported any) manifest.ValidationError {
+	d, _ := json.Marshal(onDisk)
+	r, _ := json.Marshal(reported)
+	return manifest.ValidationError{
+		Field:   field,
+		Message: fmt.Sprintf("on disk %s, plugin reports %s", d, r),
+	}
+}
@@ -0,0 +1,126 @@
+package host
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maschine.io/plugin-sdk/sdk"
+	"maschine.io/plugin-sdk/sdk/manifest"
+)
+
+func testManifest() *manifest.PluginManifest {
+	m := manifest.New("test-plugin", "io.test.plugin")
+	m.Plugin.Description = "Test plugin"
+	m.Capabilities.Stateless = false
+	m.Capabilities.SupportsDryRun = true
+	m.Resources = []manifest.ResourceDef{
+		{
+			Type:        "mrn:test:resource:action",
+			Name:        "Test Resource",
+			Description: "A test resource",
+			Category:    "action",
+			Parameters: []manifest.Parameter{
+				{Name: "param1", Type: "string", Required: true, Description: "A test parameter"},
+			},
+			Output: &manifest.OutputDef{Type: "string", Schema: map[string]any{"maxLength": 10}},
+		},
+		{
+			Type:        "mrn:test:resource:query",
+			Name:        "Test Query",
+			Description: "A test query",
+			Category:    "query",
+			Parameters:  []manifest.Parameter{},
+		},
+		{
+			Type:        "mrn:test:resource:check",
+			Name:        "Test Check",
+			Description: "A test check",
+			Category:    "check",
+			Parameters: []manifest.Parameter{
+				{Name: "status", Type: "string", Required: false, Description: "The status to report"},
+			},
+		},
+		{
+			Type:        "mrn:test:resource:trigger",
+			Name:        "Test Trigger",
+			Description: "A test trigger",
+			Category:    "trigger",
+			Parameters:  []manifest.Parameter{},
+		},
+	}
+	return m
+}
+
+func TestVerifyManifest(t *testing.T) {
+	t.Run("equal", func(t *testing.T) {
+		assert.NoError(t, VerifyManifest(testManifest(), testManifest()))
+	})
+
+	t.Run("ignores release fields", func(t *testing.T) {
+		onDisk := testManifest()
+		onDisk.Runtime.Executable.Checksums = map[string]string{"linux/amd64": "sha256:abc"}
+		onDisk.Schema = ""
+		assert.NoError(t, VerifyManifest(onDisk, testManifest()))
+	})
+
+	t.Run("reports every difference", func(t *testing.T) {
+		reported := testManifest()
+		reported.Plugin.Version = "0.2.0"
+		reported.Resources[0].Parameters[0].Required = false
+		reported.Resources = append(reported.Resources, manifest.ResourceDef{Type: "mrn:test:resource:other"})
+
+		err := VerifyManifest(testManifest(), reported)
+		var mismatch *ManifestMismatchError
+		require.True(t, errors.As(err, &mismatch))
+		assert.Equal(t, manifest.ValidationErrors{
+			{Field: "plugin.version", Message: `on disk "0.1.0", plugin reports "0.2.0"`},
+			{Field: "resources[0].parameters[0].required", Message: "on disk true, plugin reports false"},
+			{Field: "resources[4]", Message: "missing on disk"},
+		}, mismatch.Differences)
+	})
+}
+
+func TestVerifyMetadata(t *testing.T) {
+	md := &sdk.GetMetadataResponse{
+		Name:               "test-plugin",
+		Version:            "0.1.0",
+		SupportedResources: []string{"mrn:test:resource:action", "mrn:test:resource:check", "mrn:test:resource:query", "mrn:test:resource:trigger"},
+	}
+	assert.NoError(t, VerifyMetadata(testManifest(), md))
+
+	md.Version = "1.0.0"
+	md.SupportedResources = nil
+	err := VerifyMetadata(testManifest(), md)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `plugin.version: on disk "0.1.0", plugin reports "1.0.0"`)
+	assert.Contains(t, err.Error(), `resources: on disk ["mrn:test:resource:action","mrn:test:resource:check","mrn:test:resource:query","mrn:test:resource:trigger"], plugin reports []`)
+}
+
+func TestVerifyPlugin(t *testing.T) {
+	p := sdk.NewBasePlugin("test-plugin", "0.1.0")
+	require.NoError(t, p.RegisterSimpleFunction("mrn:test:resource:action", func(ctx context.Context, req *sdk.TypedExecuteRequest) (any, error) {
+		return nil, nil
+	}, "A test resource"))
+	require.NoError(t, p.RegisterSimpleFunction("mrn:test:resource:query", func(ctx context.Context, req *sdk.TypedExecuteRequest) (any, error) {
+		return nil, nil
+	}, "A test query"))
+	require.NoError(t, p.RegisterCheck("mrn:test:resource:check", func(ctx context.Context, req *sdk.TypedExecuteRequest) (*sdk.CheckResult, error) {
+		return nil, nil
+	}, "A test check"))
+	require.NoError(t, p.RegisterTrigger("mrn:test:resource:trigger", func(ctx context.Context, emit sdk.EmitFunc) error {
+		return nil
+	}, "A test trigger"))
+
+	// without a manifest the metadata is compared
+	assert.NoError(t, VerifyPlugin(context.Background(), p, testManifest()))
+
+	reported := testManifest()
+	reported.Plugin.DisplayName = "Other Name"
+	p.SetManifest(reported)
+	err := VerifyPlugin(context.Background(), p, testManifest())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "plugin.displayName")
+}
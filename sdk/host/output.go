@@ -0,0 +1,60 @@
+package host
+
+import (
+	"context"
+
+	"maschine.io/plugin-sdk/sdk"
+	"maschine.io/plugin-sdk/sdk/manifest"
+)
+
+// OutputValidation selects how the host validates outputs against the
+// output schemas declared in the manifest, see sdk.ValidateOutput
+type OutputValidation string
+
+const (
+	// OutputValidationOff does not validate outputs
+	OutputValidationOff OutputValidation = ""
+	// OutputValidationWarn logs outputs that do not match their schema as
+	// warnings and returns them unchanged
+	OutputValidationWarn OutputValidation = "warn"
+	// OutputValidationStrict replaces responses whose output does not match
+	// its schema with a failed response, see sdk.OutputErrorResponse. The
+	// violations are available with sdk.ValidationErrorsFromResponse.
+	OutputValidationStrict OutputValidation = "strict"
+)
+
+// validateOutput validates the output of an execution according to
+// Config.OutputValidation. Dry runs are not validated, their output is the
+// plan.
+func (p *Plugin) validateOutput(ctx context.Context, req *sdk.ExecuteRequest, resp *sdk.ExecuteResponse) *sdk.ExecuteResponse {
+	mode := p.config.OutputValidation
+	if mode == OutputValidationOff || req.DryRun {
+		return resp
+	}
+	def := p.resourceDef(req.Resource)
+	if def == nil {
+		return resp
+	}
+	err := sdk.ValidateOutput(def, resp)
+	if err == nil {
+		return resp
+	}
+
+	if mode == OutputValidationStrict {
+		return sdk.OutputErrorResponse(err)
+	}
+	p.logger().Warn("output does not match the declared schema",
+		"resource", req.Resource, "execution_id", sdk.ExecutionIDFromContext(ctx), "error", err)
+	return resp
+}
+
+// resourceDef returns the definition of resource in the manifest or nil if
+// the manifest does not declare it
+func (p *Plugin) resourceDef(resource string) *manifest.ResourceDef {
+	for i := range p.manifest.Resources {
+		if p.manifest.Resources[i].Type == resource {
+			return &p.manifest.Resources[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package host
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maschine.io/plugin-sdk/sdk"
+	"maschine.io/plugin-sdk/sdk/manifest"
+)
+
+func actionRequest(mode string) *sdk.ExecuteRequest {
+	return &sdk.ExecuteRequest{Resource: "mrn:test:resource:action", Parameters: map[string][]byte{"param1": []byte(`"` + mode + `"`)}}
+}
+
+func TestOutputValidationStrict(t *testing.T) {
+	p := launchTestPlugin(t, Config{OutputValidation: OutputValidationStrict})
+	ctx := context.Background()
+
+	resp, err := p.Execute(ctx, actionRequest("short"))
+	require.NoError(t, err)
+	assert.Empty(t, resp.Error)
+
+	resp, err = p.Execute(ctx, actionRequest("longer than allowed"))
+	require.NoError(t, err)
+	assert.Equal(t, sdk.ErrorTypeInvalidOutput, resp.Metadata[sdk.MetadataErrorType])
+	errors, ok := sdk.ValidationErrorsFromResponse(resp)
+	require.True(t, ok)
+	assert.Equal(t, manifest.ValidationErrors{{Field: "", Message: "must be at most 10 characters long"}}, errors)
+
+	// dry runs return the plan instead of the output
+	dryRun := actionRequest("longer than allowed")
+	dryRun.DryRun = true
+	resp, err = p.Execute(ctx, dryRun)
+	require.NoError(t, err)
+	assert.Empty(t, resp.Error)
+
+	resps, err := p.ExecuteBatch(ctx, []*sdk.ExecuteRequest{actionRequest("short"), actionRequest("longer than allowed")})
+	require.NoError(t, err)
+	assert.Empty(t, resps[0].Error)
+	assert.Equal(t, sdk.ErrorTypeInvalidOutput, resps[1].Metadata[sdk.MetadataErrorType])
+}
+
+func TestOutputValidationWarn(t *testing.T) {
+	log, buf := newTestLogger(hclog.Trace)
+	p := launchTestPlugin(t, Config{Logger: log, OutputValidation: OutputValidationWarn})
+
+	ctx := sdk.ContextWithExecutionID(context.Background(), "exec-1")
+	resp, err := p.Execute(ctx, actionRequest("longer than allowed"))
+	require.NoError(t, err)
+	assert.Empty(t, resp.Error)
+	assert.Equal(t, `"longer than allowed"`, string(resp.Output))
+
+	entries := buf.entries("output does not match the declared schema")
+	require.Len(t, entries, 1)
+	assert.Equal(t, "warn", entries[0]["@level"])
+	assert.Equal(t, "mrn:test:resource:action", entries[0]["resource"])
+	assert.Equal(t, "exec-1", entries[0]["execution_id"])
+	assert.Contains(t, entries[0]["error"], "must be at most 10 characters long")
+}
+
+func TestOutputValidationOff(t *testing.T) {
+	p := launchTestPlugin(t, Config{})
+	resp, err := p.Execute(context.Background(), actionRequest("longer than allowed"))
+	require.NoError(t, err)
+	assert.Empty(t, resp.Error)
+}
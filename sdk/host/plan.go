@@ -0,0 +1,73 @@
+package host
+
+import (
+	"context"
+	"fmt"
+
+	"maschine.io/plugin-sdk/sdk"
+)
+
+// Plan returns the changes the execution of req would make without making
+// them. Like dry runs, it fails for trigger resources, with ErrReadOnly for
+// query and check resources and with sdk.ErrDryRunNotSupported if the
+// manifest does not declare the supports_dry_run capability.
+func (p *Plugin) Plan(ctx context.Context, req *sdk.ExecuteRequest) ([]sdk.Change, error) {
+	planned := *req
+	planned.DryRun = true
+	if _, err := p.prepare(&planned); err != nil {
+		return nil, err
+	}
+
+	proc, err := p.running(ctx)
+	if err != nil {
+		return nil, err
+	}
+	planner, ok := proc.resource.(sdk.Planner)
+	if !ok {
+		return nil, sdk.ErrDryRunNotSupported
+	}
+	changes, err := planner.Plan(p.logContext(ctx), req)
+	if err != nil {
+		return nil, p.crashError(proc, err)
+	}
+	return changes, nil
+}
+
+// prepare rejects executions of trigger resources and dry runs of
+// read-only resources, and turns executions of resources that change
+// anything into dry runs if the plugin runs in plan mode. Dry runs are
+// rejected before they reach plugins that do not declare the
+// supports_dry_run capability.
+func (p *Plugin) prepare(req *sdk.ExecuteRequest) (*sdk.ExecuteRequest, error) {
+	if p.category(req.Resource) == "trigger" {
+		return nil, fmt.Errorf("resource %s is a trigger and cannot be executed", req.Resource)
+	}
+	if req.DryRun && p.readOnly(req.Resource) {
+		return nil, fmt.Errorf("%w: %s does not support dry runs", ErrReadOnly, req.Resource)
+	}
+	if p.config.PlanMode && !req.DryRun && !p.readOnly(req.Resource) {
+		planned := *req
+		planned.DryRun = true
+		req = &planned
+	}
+	if req.DryRun && !p.manifest.Capabilities.SupportsDryRun {
+		return nil, fmt.Errorf("%w: %s", sdk.ErrDryRunNotSupported, req.Resource)
+	}
+	return req, nil
+}
+
+// readOnly reports whether the manifest declares the resource as query or
+// check. Undeclared resources are assumed to change something.
+func (p *Plugin) readOnly(resource string) bool {
+	category := p.category(resource)
+	return category == "query" || category == "check"
+}
+
+// category returns the category the manifest declares for the resource,
+// empty for undeclared resources
+func (p *Plugin) category(resource string) string {
+	if def := p.resourceDef(resource); def != nil {
+		return def.Category
+	}
+	return ""
+}
@@ -0,0 +1,90 @@
+package host
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maschine.io/plugin-sdk/sdk"
+	"maschine.io/plugin-sdk/sdk/manifest"
+)
+
+func TestPlan(t *testing.T) {
+	p := launchTestPlugin(t, Config{})
+	req := &sdk.ExecuteRequest{
+		Resource:   "mrn:test:resource:action",
+		Parameters: map[string][]byte{"param1": []byte("crash")},
+	}
+
+	changes, err := p.Plan(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, []sdk.Change{{Action: "update", Target: "crash"}}, changes)
+}
+
+func TestPlanMode(t *testing.T) {
+	p := launchTestPlugin(t, Config{PlanMode: true})
+	req := &sdk.ExecuteRequest{
+		Resource:   "mrn:test:resource:action",
+		Parameters: map[string][]byte{"param1": []byte("crash")},
+	}
+
+	// the crash is only planned
+	resp, err := p.Execute(context.Background(), req)
+	require.NoError(t, err)
+	changes, err := sdk.DecodePlan(resp)
+	require.NoError(t, err)
+	assert.Equal(t, []sdk.Change{{Action: "update", Target: "crash"}}, changes)
+	assert.False(t, req.DryRun, "the request of the caller is not modified")
+
+	resps, err := p.ExecuteBatch(context.Background(), []*sdk.ExecuteRequest{req})
+	require.NoError(t, err)
+	changes, err = sdk.DecodePlan(resps[0])
+	require.NoError(t, err)
+	assert.Equal(t, []sdk.Change{{Action: "update", Target: "crash"}}, changes)
+
+	session, err := p.OpenSession(context.Background(), &sdk.OpenSessionRequest{})
+	require.NoError(t, err)
+	resp, err = session.Execute(context.Background(), req)
+	require.NoError(t, err)
+	changes, err = sdk.DecodePlan(resp)
+	require.NoError(t, err)
+	assert.Equal(t, []sdk.Change{{Action: "update", Target: "crash"}}, changes)
+}
+
+func TestPrepare(t *testing.T) {
+	m := testManifest()
+	m.Resources = append(m.Resources,
+		manifest.ResourceDef{Type: "mrn:test:resource:list", Category: "query"},
+		manifest.ResourceDef{Type: "mrn:test:resource:verify", Category: "check"},
+	)
+	p := &Plugin{config: Config{PlanMode: true}, manifest: m}
+
+	for resource, dryRun := range map[string]bool{
+		"mrn:test:resource:action":  true,
+		"mrn:test:resource:list":    false,
+		"mrn:test:resource:verify":  false,
+		"mrn:test:resource:unknown": true,
+	} {
+		req, err := p.prepare(&sdk.ExecuteRequest{Resource: resource})
+		require.NoError(t, err)
+		assert.Equal(t, dryRun, req.DryRun, resource)
+	}
+
+	_, err := p.Plan(context.Background(), &sdk.ExecuteRequest{Resource: "mrn:test:resource:trigger"})
+	assert.ErrorContains(t, err, "is a trigger")
+	_, err = p.Plan(context.Background(), &sdk.ExecuteRequest{Resource: "mrn:test:resource:list"})
+	assert.ErrorIs(t, err, ErrReadOnly)
+	_, err = p.Plan(context.Background(), &sdk.ExecuteRequest{Resource: "mrn:test:resource:verify"})
+	assert.ErrorIs(t, err, ErrReadOnly)
+
+	m.Capabilities.SupportsDryRun = false
+	_, err = p.prepare(&sdk.ExecuteRequest{Resource: "mrn:test:resource:action"})
+	assert.ErrorIs(t, err, sdk.ErrDryRunNotSupported)
+	_, err = p.Plan(context.Background(), &sdk.ExecuteRequest{Resource: "mrn:test:resource:action"})
+	assert.ErrorIs(t, err, sdk.ErrDryRunNotSupported)
+
+	req, err := p.prepare(&sdk.ExecuteRequest{Resource: "mrn:test:resource:list"})
+	require.NoError(t, err)
+	assert.False(t, req.DryRun)
+}
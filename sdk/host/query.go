@@ -0,0 +1,161 @@
+package host
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"maschine.io/plugin-sdk/sdk"
+)
+
+// DefaultQueryCacheSize is the default for QueryCacheConfig.MaxEntries
+const DefaultQueryCacheSize = 1000
+
+// ErrReadOnly is returned for dry runs of resources declared as query or
+// check. They do not change anything, so there is nothing to preview.
+var ErrReadOnly = errors.New("resource is read-only")
+
+// QueryCacheConfig configures caching of the results of query resources.
+// Only successful executions outside of sessions are cached.
+type QueryCacheConfig struct {
+	// TTL is how long results are cached. Zero disables the cache.
+	TTL time.Duration
+	// MaxEntries limits the number of cached results. Defaults to
+	// DefaultQueryCacheSize.
+	MaxEntries int
+}
+
+type noQueryCacheKey struct{}
+
+// ContextWithoutQueryCache returns a copy of ctx that bypasses the query
+// cache. The result is cached nevertheless.
+func ContextWithoutQueryCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noQueryCacheKey{}, true)
+}
+
+// queryCache keeps the responses of query resources by request
+type queryCache struct {
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]cachedResponse
+}
+
+type cachedResponse struct {
+	resp    *sdk.ExecuteResponse
+	expires time.Time
+}
+
+func newQueryCache(cfg QueryCacheConfig) *queryCache {
+	if cfg.TTL <= 0 {
+		return nil
+	}
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = DefaultQueryCacheSize
+	}
+	return &queryCache{ttl: cfg.TTL, maxEntries: cfg.MaxEntries, entries: make(map[string]cachedResponse)}
+}
+
+// queryKey identifies a query execution by everything that can change its
+// result, including the credentials and the accepted content types
+func queryKey(ctx context.Context, req *sdk.ExecuteRequest) (string, error) {
+	data, err := json.Marshal(struct {
+		Resource              string
+		Input                 []byte
+		Parameters            map[string][]byte
+		Credentials           map[string]string
+		Context               map[string]string
+		ContentType           string
+		ParameterContentTypes map[string]string
+		Accept                []string
+	}{
+		req.Resource, req.Input, req.Parameters, req.Credentials, req.Context,
+		req.ContentType, req.ParameterContentTypes, sdk.AcceptFromContext(ctx),
+	})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// get returns a copy of the cached response for key
+func (c *queryCache) get(key string) (*sdk.ExecuteResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, found := c.entries[key]
+	if !found || time.Now().After(e.expires) {
+		return nil, false
+	}
+	return e.resp.Clone(), true
+}
+
+// put caches a copy of the response for key. Expired responses are removed; if the
+// cache is still full, the response expiring first is evicted.
+func (c *queryCache) put(key string, resp *sdk.ExecuteResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= c.maxEntries {
+		var oldest string
+		for k, e := range c.entries {
+			if now.After(e.expires) {
+				delete(c.entries, k)
+				continue
+			}
+			if oldest == "" || e.expires.Before(c.entries[oldest].expires) {
+				oldest = k
+			}
+		}
+		if len(c.entries) >= c.maxEntries {
+			delete(c.entries, oldest)
+		}
+	}
+	c.entries[key] = cachedResponse{resp: resp.Clone(), expires: now.Add(c.ttl)}
+}
+
+// clear removes all cached responses
+func (c *queryCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+}
+
+// ClearQueryCache removes all cached query results, e.g. after an action
+// changed what the queries read
+func (p *Plugin) ClearQueryCache() {
+	if p.queries != nil {
+		p.queries.clear()
+	}
+}
+
+// executeQuery runs a query resource through the cache
+func (p *Plugin) executeQuery(ctx context.Context, req *sdk.ExecuteRequest) (*sdk.ExecuteResponse, error) {
+	key, err := queryKey(ctx, req)
+	if err != nil {
+		return p.execute(ctx, req)
+	}
+	if bypass, _ := ctx.Value(noQueryCacheKey{}).(bool); !bypass {
+		if resp, found := p.queries.get(key); found {
+			return resp, nil
+		}
+	}
+
+	resp, err := p.execute(ctx, req)
+	if err == nil && resp.Error == "" {
+		p.queries.put(key, resp)
+	}
+	return resp, err
+}
+
+// cacheable reports whether the result of an execution can be cached
+func (p *Plugin) cacheable(req *sdk.ExecuteRequest) bool {
+	return p.queries != nil && req.SessionID == "" && !req.DryRun && p.category(req.Resource) == "query"
+}
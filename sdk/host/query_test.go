@@ -0,0 +1,84 @@
+package host
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maschine.io/plugin-sdk/sdk"
+)
+
+func TestQueryCache(t *testing.T) {
+	p := launchTestPlugin(t, Config{QueryCache: QueryCacheConfig{TTL: time.Minute}})
+	query := func(ctx context.Context, req *sdk.ExecuteRequest) string {
+		t.Helper()
+		resp, err := p.Execute(ctx, req)
+		require.NoError(t, err)
+		return string(resp.Output)
+	}
+	req := &sdk.ExecuteRequest{Resource: "mrn:test:resource:query"}
+	ctx := context.Background()
+
+	assert.Equal(t, "1", query(ctx, req))
+	assert.Equal(t, "1", query(ctx, req), "cached")
+	assert.Equal(t, "2", query(ctx, &sdk.ExecuteRequest{Resource: "mrn:test:resource:query", Credentials: map[string]string{"user": "bob"}}))
+	assert.Equal(t, "3", query(ContextWithoutQueryCache(ctx), req))
+	assert.Equal(t, "3", query(ctx, req), "bypassed call refreshed the cache")
+
+	p.ClearQueryCache()
+	assert.Equal(t, "4", query(ctx, req))
+
+	// other categories are not cached
+	action := &sdk.ExecuteRequest{
+		Resource:   "mrn:test:resource:action",
+		Parameters: map[string][]byte{"param1": []byte(`"value"`)},
+	}
+	resp, err := p.Execute(ctx, action)
+	require.NoError(t, err)
+	assert.Equal(t, `"value"`, string(resp.Output))
+}
+
+func TestQueryCacheCopies(t *testing.T) {
+	c := newQueryCache(QueryCacheConfig{TTL: time.Minute})
+	resp := &sdk.ExecuteResponse{Output: []byte(`"inbox"`), Metadata: map[string]string{"server": "imap.example.com"}}
+	c.put("key", resp)
+	resp.Output[1] = 'X'
+	resp.Metadata["server"] = sdk.Redacted
+
+	for range 2 {
+		cached, found := c.get("key")
+		require.True(t, found)
+		assert.Equal(t, `"inbox"`, string(cached.Output))
+		assert.Equal(t, "imap.example.com", cached.Metadata["server"])
+
+		// callers may change their response, e.g. to redact it
+		cached.Output[1] = 'X'
+		cached.Metadata["server"] = sdk.Redacted
+	}
+}
+
+func TestQueryCacheDisabled(t *testing.T) {
+	p := launchTestPlugin(t, Config{})
+	req := &sdk.ExecuteRequest{Resource: "mrn:test:resource:query"}
+
+	first, err := p.Execute(context.Background(), req)
+	require.NoError(t, err)
+	second, err := p.Execute(context.Background(), req)
+	require.NoError(t, err)
+	assert.NotEqual(t, string(first.Output), string(second.Output))
+}
+
+func TestReadOnlyDryRun(t *testing.T) {
+	p := launchTestPlugin(t, Config{PlanMode: true})
+
+	_, err := p.Execute(context.Background(), &sdk.ExecuteRequest{Resource: "mrn:test:resource:query", DryRun: true})
+	assert.ErrorIs(t, err, ErrReadOnly)
+	assert.EqualError(t, err, "resource is read-only: mrn:test:resource:query does not support dry runs")
+
+	// queries run in plan mode
+	resp, err := p.Execute(context.Background(), &sdk.ExecuteRequest{Resource: "mrn:test:resource:query"})
+	require.NoError(t, err)
+	assert.Equal(t, "1", string(resp.Output))
+}
@@ -0,0 +1,217 @@
+package host
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"maschine.io/plugin-sdk/sdk"
+	"maschine.io/plugin-sdk/sdk/manifest"
+)
+
+// DefaultTenantKey is the default for RateLimitConfig.TenantKey
+const DefaultTenantKey = "tenant"
+
+// RateLimitConfig configures how the host enforces the rate limits that
+// the manifest declares for resources
+type RateLimitConfig struct {
+	// Wait makes executions over the limit wait until they are allowed or
+	// their context is done. By default they fail with *RateLimitError.
+	Wait bool
+	// TenantKey is the key of ExecuteRequest.Context that identifies the
+	// tenant of an execution for limits with scope tenant. Defaults to
+	// DefaultTenantKey.
+	TenantKey string
+}
+
+// RateLimitError is returned for executions over the rate limit of their
+// resource. They can be retried after RetryAfter.
+type RateLimitError struct {
+	Resource   string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limit of %s exceeded, retry after %s", e.Resource, e.RetryAfter)
+}
+
+// Retryable reports that the execution can be retried
+func (e *RateLimitError) Retryable() bool {
+	return true
+}
+
+// tokenBucket allows rate executions per second with bursts of burst
+// executions
+type tokenBucket struct {
+	rate  float64
+	burst float64
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(limit *manifest.RateLimit, now time.Time) *tokenBucket {
+	burst := limit.Burst
+	if burst <= 0 {
+		burst = limit.Requests
+	}
+	return &tokenBucket{
+		rate:   float64(limit.Requests) / limit.Interval.Seconds(),
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   now,
+	}
+}
+
+// refill adds the tokens accumulated since the last call
+func (b *tokenBucket) refill(now time.Time) {
+	if now.After(b.last) {
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+		b.last = now
+	}
+}
+
+// take takes a token. Without one, it returns how long it takes until a
+// token is available; if reserve is set, the token is taken nevertheless
+// and the caller must wait that long.
+func (b *tokenBucket) take(now time.Time, reserve bool) (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(now)
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, true
+	}
+	wait := time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+	if reserve {
+		b.tokens--
+	}
+	return wait, reserve
+}
+
+// giveBack returns a reserved token that was not used
+func (b *tokenBucket) giveBack() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = math.Min(b.burst, b.tokens+1)
+}
+
+// full reports whether the bucket is refilled completely, so it behaves
+// like a new one
+func (b *tokenBucket) full(now time.Time) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(now)
+	return b.tokens >= b.burst
+}
+
+// rateLimiter keeps a token bucket per resource and scope
+type rateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{buckets: make(map[string]*tokenBucket)}
+}
+
+// bucket returns the bucket for key. Full buckets are removed when a new
+// bucket is created, so buckets of inactive tenants do not pile up.
+func (l *rateLimiter) bucket(key string, limit *manifest.RateLimit, now time.Time) *tokenBucket {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if b, found := l.buckets[key]; found {
+		return b
+	}
+	for k, b := range l.buckets {
+		if b.full(now) {
+			delete(l.buckets, k)
+		}
+	}
+	b := newTokenBucket(limit, now)
+	l.buckets[key] = b
+	return b
+}
+
+// rateLimitKey returns the bucket key of an execution for the scope of the
+// limit
+func (p *Plugin) rateLimitKey(req *sdk.ExecuteRequest, limit *manifest.RateLimit) string {
+	switch limit.Scope {
+	case "credentials":
+		h := sha256.New()
+		keys := make([]string, 0, len(req.Credentials))
+		for k := range req.Credentials {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(h, "%s\x00%s\x00", k, req.Credentials[k])
+		}
+		return req.Resource + "\x00credentials\x00" + hex.EncodeToString(h.Sum(nil))
+	case "tenant":
+		key := p.config.RateLimit.TenantKey
+		if key == "" {
+			key = DefaultTenantKey
+		}
+		return req.Resource + "\x00tenant\x00" + req.Context[key]
+	}
+	return req.Resource
+}
+
+// rateLimit returns the rate limit the manifest declares for the resource
+func (p *Plugin) rateLimit(resource string) *manifest.RateLimit {
+	if def := p.resourceDef(resource); def != nil {
+		return def.RateLimit
+	}
+	return nil
+}
+
+// limit takes a token for each execution. It waits for tokens if the host
+// is configured to, otherwise it fails with *RateLimitError and takes no
+// token.
+func (p *Plugin) limit(ctx context.Context, reqs ...*sdk.ExecuteRequest) error {
+	now := time.Now()
+	var taken []*tokenBucket
+	giveBack := func() {
+		for _, b := range taken {
+			b.giveBack()
+		}
+	}
+
+	var wait time.Duration
+	for _, req := range reqs {
+		limit := p.rateLimit(req.Resource)
+		if limit == nil {
+			continue
+		}
+		b := p.limiter.bucket(p.rateLimitKey(req, limit), limit, now)
+		d, ok := b.take(now, p.config.RateLimit.Wait)
+		if !ok {
+			giveBack()
+			return &RateLimitError{Resource: req.Resource, RetryAfter: d}
+		}
+		taken = append(taken, b)
+		wait = max(wait, d)
+	}
+	if wait == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		giveBack()
+		return ctx.Err()
+	}
+}
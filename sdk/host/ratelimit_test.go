@@ -0,0 +1,125 @@
+package host
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maschine.io/plugin-sdk/sdk"
+	"maschine.io/plugin-sdk/sdk/manifest"
+)
+
+func rateLimitedPlugin(cfg RateLimitConfig, limit *manifest.RateLimit) *Plugin {
+	m := testManifest()
+	m.Resources[1].RateLimit = limit
+	return &Plugin{config: Config{RateLimit: cfg}, manifest: m, limiter: newRateLimiter()}
+}
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Now()
+	b := newTokenBucket(&manifest.RateLimit{Requests: 2, Interval: manifest.Duration{Duration: time.Second}, Burst: 1}, now)
+
+	_, ok := b.take(now, false)
+	assert.True(t, ok)
+	wait, ok := b.take(now, false)
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	_, ok = b.take(now.Add(500*time.Millisecond), false)
+	assert.True(t, ok, "refilled")
+
+	// reservations go into debt
+	wait, ok = b.take(now.Add(500*time.Millisecond), true)
+	assert.True(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+	wait, _ = b.take(now.Add(500*time.Millisecond), true)
+	assert.Equal(t, time.Second, wait)
+	assert.False(t, b.full(now.Add(time.Second)))
+	assert.True(t, b.full(now.Add(2*time.Second)))
+}
+
+func TestRateLimitFails(t *testing.T) {
+	p := rateLimitedPlugin(RateLimitConfig{}, &manifest.RateLimit{Requests: 1, Interval: manifest.Duration{Duration: time.Minute}})
+	req := &sdk.ExecuteRequest{Resource: "mrn:test:resource:query"}
+
+	require.NoError(t, p.limit(context.Background(), req))
+	err := p.limit(context.Background(), req)
+	var limited *RateLimitError
+	require.ErrorAs(t, err, &limited)
+	assert.Equal(t, "mrn:test:resource:query", limited.Resource)
+	assert.InDelta(t, time.Minute, limited.RetryAfter, float64(time.Second))
+	assert.True(t, limited.Retryable())
+
+	// other resources are not limited
+	assert.NoError(t, p.limit(context.Background(), &sdk.ExecuteRequest{Resource: "mrn:test:resource:action"}))
+
+	// batches are limited as a whole
+	p = rateLimitedPlugin(RateLimitConfig{}, &manifest.RateLimit{Requests: 2, Interval: manifest.Duration{Duration: time.Minute}})
+	assert.ErrorAs(t, p.limit(context.Background(), req, req, req), &limited)
+	assert.NoError(t, p.limit(context.Background(), req, req), "failed batch took no tokens")
+}
+
+func TestRateLimitWaits(t *testing.T) {
+	p := rateLimitedPlugin(RateLimitConfig{Wait: true}, &manifest.RateLimit{Requests: 1, Interval: manifest.Duration{Duration: 50 * time.Millisecond}})
+	req := &sdk.ExecuteRequest{Resource: "mrn:test:resource:query"}
+
+	start := time.Now()
+	for range 3 {
+		require.NoError(t, p.limit(context.Background(), req))
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	p = rateLimitedPlugin(RateLimitConfig{Wait: true}, &manifest.RateLimit{Requests: 1, Interval: manifest.Duration{Duration: time.Minute}})
+	require.NoError(t, p.limit(ctx, req))
+	assert.True(t, errors.Is(p.limit(ctx, req), context.DeadlineExceeded))
+}
+
+func TestRateLimitScopes(t *testing.T) {
+	perMinute := func(scope string) *manifest.RateLimit {
+		return &manifest.RateLimit{Requests: 1, Interval: manifest.Duration{Duration: time.Minute}, Scope: scope}
+	}
+	alice := map[string]string{"user": "alice", "password": "secret"}
+	bob := map[string]string{"user": "bob", "password": "secret"}
+
+	p := rateLimitedPlugin(RateLimitConfig{}, perMinute("credentials"))
+	exec := func(creds, reqCtx map[string]string) error {
+		return p.limit(context.Background(), &sdk.ExecuteRequest{Resource: "mrn:test:resource:query", Credentials: creds, Context: reqCtx})
+	}
+	assert.NoError(t, exec(alice, nil))
+	assert.NoError(t, exec(bob, nil))
+	assert.Error(t, exec(map[string]string{"password": "secret", "user": "alice"}, nil))
+
+	p = rateLimitedPlugin(RateLimitConfig{}, perMinute("tenant"))
+	assert.NoError(t, exec(alice, map[string]string{"tenant": "acme"}))
+	assert.NoError(t, exec(alice, map[string]string{"tenant": "globex"}))
+	assert.Error(t, exec(bob, map[string]string{"tenant": "acme"}))
+
+	p = rateLimitedPlugin(RateLimitConfig{TenantKey: "org"}, perMinute("tenant"))
+	assert.NoError(t, exec(alice, map[string]string{"org": "acme", "tenant": "x"}))
+	assert.NoError(t, exec(alice, map[string]string{"org": "globex", "tenant": "x"}))
+
+	p = rateLimitedPlugin(RateLimitConfig{}, perMinute(""))
+	assert.NoError(t, exec(alice, nil))
+	assert.Error(t, exec(bob, nil))
+}
+
+func TestExecuteRateLimited(t *testing.T) {
+	p := launchTestPlugin(t, Config{})
+	p.manifest.Resources[1].RateLimit = &manifest.RateLimit{Requests: 1, Interval: manifest.Duration{Duration: time.Minute}}
+	req := &sdk.ExecuteRequest{Resource: "mrn:test:resource:query"}
+
+	resp, err := p.Execute(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "1", string(resp.Output))
+
+	var limited *RateLimitError
+	_, err = p.Execute(context.Background(), req)
+	assert.ErrorAs(t, err, &limited)
+	_, err = p.ExecuteBatch(context.Background(), []*sdk.ExecuteRequest{req})
+	assert.ErrorAs(t, err, &limited)
+}
@@ -0,0 +1,161 @@
+package host
+
+import (
+	"context"
+	cryptorand "crypto/rand"
+	"errors"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"maschine.io/plugin-sdk/sdk"
+	"maschine.io/plugin-sdk/sdk/manifest"
+)
+
+// Defaults of manifest.RetryPolicy
+const (
+	DefaultRetryBackoff    = 100 * time.Millisecond
+	DefaultRetryMaxBackoff = 10 * time.Second
+	DefaultRetryMultiplier = 2
+	DefaultRetryJitter     = 0.2
+)
+
+// DefaultRetryableErrors are the error types retried if a retry policy does
+// not list any
+var DefaultRetryableErrors = []string{sdk.ErrorTypeUnavailable, sdk.ErrorTypeTimeout, sdk.ErrorTypeRateLimited}
+
+// RetryConfig configures the retries of executions that failed with a
+// transient error
+type RetryConfig struct {
+	// Default applies to resources without a retry policy in the manifest
+	Default *manifest.RetryPolicy
+	// Resources overrides the retry policies of the manifest by resource
+	Resources map[string]*manifest.RetryPolicy
+}
+
+// retryPolicy returns the retry policy of an execution with defaults
+// applied, nil if it must not be retried
+func (p *Plugin) retryPolicy(req *sdk.ExecuteRequest) *manifest.RetryPolicy {
+	def := p.resourceDef(req.Resource)
+	policy := p.config.Retry.Resources[req.Resource]
+	if policy == nil && def != nil {
+		policy = def.Retry
+	}
+	if policy == nil {
+		policy = p.config.Retry.Default
+	}
+	if policy == nil || policy.MaxAttempts <= 1 {
+		return nil
+	}
+	// repeating an action that is not idempotent can repeat its effect
+	if !p.readOnly(req.Resource) && !policy.AllowNonIdempotent && (def == nil || !def.Idempotent) {
+		return nil
+	}
+
+	resolved := *policy
+	if resolved.InitialBackoff.Duration <= 0 {
+		resolved.InitialBackoff.Duration = DefaultRetryBackoff
+	}
+	if resolved.MaxBackoff.Duration <= 0 {
+		resolved.MaxBackoff.Duration = max(DefaultRetryMaxBackoff, resolved.InitialBackoff.Duration)
+	}
+	if resolved.Multiplier < 1 {
+		resolved.Multiplier = DefaultRetryMultiplier
+	}
+	if resolved.Jitter <= 0 || resolved.Jitter > 1 {
+		resolved.Jitter = DefaultRetryJitter
+	}
+	if len(resolved.RetryableErrors) == 0 {
+		resolved.RetryableErrors = DefaultRetryableErrors
+	}
+	return &resolved
+}
+
+// errorType classifies the outcome of an execution with the error types of
+// the SDK, empty if it succeeded or the error is not transient
+func errorType(resp *sdk.ExecuteResponse, err error) string {
+	var (
+		limited *RateLimitError
+		crash   *CrashError
+	)
+	switch {
+	case err == nil:
+		if resp != nil && resp.Error != "" {
+			return resp.Metadata[sdk.MetadataErrorType]
+		}
+		return ""
+	case errors.As(err, &limited):
+		return sdk.ErrorTypeRateLimited
+	case errors.As(err, &crash), errors.Is(err, sdk.ErrShuttingDown):
+		return sdk.ErrorTypeUnavailable
+	}
+	switch status.Code(err) {
+	case codes.Unavailable:
+		return sdk.ErrorTypeUnavailable
+	case codes.DeadlineExceeded:
+		return sdk.ErrorTypeTimeout
+	case codes.ResourceExhausted:
+		return sdk.ErrorTypeRateLimited
+	}
+	return ""
+}
+
+// backoff returns the wait before the retry after attempt, with jitter
+func backoff(policy *manifest.RetryPolicy, attempt int) time.Duration {
+	wait := float64(policy.InitialBackoff.Duration)
+	for range attempt - 1 {
+		wait *= policy.Multiplier
+		if wait >= float64(policy.MaxBackoff.Duration) {
+			break
+		}
+	}
+	wait = min(wait, float64(policy.MaxBackoff.Duration))
+	return time.Duration(wait * (1 - policy.Jitter*rand.Float64()))
+}
+
+// idempotencyKey returns a random key for executions that are retried
+// without one
+func idempotencyKey() string {
+	return cryptorand.Text()
+}
+
+// execute runs an execution and retries it according to its retry policy.
+// The attempt number is sent to the plugin, see sdk.AttemptFromContext.
+func (p *Plugin) execute(ctx context.Context, req *sdk.ExecuteRequest) (*sdk.ExecuteResponse, error) {
+	policy := p.retryPolicy(req)
+	if policy == nil {
+		return p.attempt(ctx, req)
+	}
+	// the key lets plugins using sdk.WithIdempotency recognize the retries
+	if req.IdempotencyKey == "" {
+		keyed := *req
+		keyed.IdempotencyKey = idempotencyKey()
+		req = &keyed
+	}
+
+	for attempt := 1; ; attempt++ {
+		resp, err := p.attempt(sdk.ContextWithAttempt(ctx, attempt), req)
+		errType := errorType(resp, err)
+		if errType == "" || attempt >= policy.MaxAttempts || ctx.Err() != nil ||
+			!slices.Contains(policy.RetryableErrors, errType) {
+			return resp, err
+		}
+
+		wait := backoff(policy, attempt)
+		var limited *RateLimitError
+		if errors.As(err, &limited) {
+			wait = max(wait, limited.RetryAfter)
+		}
+		p.logger().Debug("retrying execution", "resource", req.Resource, "attempt", attempt+1, "error_type", errType, "wait", wait)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return resp, err
+		}
+	}
+}
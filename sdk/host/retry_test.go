@@ -0,0 +1,122 @@
+package host
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"maschine.io/plugin-sdk/sdk"
+	"maschine.io/plugin-sdk/sdk/manifest"
+)
+
+func flakyRequest() *sdk.ExecuteRequest {
+	return &sdk.ExecuteRequest{
+		Resource:   "mrn:test:resource:action",
+		Parameters: map[string][]byte{"param1": []byte(`"flaky"`)},
+	}
+}
+
+func TestRetry(t *testing.T) {
+	policy := &manifest.RetryPolicy{MaxAttempts: 3, InitialBackoff: manifest.Duration{Duration: time.Millisecond}, AllowNonIdempotent: true}
+	p := launchTestPlugin(t, Config{Retry: RetryConfig{Resources: map[string]*manifest.RetryPolicy{"mrn:test:resource:action": policy}}})
+
+	resp, err := p.Execute(context.Background(), flakyRequest())
+	require.NoError(t, err)
+	assert.Empty(t, resp.Error)
+	assert.Equal(t, "true", string(resp.Output), "retries carry an idempotency key")
+
+	policy.MaxAttempts = 2
+	resp, err = p.Execute(context.Background(), flakyRequest())
+	require.NoError(t, err)
+	assert.Equal(t, "attempt 2: connection refused", resp.Error)
+	assert.Equal(t, sdk.ErrorTypeUnavailable, resp.Metadata[sdk.MetadataErrorType])
+
+	policy.MaxAttempts = 3
+	policy.RetryableErrors = []string{sdk.ErrorTypeTimeout}
+	resp, err = p.Execute(context.Background(), flakyRequest())
+	require.NoError(t, err)
+	assert.Equal(t, "attempt 1: connection refused", resp.Error, "unavailable is not retryable")
+}
+
+func TestRetryIdempotentPlugin(t *testing.T) {
+	t.Setenv(envTestPlugin, testPluginIdempotent)
+	policy := &manifest.RetryPolicy{MaxAttempts: 3, InitialBackoff: manifest.Duration{Duration: time.Millisecond}, AllowNonIdempotent: true}
+	p := launchTestPlugin(t, Config{Retry: RetryConfig{Default: policy}})
+
+	req := flakyRequest()
+	req.Parameters["param1"] = []byte(`"flaky-once"`)
+	resp, err := p.Execute(context.Background(), req)
+	require.NoError(t, err)
+	assert.Empty(t, resp.Error, "the failed attempt is not replayed under the idempotency key")
+	assert.Equal(t, "2", string(resp.Output))
+}
+
+func TestRetryOnlyIdempotentActions(t *testing.T) {
+	policy := &manifest.RetryPolicy{MaxAttempts: 3, InitialBackoff: manifest.Duration{Duration: time.Millisecond}}
+	p := launchTestPlugin(t, Config{Retry: RetryConfig{Default: policy}})
+
+	resp, err := p.Execute(context.Background(), flakyRequest())
+	require.NoError(t, err)
+	assert.Equal(t, "attempt 1: connection refused", resp.Error)
+
+	p.manifest.Resources[0].Idempotent = true
+	resp, err = p.Execute(context.Background(), flakyRequest())
+	require.NoError(t, err)
+	assert.Equal(t, "true", string(resp.Output))
+}
+
+func TestRetryPolicy(t *testing.T) {
+	m := testManifest()
+	m.Resources[1].Retry = &manifest.RetryPolicy{MaxAttempts: 5, Jitter: 0.5}
+	override := &manifest.RetryPolicy{MaxAttempts: 2}
+	p := &Plugin{manifest: m, config: Config{Retry: RetryConfig{
+		Default:   &manifest.RetryPolicy{MaxAttempts: 4},
+		Resources: map[string]*manifest.RetryPolicy{"mrn:test:resource:check": override},
+	}}}
+
+	policy := p.retryPolicy(&sdk.ExecuteRequest{Resource: "mrn:test:resource:query"})
+	require.NotNil(t, policy)
+	assert.Equal(t, 5, policy.MaxAttempts, "declared in the manifest")
+	assert.Equal(t, 0.5, policy.Jitter)
+	assert.Equal(t, DefaultRetryBackoff, policy.InitialBackoff.Duration)
+	assert.Equal(t, DefaultRetryMaxBackoff, policy.MaxBackoff.Duration)
+	assert.Equal(t, float64(DefaultRetryMultiplier), policy.Multiplier)
+	assert.Equal(t, DefaultRetryableErrors, policy.RetryableErrors)
+
+	assert.Equal(t, 2, p.retryPolicy(&sdk.ExecuteRequest{Resource: "mrn:test:resource:check"}).MaxAttempts, "overridden")
+	assert.Nil(t, p.retryPolicy(&sdk.ExecuteRequest{Resource: "mrn:test:resource:action"}), "not idempotent")
+
+	override.MaxAttempts = 1
+	assert.Nil(t, p.retryPolicy(&sdk.ExecuteRequest{Resource: "mrn:test:resource:check"}))
+}
+
+func TestBackoff(t *testing.T) {
+	policy := &manifest.RetryPolicy{
+		InitialBackoff: manifest.Duration{Duration: 100 * time.Millisecond},
+		MaxBackoff:     manifest.Duration{Duration: time.Second},
+		Multiplier:     3,
+		Jitter:         0.2,
+	}
+	for attempt, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 300 * time.Millisecond, 3: 900 * time.Millisecond, 10: time.Second} {
+		wait := backoff(policy, attempt)
+		assert.LessOrEqual(t, wait, want)
+		assert.GreaterOrEqual(t, wait, want*8/10)
+	}
+}
+
+func TestErrorType(t *testing.T) {
+	failed := &sdk.ExecuteResponse{Error: "refused", Metadata: map[string]string{sdk.MetadataErrorType: sdk.ErrorTypeUnavailable}}
+	assert.Equal(t, sdk.ErrorTypeUnavailable, errorType(failed, nil))
+	assert.Empty(t, errorType(&sdk.ExecuteResponse{Metadata: failed.Metadata}, nil), "succeeded")
+	assert.Empty(t, errorType(&sdk.ExecuteResponse{Error: "invalid recipient"}, nil))
+
+	assert.Equal(t, sdk.ErrorTypeRateLimited, errorType(nil, &RateLimitError{}))
+	assert.Equal(t, sdk.ErrorTypeUnavailable, errorType(nil, &CrashError{Err: context.Canceled}))
+	assert.Equal(t, sdk.ErrorTypeUnavailable, errorType(nil, sdk.ErrShuttingDown))
+	assert.Equal(t, sdk.ErrorTypeTimeout, errorType(nil, status.Error(codes.DeadlineExceeded, "too slow")))
+	assert.Empty(t, errorType(nil, ErrClosed))
+}
@@ -0,0 +1,99 @@
+package host
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"maschine.io/plugin-sdk/sdk"
+)
+
+// ErrSessionLost is returned for executions in a session whose plugin
+// process exited or was restarted
+var ErrSessionLost = errors.New("plugin process holding the session exited")
+
+// Session is a session opened in the plugin, usually for a single state
+// machine execution. Its executions are pinned to the plugin process that
+// holds the session; they are never sent to a restarted process.
+type Session struct {
+	ID string
+
+	plugin *Plugin
+	proc   process
+}
+
+// OpenSession opens a session in the plugin. Plugins declared stateless in
+// their manifest do not support sessions.
+func (p *Plugin) OpenSession(ctx context.Context, req *sdk.OpenSessionRequest) (*Session, error) {
+	if p.manifest.Capabilities.Stateless {
+		return nil, fmt.Errorf("plugin %s is stateless: %w", p.manifest.Plugin.Name, sdk.ErrSessionsNotSupported)
+	}
+
+	proc, err := p.running(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	client, ok := proc.resource.(sdk.SessionClient)
+	if !ok {
+		return nil, sdk.ErrSessionsNotSupported
+	}
+	id, err := client.OpenSession(ctx, req)
+	if err != nil {
+		return nil, p.crashError(proc, err)
+	}
+	return &Session{ID: id, plugin: p, proc: proc}, nil
+}
+
+// pinned returns the plugin process of the session or ErrSessionLost if
+// the plugin runs another process now
+func (s *Session) pinned() (process, error) {
+	s.plugin.mu.Lock()
+	defer s.plugin.mu.Unlock()
+
+	if s.plugin.closed {
+		return process{}, ErrClosed
+	}
+	if s.plugin.client != s.proc.client || s.proc.client.Exited() {
+		return process{}, ErrSessionLost
+	}
+	return s.proc, nil
+}
+
+// Execute runs a resource of the plugin in the session
+func (s *Session) Execute(ctx context.Context, req *sdk.ExecuteRequest) (*sdk.ExecuteResponse, error) {
+	req, err := s.plugin.prepare(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.plugin.limit(ctx, req); err != nil {
+		return nil, err
+	}
+	proc, err := s.pinned()
+	if err != nil {
+		return nil, err
+	}
+
+	pinned := *req
+	pinned.SessionID = s.ID
+	resp, err := proc.resource.Execute(s.plugin.logContext(s.plugin.accept(ctx)), &pinned)
+	if err != nil {
+		err = s.plugin.crashError(proc, err)
+		var crash *CrashError
+		if errors.As(err, &crash) {
+			return nil, fmt.Errorf("%w: %w", ErrSessionLost, err)
+		}
+		return nil, err
+	}
+	return s.plugin.validateOutput(ctx, req, resp), nil
+}
+
+// Close closes the session in the plugin. Sessions of exited plugin
+// processes are gone already and closed without error.
+func (s *Session) Close(ctx context.Context) error {
+	proc, err := s.pinned()
+	if err != nil {
+		return nil
+	}
+	return proc.resource.(sdk.SessionClient).CloseSession(ctx, s.ID)
+}
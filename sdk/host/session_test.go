@@ -0,0 +1,70 @@
+package host
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maschine.io/plugin-sdk/sdk"
+)
+
+func TestSession(t *testing.T) {
+	p := launchTestPlugin(t, Config{})
+	ctx := context.Background()
+
+	s, err := p.OpenSession(ctx, &sdk.OpenSessionRequest{})
+	require.NoError(t, err)
+
+	req := &sdk.ExecuteRequest{
+		Resource:   "mrn:test:resource:action",
+		Parameters: map[string][]byte{"param1": []byte("session")},
+	}
+	resp, err := s.Execute(ctx, req)
+	require.NoError(t, err)
+	assert.JSONEq(t, `"`+s.ID+`"`, string(resp.Output))
+	assert.Empty(t, req.SessionID, "the request of the caller is not modified")
+
+	require.NoError(t, s.Close(ctx))
+	_, err = s.Execute(ctx, req)
+	assert.ErrorIs(t, err, sdk.ErrSessionNotFound)
+}
+
+func TestSessionPinnedToProcess(t *testing.T) {
+	p := launchTestPlugin(t, Config{})
+	ctx := context.Background()
+
+	s, err := p.OpenSession(ctx, &sdk.OpenSessionRequest{})
+	require.NoError(t, err)
+
+	_, err = s.Execute(ctx, &sdk.ExecuteRequest{
+		Resource:   "mrn:test:resource:action",
+		Parameters: map[string][]byte{"param1": []byte("crash")},
+	})
+	assert.ErrorIs(t, err, ErrSessionLost)
+	var crash *CrashError
+	assert.True(t, errors.As(err, &crash))
+
+	// the plugin is restarted, but the session is not moved to the new process
+	_, err = p.Execute(ctx, &sdk.ExecuteRequest{
+		Resource:   "mrn:test:resource:action",
+		Parameters: map[string][]byte{"param1": []byte("ok")},
+	})
+	require.NoError(t, err)
+
+	_, err = s.Execute(ctx, &sdk.ExecuteRequest{
+		Resource:   "mrn:test:resource:action",
+		Parameters: map[string][]byte{"param1": []byte("session")},
+	})
+	assert.ErrorIs(t, err, ErrSessionLost)
+	assert.NoError(t, s.Close(ctx))
+}
+
+func TestOpenSessionStateless(t *testing.T) {
+	m := testManifest()
+	m.Capabilities.Stateless = true
+
+	_, err := (&Plugin{manifest: m}).OpenSession(context.Background(), &sdk.OpenSessionRequest{})
+	assert.ErrorIs(t, err, sdk.ErrSessionsNotSupported)
+}
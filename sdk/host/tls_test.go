@@ -0,0 +1,208 @@
+package host
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	pluginv1 "maschine.io/plugin-sdk/proto/plugin/v1"
+	"maschine.io/plugin-sdk/sdk"
+)
+
+// testCA issues certificates for the TLS tests
+type testCA struct {
+	dir  string
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	file string
+}
+
+func newTestCA(t *testing.T, name string) *testCA {
+	t.Helper()
+
+	ca := &testCA{dir: t.TempDir()}
+	ca.cert, ca.key = createCertificate(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: name},
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}, nil, nil)
+	ca.file = writePEM(t, ca.dir, name+"-ca.pem", "CERTIFICATE", ca.cert.Raw)
+	return ca
+}
+
+// issue returns the files of a certificate for localhost signed by the CA
+func (ca *testCA) issue(t *testing.T, name string) sdk.TLSFiles {
+	t.Helper()
+
+	cert, key := createCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: name},
+		DNSNames:    []string{"localhost"},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}, ca.cert, ca.key)
+
+	der, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	return sdk.TLSFiles{
+		CAFile:   ca.file,
+		CertFile: writePEM(t, ca.dir, name+".pem", "CERTIFICATE", cert.Raw),
+		KeyFile:  writePEM(t, ca.dir, name+"-key.pem", "EC PRIVATE KEY", der),
+	}
+}
+
+// createCertificate signs template with parent, or self-signs it if parent
+// is nil
+func createCertificate(t *testing.T, template, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+
+	template.SerialNumber = serial
+	template.NotBefore = time.Now().Add(-time.Minute)
+	template.NotAfter = time.Now().Add(time.Hour)
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+func writePEM(t *testing.T, dir, name, blockType string, der []byte) string {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+	return path
+}
+
+// dialPlugin connects to the plugin process directly, bypassing the host,
+// and calls GetMetadata
+func dialPlugin(t *testing.T, p *Plugin, creds credentials.TransportCredentials) error {
+	t.Helper()
+
+	addr := p.client.ReattachConfig().Addr
+	conn, err := grpc.NewClient("passthrough:///plugin",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, addr.Network(), addr.String())
+		}),
+		grpc.WithTransportCredentials(creds),
+	)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_, err = pluginv1.NewPluginClient(conn).GetMetadata(ctx, &pluginv1.GetMetadataRequest{})
+	return err
+}
+
+func TestAutoMTLS(t *testing.T) {
+	p := launchTestPlugin(t, Config{})
+
+	_, err := p.Execute(context.Background(), &sdk.ExecuteRequest{
+		Resource:   "mrn:test:resource:action",
+		Parameters: map[string][]byte{"param1": []byte("ok")},
+	})
+	require.NoError(t, err)
+
+	t.Run("rejects plaintext clients", func(t *testing.T) {
+		assert.Error(t, dialPlugin(t, p, insecure.NewCredentials()))
+	})
+
+	t.Run("rejects clients with another certificate", func(t *testing.T) {
+		files := newTestCA(t, "intruder").issue(t, "intruder")
+		cert, err := tls.LoadX509KeyPair(files.CertFile, files.KeyFile)
+		require.NoError(t, err)
+
+		assert.Error(t, dialPlugin(t, p, credentials.NewTLS(&tls.Config{
+			Certificates:       []tls.Certificate{cert},
+			InsecureSkipVerify: true,
+		})))
+	})
+}
+
+func TestOperatorTLS(t *testing.T) {
+	ca := newTestCA(t, "operator")
+	p := launchTestPlugin(t, Config{TLS: &TLSConfig{
+		Host:   ca.issue(t, "host"),
+		Plugin: ca.issue(t, "plugin"),
+	}})
+
+	resp, err := p.Execute(context.Background(), &sdk.ExecuteRequest{
+		Resource:   "mrn:test:resource:action",
+		Parameters: map[string][]byte{"param1": []byte("ok")},
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `"ok"`, string(resp.Output))
+
+	t.Run("accepts clients signed by the CA", func(t *testing.T) {
+		tlsConfig, err := ca.issue(t, "operator-client").ClientConfig()
+		require.NoError(t, err)
+		assert.NoError(t, dialPlugin(t, p, credentials.NewTLS(tlsConfig)))
+	})
+
+	t.Run("rejects clients without certificate", func(t *testing.T) {
+		pool := x509.NewCertPool()
+		pool.AddCert(ca.cert)
+		assert.Error(t, dialPlugin(t, p, credentials.NewTLS(&tls.Config{
+			RootCAs:    pool,
+			ServerName: sdk.DefaultTLSServerName,
+		})))
+	})
+
+	t.Run("rejects clients signed by another CA", func(t *testing.T) {
+		files := newTestCA(t, "rogue").issue(t, "rogue")
+		files.CAFile = ca.file
+		tlsConfig, err := files.ClientConfig()
+		require.NoError(t, err)
+		assert.Error(t, dialPlugin(t, p, credentials.NewTLS(tlsConfig)))
+	})
+}
+
+func TestOperatorTLSUntrustedPlugin(t *testing.T) {
+	t.Setenv(envTestPlugin, "1")
+
+	ca := newTestCA(t, "operator")
+	_, err := Launch(context.Background(), Config{
+		Path:     os.Args[0],
+		Manifest: testManifest(),
+		TLS: &TLSConfig{
+			Host:   ca.issue(t, "host"),
+			Plugin: newTestCA(t, "rogue").issue(t, "plugin"),
+		},
+	})
+	assert.Error(t, err)
+}
+
+func TestOperatorTLSMissingFiles(t *testing.T) {
+	_, err := Launch(context.Background(), Config{
+		Path:     os.Args[0],
+		Manifest: testManifest(),
+		TLS:      &TLSConfig{},
+	})
+	assert.ErrorContains(t, err, "failed to load host certificate")
+}
@@ -0,0 +1,248 @@
+package sdk
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultIdempotencyWindow is the default for IdempotencyConfig.Window
+const DefaultIdempotencyWindow = time.Hour
+
+// IdempotencyStore keeps the responses of completed executions by their
+// idempotency key
+type IdempotencyStore interface {
+	// Get returns the response stored for key. It returns false if there is
+	// none or it expired.
+	Get(key string) (*ExecuteResponse, bool, error)
+	// Put stores the response for key until ttl passed. It keeps a copy,
+	// resp is returned to the caller and may be changed.
+	Put(key string, resp *ExecuteResponse, ttl time.Duration) error
+}
+
+// IdempotencyConfig configures WithIdempotency
+type IdempotencyConfig struct {
+	// Window is how long responses are kept for retries. Defaults to
+	// DefaultIdempotencyWindow.
+	Window time.Duration
+	// Store keeps the responses. Defaults to a MemoryStore.
+	Store IdempotencyStore
+}
+
+// WithIdempotency wraps impl so that executions with an idempotency key run
+// at most once per key and window. Retries get the response of the first
+// execution; a retry that arrives while the first execution is still
+// running waits for it. Only successful executions are kept; a retry of a
+// failed execution runs it again. Executions without key and dry runs are
+// passed through. Keys are scoped to the resource.
+func WithIdempotency(impl MaschineResource, cfg IdempotencyConfig) MaschineResource {
+	if cfg.Window <= 0 {
+		cfg.Window = DefaultIdempotencyWindow
+	}
+	if cfg.Store == nil {
+		cfg.Store = NewMemoryStore()
+	}
+	return &idempotentResource{
+		MaschineResource: impl,
+		config:           cfg,
+		running:          make(map[string]*idempotentCall),
+	}
+}
+
+type idempotentResource struct {
+	MaschineResource
+	config IdempotencyConfig
+
+	mu      sync.Mutex
+	running map[string]*idempotentCall
+}
+
+// idempotentCall is a running execution that retries wait for
+type idempotentCall struct {
+	done chan struct{}
+	resp *ExecuteResponse
+	err  error
+}
+
+// Unwrap returns the wrapped resource
+func (r *idempotentResource) Unwrap() MaschineResource {
+	return r.MaschineResource
+}
+
+func (r *idempotentResource) Execute(ctx context.Context, req *ExecuteRequest) (*ExecuteResponse, error) {
+	if req.IdempotencyKey == "" || req.DryRun {
+		return r.MaschineResource.Execute(ctx, req)
+	}
+	key := req.Resource + "\x00" + req.IdempotencyKey
+
+	r.mu.Lock()
+	if call, found := r.running[key]; found {
+		r.mu.Unlock()
+		select {
+		case <-call.done:
+			return call.resp.Clone(), call.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	resp, found, err := r.config.Store.Get(key)
+	if err != nil || found {
+		r.mu.Unlock()
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up idempotency key: %w", err)
+		}
+		return resp.Clone(), nil
+	}
+
+	call := &idempotentCall{done: make(chan struct{})}
+	r.running[key] = call
+	r.mu.Unlock()
+
+	defer func() {
+		r.mu.Lock()
+		delete(r.running, key)
+		r.mu.Unlock()
+		close(call.done)
+	}()
+
+	// a panic is reported to waiting retries before it is recovered by the
+	// gRPC server
+	call.err = errors.New("execution with the same idempotency key failed")
+	call.resp, call.err = r.MaschineResource.Execute(ctx, req)
+	if call.err != nil {
+		return nil, call.err
+	}
+	if call.resp == nil || call.resp.Error != "" {
+		// failures, transient or not, free the key for the next attempt
+		return call.resp.Clone(), nil
+	}
+	if err := r.config.Store.Put(key, call.resp, r.config.Window); err != nil {
+		return nil, fmt.Errorf("failed to store response for idempotency key: %w", err)
+	}
+	// waiting retries get their own copies, the caller may change its
+	// response, e.g. to redact it
+	return call.resp.Clone(), nil
+}
+
+// MemoryStore is an IdempotencyStore in memory. Responses are lost when the
+// plugin process exits.
+type MemoryStore struct {
+	mu        sync.Mutex
+	responses map[string]storedResponse
+}
+
+type storedResponse struct {
+	Response *ExecuteResponse `json:"response"`
+	Expires  time.Time        `json:"expires"`
+}
+
+// NewMemoryStore creates an empty MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{responses: make(map[string]storedResponse)}
+}
+
+// Get returns a copy of the response stored for key
+func (s *MemoryStore) Get(key string) (*ExecuteResponse, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, found := s.responses[key]
+	if !found || time.Now().After(r.Expires) {
+		return nil, false, nil
+	}
+	return r.Response.Clone(), true, nil
+}
+
+// Put stores a copy of the response for key. Expired responses are removed.
+func (s *MemoryStore) Put(key string, resp *ExecuteResponse, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for k, r := range s.responses {
+		if now.After(r.Expires) {
+			delete(s.responses, k)
+		}
+	}
+	s.responses[key] = storedResponse{Response: resp.Clone(), Expires: now.Add(ttl)}
+	return nil
+}
+
+// FileStore is an IdempotencyStore that keeps every response in a JSON file
+// in a directory, so retries are recognized across restarts of the plugin
+// process
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore creates a FileStore in dir. The directory is created if it
+// does not exist.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create idempotency store: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// path returns the file of key. Keys are hashed, so they can contain any
+// character.
+func (s *FileStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Get returns the response stored for key. An expired response is removed.
+func (s *FileStore) Get(key string) (*ExecuteResponse, bool, error) {
+	path := s.path(key)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	var r storedResponse
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, false, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	if time.Now().After(r.Expires) {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, false, err
+		}
+		return nil, false, nil
+	}
+	return r.Response, true, nil
+}
+
+// Put stores the response for key. The file is replaced atomically, so a
+// crash never leaves a partial response.
+func (s *FileStore) Put(key string, resp *ExecuteResponse, ttl time.Duration) error {
+	data, err := json.Marshal(storedResponse{Response: resp, Expires: time.Now().Add(ttl)})
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(s.dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), s.path(key))
+}
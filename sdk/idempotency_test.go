@@ -0,0 +1,213 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mailerPlugin counts the mails it sends. Sending blocks until release is
+// closed if it is set.
+type mailerPlugin struct {
+	*BasePlugin
+	sent    atomic.Int32
+	started chan struct{}
+	release chan struct{}
+}
+
+func newMailerPlugin(t *testing.T) *mailerPlugin {
+	t.Helper()
+
+	p := &mailerPlugin{BasePlugin: NewBasePlugin("mailer-plugin", "1.0.0")}
+	require.NoError(t, p.RegisterSimpleFunction("mrn:mailer:mail:send", func(ctx context.Context, req *TypedExecuteRequest) (any, error) {
+		if string(req.Input) == "panic" {
+			panic("mailbox full")
+		}
+		n := p.sent.Add(1)
+		if p.release != nil {
+			p.started <- struct{}{}
+			<-p.release
+		}
+		return n, nil
+	}, "Send a mail"))
+	return p
+}
+
+func TestIdempotency(t *testing.T) {
+	impl := newMailerPlugin(t)
+	res := WithIdempotency(impl, IdempotencyConfig{})
+	ctx := context.Background()
+
+	send := func(resource, key string) string {
+		resp, err := res.Execute(ctx, &ExecuteRequest{Resource: resource, IdempotencyKey: key})
+		require.NoError(t, err)
+		return string(resp.Output) + resp.Error
+	}
+
+	assert.Equal(t, "1", send("mrn:mailer:mail:send", "mail-1"))
+	assert.Equal(t, "1", send("mrn:mailer:mail:send", "mail-1"), "retries get the first response")
+	assert.Equal(t, "2", send("mrn:mailer:mail:send", "mail-2"))
+	assert.Equal(t, "3", send("mrn:mailer:mail:send", ""), "executions without key are not deduplicated")
+	assert.Equal(t, "4", send("mrn:mailer:mail:send", ""))
+	assert.Equal(t, "unknown resource: mrn:mailer:mail:other", send("mrn:mailer:mail:other", "mail-1"), "keys are scoped to the resource")
+	assert.Equal(t, int32(4), impl.sent.Load())
+}
+
+func TestIdempotencyRetryWaits(t *testing.T) {
+	impl := newMailerPlugin(t)
+	impl.started = make(chan struct{}, 1)
+	impl.release = make(chan struct{})
+	client := dispenseTestClient(t, WithIdempotency(impl, IdempotencyConfig{}))
+
+	var wg sync.WaitGroup
+	outputs := make([]string, 3)
+	send := func(i int) {
+		defer wg.Done()
+		resp, err := client.Execute(context.Background(), &ExecuteRequest{Resource: "mrn:mailer:mail:send", IdempotencyKey: "mail-1"})
+		assert.NoError(t, err)
+		outputs[i] = string(resp.Output)
+	}
+
+	wg.Add(1)
+	go send(0)
+	<-impl.started
+
+	// retries while the first execution is still running
+	wg.Add(2)
+	go send(1)
+	go send(2)
+	time.Sleep(50 * time.Millisecond)
+	close(impl.release)
+	wg.Wait()
+
+	assert.Equal(t, []string{"1", "1", "1"}, outputs)
+	assert.Equal(t, int32(1), impl.sent.Load())
+}
+
+// staticResource answers every execution with resp
+type staticResource struct {
+	*BasePlugin
+	resp *ExecuteResponse
+}
+
+func (r *staticResource) Execute(ctx context.Context, req *ExecuteRequest) (*ExecuteResponse, error) {
+	return r.resp, nil
+}
+
+func TestIdempotencyResponseCopies(t *testing.T) {
+	res := WithIdempotency(&staticResource{
+		BasePlugin: NewBasePlugin("mailer-plugin", "1.0.0"),
+		resp:       &ExecuteResponse{Output: []byte(`"sent"`), Metadata: map[string]string{"server": "smtp.example.com"}},
+	}, IdempotencyConfig{})
+	req := &ExecuteRequest{Resource: "mrn:mailer:mail:send", IdempotencyKey: "mail-1"}
+
+	for range 3 {
+		resp, err := res.Execute(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, `"sent"`, string(resp.Output))
+		assert.Equal(t, "smtp.example.com", resp.Metadata["server"])
+
+		// callers like WithSecretRedaction change their response in place
+		resp.Output[1] = 'X'
+		resp.Metadata["server"] = Redacted
+	}
+}
+
+func TestIdempotencyPanic(t *testing.T) {
+	impl := newMailerPlugin(t)
+	res := WithIdempotency(impl, IdempotencyConfig{})
+	req := &ExecuteRequest{Resource: "mrn:mailer:mail:send", Input: []byte("panic"), IdempotencyKey: "mail-1"}
+
+	assert.PanicsWithValue(t, "mailbox full", func() {
+		res.Execute(context.Background(), req)
+	})
+
+	// the key is released, the execution is not cached
+	req.Input = nil
+	resp, err := res.Execute(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "1", string(resp.Output))
+}
+
+func TestIdempotencyFailure(t *testing.T) {
+	p := NewBasePlugin("mailer-plugin", "1.0.0")
+	var calls atomic.Int32
+	require.NoError(t, p.RegisterSimpleFunction("mrn:mailer:mail:send", func(ctx context.Context, req *TypedExecuteRequest) (any, error) {
+		if calls.Add(1) == 1 {
+			return nil, WithErrorType(errors.New("smtp down"), ErrorTypeUnavailable)
+		}
+		return "sent", nil
+	}, "Send a mail"))
+	res := WithIdempotency(p, IdempotencyConfig{})
+	req := &ExecuteRequest{Resource: "mrn:mailer:mail:send", IdempotencyKey: "mail-1"}
+
+	resp, err := res.Execute(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "smtp down", resp.Error)
+
+	// the failure is not cached, the retry runs the execution again
+	resp, err = res.Execute(ContextWithAttempt(context.Background(), 2), req)
+	require.NoError(t, err)
+	assert.Empty(t, resp.Error)
+	assert.Equal(t, `"sent"`, string(resp.Output))
+
+	resp, err = res.Execute(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, `"sent"`, string(resp.Output))
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestIdempotencyWindow(t *testing.T) {
+	impl := newMailerPlugin(t)
+	res := WithIdempotency(impl, IdempotencyConfig{Window: 20 * time.Millisecond})
+	req := &ExecuteRequest{Resource: "mrn:mailer:mail:send", IdempotencyKey: "mail-1"}
+
+	for range 2 {
+		_, err := res.Execute(context.Background(), req)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), impl.sent.Load())
+
+	time.Sleep(30 * time.Millisecond)
+	resp, err := res.Execute(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "2", string(resp.Output))
+}
+
+func TestFileStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileStore(dir)
+	require.NoError(t, err)
+
+	resp := &ExecuteResponse{Output: []byte(`"sent"`), Metadata: map[string]string{"message_id": "42"}}
+	require.NoError(t, store.Put("mrn:mailer:mail:send\x00mail/1", resp, time.Hour))
+	require.NoError(t, store.Put("mrn:mailer:mail:send\x00mail/2", resp, -time.Second))
+
+	// a new store in the same directory, like after a restart
+	store, err = NewFileStore(dir)
+	require.NoError(t, err)
+
+	got, found, err := store.Get("mrn:mailer:mail:send\x00mail/1")
+	require.NoError(t, err)
+	require.True(t, found)
+	assert.Equal(t, resp, got)
+
+	_, found, err = store.Get("mrn:mailer:mail:send\x00mail/2")
+	require.NoError(t, err)
+	assert.False(t, found, "expired")
+
+	_, found, err = store.Get("mrn:mailer:mail:send\x00mail/3")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	files, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 1, "expired responses and temporary files are removed")
+}
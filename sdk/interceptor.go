@@ -0,0 +1,461 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// ServerInterceptors are gRPC interceptors of the plugin server. Install
+// them with the options of ServerOptions in plugin.ServeConfig.GRPCServer:
+//
+//	GRPCServer: sdk.GRPCServer(interceptors.ServerOptions()...)
+type ServerInterceptors struct {
+	Unary  []grpc.UnaryServerInterceptor
+	Stream []grpc.StreamServerInterceptor
+}
+
+// Append returns the interceptors of i followed by the interceptors of
+// others
+func (i ServerInterceptors) Append(others ...ServerInterceptors) ServerInterceptors {
+	result := ServerInterceptors{
+		Unary:  append([]grpc.UnaryServerInterceptor(nil), i.Unary...),
+		Stream: append([]grpc.StreamServerInterceptor(nil), i.Stream...),
+	}
+	for _, o := range others {
+		result.Unary = append(result.Unary, o.Unary...)
+		result.Stream = append(result.Stream, o.Stream...)
+	}
+	return result
+}
+
+// ServerOptions returns the gRPC server options that install the
+// interceptors
+func (i ServerInterceptors) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(i.Unary...),
+		grpc.ChainStreamInterceptor(i.Stream...),
+	}
+}
+
+// ClientInterceptors are gRPC interceptors of the host client. Hosts using
+// the host package set them in host.Config.Interceptors, other hosts use
+// the options of DialOptions in plugin.ClientConfig.GRPCDialOptions.
+type ClientInterceptors struct {
+	Unary  []grpc.UnaryClientInterceptor
+	Stream []grpc.StreamClientInterceptor
+}
+
+// Append returns the interceptors of i followed by the interceptors of
+// others
+func (i ClientInterceptors) Append(others ...ClientInterceptors) ClientInterceptors {
+	result := ClientInterceptors{
+		Unary:  append([]grpc.UnaryClientInterceptor(nil), i.Unary...),
+		Stream: append([]grpc.StreamClientInterceptor(nil), i.Stream...),
+	}
+	for _, o := range others {
+		result.Unary = append(result.Unary, o.Unary...)
+		result.Stream = append(result.Stream, o.Stream...)
+	}
+	return result
+}
+
+// DialOptions returns the gRPC dial options that install the interceptors
+func (i ClientInterceptors) DialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(i.Unary...),
+		grpc.WithChainStreamInterceptor(i.Stream...),
+	}
+}
+
+// GRPCServer returns a constructor for plugin.ServeConfig.GRPCServer that
+// adds opts to the options of go-plugin. The standard gRPC health service
+// of the server reports the health of the plugin.
+func GRPCServer(opts ...grpc.ServerOption) func([]grpc.ServerOption) *grpc.Server {
+	return func(pluginOpts []grpc.ServerOption) *grpc.Server {
+		target := &healthTarget{}
+		opts := append(append(pluginOpts, opts...), target.interceptors().ServerOptions()...)
+		s := grpc.NewServer(opts...)
+		healthTargets.Store(s, target)
+		return s
+	}
+}
+
+// ServerLogging logs every call of the plugin server with its duration.
+// Failed calls are logged as warnings, all others as debug messages.
+func ServerLogging(log hclog.Logger) ServerInterceptors {
+	return ServerInterceptors{
+		Unary: []grpc.UnaryServerInterceptor{
+			func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+				start := time.Now()
+				resp, err := handler(ctx, req)
+				logCall(log, info.FullMethod, start, err)
+				return resp, err
+			},
+		},
+		Stream: []grpc.StreamServerInterceptor{
+			func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+				start := time.Now()
+				err := handler(srv, ss)
+				logCall(log, info.FullMethod, start, err)
+				return err
+			},
+		},
+	}
+}
+
+// ClientLogging logs every call of the host client with its duration.
+// Failed calls are logged as warnings, all others as debug messages.
+func ClientLogging(log hclog.Logger) ClientInterceptors {
+	return ClientInterceptors{
+		Unary: []grpc.UnaryClientInterceptor{
+			func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+				start := time.Now()
+				err := invoker(ctx, method, req, reply, cc, opts...)
+				logCall(log, method, start, err)
+				return err
+			},
+		},
+		Stream: []grpc.StreamClientInterceptor{
+			func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+				// The stream is logged when it is opened, its end is not
+				// observed
+				start := time.Now()
+				cs, err := streamer(ctx, desc, cc, method, opts...)
+				logCall(log, method, start, err)
+				return cs, err
+			},
+		},
+	}
+}
+
+func logCall(log hclog.Logger, method string, start time.Time, err error) {
+	args := []any{"method", method, "code", status.Code(err).String(), "duration", time.Since(start)}
+	if err != nil {
+		log.Warn("grpc call failed", append(args, "error", err)...)
+		return
+	}
+	log.Debug("grpc call", args...)
+}
+
+// LatencyRecorder receives the latency of every call
+type LatencyRecorder interface {
+	ObserveLatency(method string, code codes.Code, d time.Duration)
+}
+
+// ServerMetrics reports the latency of every call of the plugin server to
+// rec
+func ServerMetrics(rec LatencyRecorder) ServerInterceptors {
+	return ServerInterceptors{
+		Unary: []grpc.UnaryServerInterceptor{
+			func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+				start := time.Now()
+				resp, err := handler(ctx, req)
+				rec.ObserveLatency(info.FullMethod, status.Code(err), time.Since(start))
+				return resp, err
+			},
+		},
+		Stream: []grpc.StreamServerInterceptor{
+			func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+				start := time.Now()
+				err := handler(srv, ss)
+				rec.ObserveLatency(info.FullMethod, status.Code(err), time.Since(start))
+				return err
+			},
+		},
+	}
+}
+
+// ClientMetrics reports the latency of every unary call of the host client
+// to rec. Streams are reported when they are opened.
+func ClientMetrics(rec LatencyRecorder) ClientInterceptors {
+	return ClientInterceptors{
+		Unary: []grpc.UnaryClientInterceptor{
+			func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+				start := time.Now()
+				err := invoker(ctx, method, req, reply, cc, opts...)
+				rec.ObserveLatency(method, status.Code(err), time.Since(start))
+				return err
+			},
+		},
+		Stream: []grpc.StreamClientInterceptor{
+			func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+				start := time.Now()
+				cs, err := streamer(ctx, desc, cc, method, opts...)
+				rec.ObserveLatency(method, status.Code(err), time.Since(start))
+				return cs, err
+			},
+		},
+	}
+}
+
+// LatencyStats is an in-memory LatencyRecorder
+type LatencyStats struct {
+	mu      sync.Mutex
+	methods map[string]*MethodLatency
+}
+
+// MethodLatency summarizes the latencies of a method
+type MethodLatency struct {
+	Count  int
+	Errors int
+	Total  time.Duration
+	Max    time.Duration
+}
+
+// String returns a short description of the latency, e.g. for logs
+func (m MethodLatency) String() string {
+	if m.Count == 0 {
+		return "no calls"
+	}
+	return fmt.Sprintf("%d calls, %d errors, avg %s, max %s", m.Count, m.Errors, m.Total/time.Duration(m.Count), m.Max)
+}
+
+// ObserveLatency implements LatencyRecorder
+func (s *LatencyStats) ObserveLatency(method string, code codes.Code, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.methods == nil {
+		s.methods = make(map[string]*MethodLatency)
+	}
+	m, found := s.methods[method]
+	if !found {
+		m = &MethodLatency{}
+		s.methods[method] = m
+	}
+	m.Count++
+	if code != codes.OK {
+		m.Errors++
+	}
+	m.Total += d
+	if d > m.Max {
+		m.Max = d
+	}
+}
+
+// Snapshot returns a copy of the latencies per method
+func (s *LatencyStats) Snapshot() map[string]MethodLatency {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := make(map[string]MethodLatency, len(s.methods))
+	for method, m := range s.methods {
+		snapshot[method] = *m
+	}
+	return snapshot
+}
+
+// ServerPayloadLimit rejects requests and responses of the plugin server
+// above maxSize bytes with codes.ResourceExhausted. For streams, every
+// message is checked.
+func ServerPayloadLimit(maxSize int) ServerInterceptors {
+	return ServerInterceptors{
+		Unary: []grpc.UnaryServerInterceptor{
+			func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+				if err := checkPayloadSize("request", req, maxSize); err != nil {
+					return nil, err
+				}
+				resp, err := handler(ctx, req)
+				if err != nil {
+					return nil, err
+				}
+				if err := checkPayloadSize("response", resp, maxSize); err != nil {
+					return nil, err
+				}
+				return resp, nil
+			},
+		},
+		Stream: []grpc.StreamServerInterceptor{
+			func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+				return handler(srv, &limitedServerStream{ServerStream: ss, maxSize: maxSize})
+			},
+		},
+	}
+}
+
+// ClientPayloadLimit rejects requests and responses of the host client
+// above maxSize bytes with codes.ResourceExhausted. For streams, every
+// message is checked.
+func ClientPayloadLimit(maxSize int) ClientInterceptors {
+	return ClientInterceptors{
+		Unary: []grpc.UnaryClientInterceptor{
+			func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+				if err := checkPayloadSize("request", req, maxSize); err != nil {
+					return err
+				}
+				if err := invoker(ctx, method, req, reply, cc, opts...); err != nil {
+					return err
+				}
+				return checkPayloadSize("response", reply, maxSize)
+			},
+		},
+		Stream: []grpc.StreamClientInterceptor{
+			func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+				cs, err := streamer(ctx, desc, cc, method, opts...)
+				if err != nil {
+					return nil, err
+				}
+				return &limitedClientStream{ClientStream: cs, maxSize: maxSize}, nil
+			},
+		},
+	}
+}
+
+func checkPayloadSize(kind string, msg any, maxSize int) error {
+	m, ok := msg.(proto.Message)
+	if !ok {
+		return nil
+	}
+	if size := proto.Size(m); size > maxSize {
+		return status.Errorf(codes.ResourceExhausted, "%s of %d bytes exceeds limit of %d bytes", kind, size, maxSize)
+	}
+	return nil
+}
+
+type limitedServerStream struct {
+	grpc.ServerStream
+	maxSize int
+}
+
+func (s *limitedServerStream) SendMsg(m any) error {
+	if err := checkPayloadSize("response", m, s.maxSize); err != nil {
+		return err
+	}
+	return s.ServerStream.SendMsg(m)
+}
+
+func (s *limitedServerStream) RecvMsg(m any) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return checkPayloadSize("request", m, s.maxSize)
+}
+
+type limitedClientStream struct {
+	grpc.ClientStream
+	maxSize int
+}
+
+func (s *limitedClientStream) SendMsg(m any) error {
+	if err := checkPayloadSize("request", m, s.maxSize); err != nil {
+		return err
+	}
+	return s.ClientStream.SendMsg(m)
+}
+
+func (s *limitedClientStream) RecvMsg(m any) error {
+	if err := s.ClientStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return checkPayloadSize("response", m, s.maxSize)
+}
+
+// metadataPrefix marks the gRPC metadata propagated by ClientMetadata
+const metadataPrefix = "x-maschine-"
+
+type metadataContextKey struct{}
+
+// ContextWithMetadata returns a copy of ctx carrying md, for example the ID
+// of the state machine execution. ClientMetadata sends it to the plugin,
+// where ServerMetadata makes it available with MetadataFromContext. Keys
+// are case-insensitive and returned in lower case.
+func ContextWithMetadata(ctx context.Context, md map[string]string) context.Context {
+	merged := make(map[string]string, len(md))
+	for k, v := range MetadataFromContext(ctx) {
+		merged[k] = v
+	}
+	for k, v := range md {
+		merged[strings.ToLower(k)] = v
+	}
+	return context.WithValue(ctx, metadataContextKey{}, merged)
+}
+
+// MetadataFromContext returns the metadata added with ContextWithMetadata
+func MetadataFromContext(ctx context.Context) map[string]string {
+	md, _ := ctx.Value(metadataContextKey{}).(map[string]string)
+	return md
+}
+
+// ServerMetadata makes the metadata propagated by ClientMetadata available
+// to the plugin with MetadataFromContext
+func ServerMetadata() ServerInterceptors {
+	return ServerInterceptors{
+		Unary: []grpc.UnaryServerInterceptor{
+			func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+				return handler(incomingMetadata(ctx), req)
+			},
+		},
+		Stream: []grpc.StreamServerInterceptor{
+			func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+				return handler(srv, &metadataServerStream{ServerStream: ss, ctx: incomingMetadata(ss.Context())})
+			},
+		},
+	}
+}
+
+// ClientMetadata sends the metadata added with ContextWithMetadata to the
+// plugin
+func ClientMetadata() ClientInterceptors {
+	return ClientInterceptors{
+		Unary: []grpc.UnaryClientInterceptor{
+			func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+				return invoker(outgoingMetadata(ctx), method, req, reply, cc, opts...)
+			},
+		},
+		Stream: []grpc.StreamClientInterceptor{
+			func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+				return streamer(outgoingMetadata(ctx), desc, cc, method, opts...)
+			},
+		},
+	}
+}
+
+func outgoingMetadata(ctx context.Context) context.Context {
+	md := MetadataFromContext(ctx)
+	if len(md) == 0 {
+		return ctx
+	}
+
+	pairs := make([]string, 0, 2*len(md))
+	for _, k := range sortedKeys(md) {
+		pairs = append(pairs, metadataPrefix+k, md[k])
+	}
+	return metadata.AppendToOutgoingContext(ctx, pairs...)
+}
+
+func incomingMetadata(ctx context.Context) context.Context {
+	in, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+
+	md := make(map[string]string)
+	for k, values := range in {
+		if name, found := strings.CutPrefix(k, metadataPrefix); found && len(values) > 0 {
+			md[name] = values[len(values)-1]
+		}
+	}
+	if len(md) == 0 {
+		return ctx
+	}
+	return ContextWithMetadata(ctx, md)
+}
+
+type metadataServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *metadataServerStream) Context() context.Context {
+	return s.ctx
+}
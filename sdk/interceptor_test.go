@@ -0,0 +1,157 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	pluginv1 "maschine.io/plugin-sdk/proto/plugin/v1"
+)
+
+// bufconnClient serves impl with the server interceptors over an in-memory
+// connection and returns a client using the client interceptors
+func bufconnClient(t *testing.T, impl MaschineResource, transfer TransferConfig, server ServerInterceptors, client ClientInterceptors) *grpcClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1024 * 1024)
+	s := grpc.NewServer(append(transfer.ServerOptions(), server.ServerOptions()...)...)
+	pluginv1.RegisterPluginServer(s, newGRPCServer(impl, transfer))
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	opts := append([]grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}, transfer.DialOptions()...)
+	conn, err := grpc.NewClient("passthrough:///bufconn", append(opts, client.DialOptions()...)...)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return &grpcClient{client: pluginv1.NewPluginClient(conn), transfer: transfer.withDefaults()}
+}
+
+func TestInterceptorLogging(t *testing.T) {
+	var serverLog, clientLog bytes.Buffer
+	client := bufconnClient(t, newEchoPlugin(t), TransferConfig{},
+		ServerLogging(hclog.New(&hclog.LoggerOptions{Output: &serverLog, Level: hclog.Debug})),
+		ClientLogging(hclog.New(&hclog.LoggerOptions{Output: &clientLog, Level: hclog.Debug})),
+	)
+
+	_, err := client.Execute(context.Background(), &ExecuteRequest{Resource: "mrn:echo:payload:copy"})
+	require.NoError(t, err)
+	require.NoError(t, client.Configure(context.Background(), &ConfigureRequest{}))
+
+	assert.Contains(t, serverLog.String(), "method=/maschine.plugin.v1.Plugin/Execute code=OK")
+	assert.Contains(t, clientLog.String(), "method=/maschine.plugin.v1.Plugin/Execute code=OK")
+
+	_, err = client.OpenSession(context.Background(), &OpenSessionRequest{})
+	assert.ErrorIs(t, err, ErrSessionsNotSupported)
+	assert.Contains(t, serverLog.String(), "[WARN]  grpc call failed: method=/maschine.plugin.v1.Plugin/OpenSession code=Unimplemented")
+}
+
+func TestInterceptorMetrics(t *testing.T) {
+	var serverStats, clientStats LatencyStats
+	client := bufconnClient(t, newEchoPlugin(t), TransferConfig{Threshold: 1024, ChunkSize: 100},
+		ServerMetrics(&serverStats),
+		ClientMetrics(&clientStats),
+	)
+
+	for range 3 {
+		_, err := client.Execute(context.Background(), &ExecuteRequest{Resource: "mrn:echo:payload:copy"})
+		require.NoError(t, err)
+	}
+	_, err := client.Execute(context.Background(), &ExecuteRequest{
+		Resource: "mrn:echo:payload:copy",
+		Input:    bytes.Repeat([]byte("x"), 4096),
+	})
+	require.NoError(t, err)
+
+	for _, stats := range []*LatencyStats{&serverStats, &clientStats} {
+		snapshot := stats.Snapshot()
+		assert.Equal(t, 4, snapshot["/maschine.plugin.v1.Plugin/Execute"].Count)
+		assert.Equal(t, 0, snapshot["/maschine.plugin.v1.Plugin/Execute"].Errors)
+		assert.Equal(t, 1, snapshot["/maschine.plugin.v1.Plugin/Upload"].Count)
+		assert.Equal(t, 1, snapshot["/maschine.plugin.v1.Plugin/Download"].Count)
+	}
+}
+
+func TestInterceptorPayloadLimit(t *testing.T) {
+	t.Run("server", func(t *testing.T) {
+		client := bufconnClient(t, newEchoPlugin(t), TransferConfig{}, ServerPayloadLimit(1024), ClientInterceptors{})
+
+		_, err := client.Execute(context.Background(), &ExecuteRequest{
+			Resource: "mrn:echo:payload:copy",
+			Input:    bytes.Repeat([]byte("x"), 2048),
+		})
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+		_, err = client.Execute(context.Background(), &ExecuteRequest{
+			Resource:   "mrn:echo:payload:copy",
+			Input:      bytes.Repeat([]byte("x"), 100),
+			Parameters: map[string][]byte{"times": []byte("20")},
+		})
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		assert.Contains(t, err.Error(), "response of")
+	})
+
+	t.Run("client", func(t *testing.T) {
+		client := bufconnClient(t, newEchoPlugin(t), TransferConfig{}, ServerInterceptors{}, ClientPayloadLimit(1024))
+
+		_, err := client.Execute(context.Background(), &ExecuteRequest{
+			Resource: "mrn:echo:payload:copy",
+			Input:    bytes.Repeat([]byte("x"), 2048),
+		})
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+		assert.Contains(t, err.Error(), "request of")
+	})
+
+	t.Run("streams", func(t *testing.T) {
+		client := bufconnClient(t, newEchoPlugin(t), TransferConfig{Threshold: 1024, ChunkSize: 8192},
+			ServerPayloadLimit(4096), ClientInterceptors{})
+
+		_, err := client.Execute(context.Background(), &ExecuteRequest{
+			Resource: "mrn:echo:payload:copy",
+			Input:    bytes.Repeat([]byte("x"), 8192),
+		})
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	})
+}
+
+func TestInterceptorMetadata(t *testing.T) {
+	p := NewBasePlugin("metadata-plugin", "1.0.0")
+	require.NoError(t, p.RegisterSimpleFunction("mrn:metadata:execution:id", func(ctx context.Context, req *TypedExecuteRequest) (any, error) {
+		return MetadataFromContext(ctx), nil
+	}, "Return the metadata"))
+
+	client := bufconnClient(t, p, TransferConfig{}, ServerMetadata(), ClientMetadata())
+
+	ctx := ContextWithMetadata(context.Background(), map[string]string{"Execution-ID": "exec-42"})
+	ctx = ContextWithMetadata(ctx, map[string]string{"trace-id": "trace-7"})
+	resp, err := client.Execute(ctx, &ExecuteRequest{Resource: "mrn:metadata:execution:id"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"execution-id": "exec-42", "trace-id": "trace-7"}`, string(resp.Output))
+
+	resp, err = client.Execute(context.Background(), &ExecuteRequest{Resource: "mrn:metadata:execution:id"})
+	require.NoError(t, err)
+	assert.Equal(t, "null", strings.TrimSpace(string(resp.Output)))
+}
+
+func TestInterceptorsAppend(t *testing.T) {
+	var stats LatencyStats
+	combined := ServerMetadata().Append(ServerMetrics(&stats), ServerPayloadLimit(10))
+	assert.Len(t, combined.Unary, 3)
+	assert.Len(t, combined.Stream, 3)
+	assert.Len(t, ServerMetadata().Unary, 1, "Append does not modify the receiver")
+}
@@ -0,0 +1,104 @@
+// Package redact replaces secret values in strings. It is shared by the
+// plugin logger and the SDK response scrubber.
+package redact
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Placeholder replaces every secret value
+const Placeholder = "[REDACTED]"
+
+// MinLength is the minimum length of a secret value. Shorter values are not
+// redacted because replacing them would mangle unrelated text.
+const MinLength = 4
+
+// Set is a concurrency safe, reference counted set of secret values
+type Set struct {
+	mu      sync.RWMutex
+	secrets map[string]int
+}
+
+// Add adds values to the set. The returned function removes them again.
+func (s *Set) Add(values ...string) (remove func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.secrets == nil {
+		s.secrets = make(map[string]int)
+	}
+	var added []string
+	for _, v := range values {
+		if len(v) < MinLength {
+			continue
+		}
+		s.secrets[v]++
+		added = append(added, v)
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			for _, v := range added {
+				if s.secrets[v]--; s.secrets[v] <= 0 {
+					delete(s.secrets, v)
+				}
+			}
+		})
+	}
+}
+
+// Len returns the number of distinct secret values
+func (s *Set) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.secrets)
+}
+
+// Replace replaces all secret values of the set in str
+func (s *Set) Replace(str string) string {
+	s.mu.RLock()
+	values := make([]string, 0, len(s.secrets))
+	for v := range s.secrets {
+		values = append(values, v)
+	}
+	s.mu.RUnlock()
+
+	return Replace(str, values)
+}
+
+// Replace replaces every occurrence of the given secret values in str, in
+// their plain and in their JSON escaped form. Longer values are replaced
+// first so that a secret containing another one is fully redacted.
+func Replace(str string, values []string) string {
+	if len(values) == 0 || str == "" {
+		return str
+	}
+
+	sorted := append([]string(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+
+	for _, v := range sorted {
+		if len(v) < MinLength {
+			continue
+		}
+		str = strings.ReplaceAll(str, v, Placeholder)
+		if escaped := jsonEscape(v); escaped != v {
+			str = strings.ReplaceAll(str, escaped, Placeholder)
+		}
+	}
+	return str
+}
+
+func jsonEscape(v string) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	return string(data[1 : len(data)-1])
+}
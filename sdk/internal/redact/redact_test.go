@@ -0,0 +1,30 @@
+package redact
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplace(t *testing.T) {
+	assert.Equal(t, "user=bob pass=[REDACTED]", Replace("user=bob pass=s3cr3t", []string{"s3cr3t"}))
+	assert.Equal(t, `{"p":"[REDACTED]"}`, Replace(`{"p":"pa\"ss"}`, []string{`pa"ss`}))
+	assert.Equal(t, "[REDACTED]", Replace("secret-long", []string{"secret", "secret-long"}))
+	assert.Equal(t, "abc", Replace("abc", []string{"abc"}), "short values are not redacted")
+}
+
+func TestSet(t *testing.T) {
+	var s Set
+	remove1 := s.Add("token-1", "x")
+	remove2 := s.Add("token-1")
+	assert.Equal(t, 1, s.Len())
+	assert.Equal(t, "[REDACTED]", s.Replace("token-1"))
+
+	remove1()
+	remove1()
+	assert.Equal(t, "[REDACTED]", s.Replace("token-1"), "still referenced")
+
+	remove2()
+	assert.Equal(t, 0, s.Len())
+	assert.Equal(t, "token-1", s.Replace("token-1"))
+}
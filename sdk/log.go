@@ -0,0 +1,99 @@
+package sdk
+
+import (
+	"context"
+
+	"github.com/hashicorp/go-hclog"
+	"google.golang.org/grpc/metadata"
+	"maschine.io/plugin-sdk/sdk/logger"
+)
+
+const (
+	// executionHeader is the gRPC metadata with the ID the host assigned
+	// to an execution
+	executionHeader = "maschine-execution-id"
+	// logLevelHeader is the gRPC metadata with the log level the host
+	// requests from the plugin
+	logLevelHeader = "maschine-log-level"
+)
+
+type (
+	executionContextKey struct{}
+	logLevelContextKey  struct{}
+)
+
+// ContextWithExecutionID returns a copy of ctx with the ID of an execution.
+// Hosts assign one ID per execution, shared by all its attempts, and
+// plugins tag their logs with it.
+func ContextWithExecutionID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, executionContextKey{}, id)
+}
+
+// ExecutionIDFromContext returns the ID of the execution or "" if the host
+// did not assign one
+func ExecutionIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(executionContextKey{}).(string)
+	return id
+}
+
+// ContextWithLogLevel returns a copy of ctx with the log level the host
+// requests from the plugin. The plugin logger switches to it with the call.
+func ContextWithLogLevel(ctx context.Context, level hclog.Level) context.Context {
+	return context.WithValue(ctx, logLevelContextKey{}, level)
+}
+
+// LogLevelFromContext returns the log level requested by the host or
+// hclog.NoLevel if the host keeps the level of the plugin
+func LogLevelFromContext(ctx context.Context) hclog.Level {
+	if level, ok := ctx.Value(logLevelContextKey{}).(hclog.Level); ok {
+		return level
+	}
+	return hclog.NoLevel
+}
+
+// outgoingLog sends the execution ID and the log level of ctx to the plugin
+func outgoingLog(ctx context.Context) context.Context {
+	var kv []string
+	if id := ExecutionIDFromContext(ctx); id != "" {
+		kv = append(kv, executionHeader, id)
+	}
+	if level := LogLevelFromContext(ctx); level != hclog.NoLevel {
+		kv = append(kv, logLevelHeader, level.String())
+	}
+	if len(kv) == 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, kv...)
+}
+
+// incomingLog makes the execution ID sent by the host available with
+// ExecutionIDFromContext and switches the plugin logger to the requested
+// level
+func incomingLog(ctx context.Context) context.Context {
+	if values := metadata.ValueFromIncomingContext(ctx, executionHeader); len(values) > 0 {
+		ctx = ContextWithExecutionID(ctx, values[0])
+	}
+	if values := metadata.ValueFromIncomingContext(ctx, logLevelHeader); len(values) > 0 {
+		if level := hclog.LevelFromString(values[0]); level != hclog.NoLevel {
+			ctx = ContextWithLogLevel(ctx, level)
+			if logger.Get().GetLevel() != level {
+				logger.SetLevel(level)
+			}
+		}
+	}
+	return ctx
+}
+
+// withExecutionLogger adds the plugin logger tagged with the resource and
+// the execution ID of req to ctx, see logger.FromContext. The execution ID
+// of req replaces the one sent with the call.
+func withExecutionLogger(ctx context.Context, req *ExecuteRequest) context.Context {
+	if req.ExecutionID != "" {
+		ctx = ContextWithExecutionID(ctx, req.ExecutionID)
+	}
+	args := []any{"resource", req.Resource}
+	if id := ExecutionIDFromContext(ctx); id != "" {
+		args = append(args, "execution_id", id)
+	}
+	return logger.WithContext(ctx, logger.Get().With(args...))
+}
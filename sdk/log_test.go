@@ -0,0 +1,50 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maschine.io/plugin-sdk/sdk/logger"
+)
+
+func TestGRPCLog(t *testing.T) {
+	level := logger.Get().GetLevel()
+	t.Cleanup(func() { logger.SetLevel(level) })
+
+	p := NewBasePlugin("mail-plugin", "1.0.0")
+	require.NoError(t, p.RegisterSimpleFunction("mrn:mail:smtp:send", func(ctx context.Context, req *TypedExecuteRequest) (any, error) {
+		// the tags of the execution logger and the level of the plugin
+		return fmt.Sprint(logger.FromContext(ctx).ImpliedArgs(), " ", logger.Get().GetLevel()), nil
+	}, "Send a mail"))
+	client := dispenseTestClient(t, p)
+	req := &ExecuteRequest{Resource: "mrn:mail:smtp:send"}
+
+	logger.SetLevel(hclog.Info)
+	resp, err := client.Execute(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, `"[resource mrn:mail:smtp:send] info"`, string(resp.Output))
+
+	ctx := ContextWithLogLevel(ContextWithExecutionID(context.Background(), "exec-1"), hclog.Trace)
+	resp, err = client.Execute(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, `"[execution_id exec-1 resource mrn:mail:smtp:send] trace"`, string(resp.Output))
+
+	// the level stays until the host requests another one
+	resp, err = client.Execute(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, `"[resource mrn:mail:smtp:send] trace"`, string(resp.Output))
+}
+
+func TestLogContext(t *testing.T) {
+	ctx := context.Background()
+	assert.Empty(t, ExecutionIDFromContext(ctx))
+	assert.Equal(t, hclog.NoLevel, LogLevelFromContext(ctx))
+
+	ctx = ContextWithLogLevel(ContextWithExecutionID(ctx, "exec-1"), hclog.Debug)
+	assert.Equal(t, "exec-1", ExecutionIDFromContext(ctx))
+	assert.Equal(t, hclog.Debug, LogLevelFromContext(ctx))
+}
@@ -0,0 +1,145 @@
+// Package logger provides the plugin-side logger used by Maschine plugins.
+//
+// Plugins log structured JSON to STDERR. go-plugin forwards every line the
+// plugin writes to STDERR to the host, which re-emits it through its own
+// logger. Secret values registered with AddSecrets are redacted from every
+// line before it is written.
+//
+// The host tags forwarded lines with the plugin ID. Log with the logger of
+// FromContext during executions, so that lines are also tagged with the
+// resource and the execution ID.
+package logger
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/go-hclog"
+	"maschine.io/plugin-sdk/sdk/internal/redact"
+)
+
+const (
+	// EnvLogLevel is the environment variable that sets the plugin log level
+	EnvLogLevel = "MASCHINE_PLUGIN_LOG_LEVEL"
+
+	// DefaultLevel is used when EnvLogLevel is unset or invalid
+	DefaultLevel = hclog.Info
+
+	defaultName = "plugin"
+)
+
+var (
+	lock = &sync.Mutex{}
+
+	instance hclog.Logger
+
+	secrets = &redact.Set{}
+)
+
+// InitializeFromEnv creates the plugin logger with the given name and sets
+// its level from MASCHINE_PLUGIN_LOG_LEVEL. The logger writes JSON to STDERR.
+func InitializeFromEnv(name string) hclog.Logger {
+	l := New(name, LevelFromEnv())
+
+	lock.Lock()
+	defer lock.Unlock()
+	instance = l
+	return l
+}
+
+// New creates a JSON logger writing to STDERR with the given name and level
+func New(name string, level hclog.Level) hclog.Logger {
+	return hclog.New(&hclog.LoggerOptions{
+		Name:       name,
+		Level:      level,
+		Output:     NewRedactingWriter(os.Stderr),
+		JSONFormat: true,
+	})
+}
+
+// AddSecrets registers secret values that are redacted from all log output
+// written through NewRedactingWriter. The returned function unregisters them.
+// Values shorter than four characters are ignored.
+func AddSecrets(values ...string) (remove func()) {
+	return secrets.Add(values...)
+}
+
+// Redact replaces all registered secret values in s
+func Redact(s string) string {
+	return secrets.Replace(s)
+}
+
+// NewRedactingWriter returns a writer that redacts all registered secret
+// values before writing to w. hclog writes every log line with a single
+// Write call, so secrets are never split across writes.
+func NewRedactingWriter(w io.Writer) io.Writer {
+	return &redactingWriter{w: w}
+}
+
+type redactingWriter struct {
+	w io.Writer
+}
+
+func (r *redactingWriter) Write(p []byte) (int, error) {
+	if secrets.Len() == 0 {
+		return r.w.Write(p)
+	}
+	if _, err := io.WriteString(r.w, secrets.Replace(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// LevelFromEnv returns the level configured in MASCHINE_PLUGIN_LOG_LEVEL.
+// Unknown or empty values fall back to DefaultLevel.
+func LevelFromEnv() hclog.Level {
+	level := hclog.LevelFromString(strings.TrimSpace(os.Getenv(EnvLogLevel)))
+	if level == hclog.NoLevel {
+		return DefaultLevel
+	}
+	return level
+}
+
+// Get returns the plugin logger. If InitializeFromEnv has not been called
+// yet, a logger with a default name is initialized from the environment.
+func Get() hclog.Logger {
+	lock.Lock()
+	l := instance
+	lock.Unlock()
+
+	if l == nil {
+		return InitializeFromEnv(defaultName)
+	}
+	return l
+}
+
+// Named returns a sub logger of the plugin logger
+func Named(name string) hclog.Logger {
+	return Get().Named(name)
+}
+
+// SetLevel changes the level of the plugin logger and all its sub loggers.
+// The SDK calls it when the host overrides the level of the plugin.
+func SetLevel(level hclog.Level) {
+	Get().SetLevel(level)
+}
+
+type contextKey struct{}
+
+// WithContext returns a copy of ctx that carries l
+func WithContext(ctx context.Context, l hclog.Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger of ctx or the plugin logger if ctx has
+// none. The SDK passes executions a logger tagged with the resource and
+// the execution ID.
+func FromContext(ctx context.Context) hclog.Logger {
+	if l, ok := ctx.Value(contextKey{}).(hclog.Logger); ok {
+		return l
+	}
+	return Get()
+}
@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLevelFromEnv(t *testing.T) {
+	tests := []struct {
+		value string
+		want  hclog.Level
+	}{
+		{value: "", want: DefaultLevel},
+		{value: "trace", want: hclog.Trace},
+		{value: "debug", want: hclog.Debug},
+		{value: "WARN", want: hclog.Warn},
+		{value: " error ", want: hclog.Error},
+		{value: "verbose", want: DefaultLevel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv(EnvLogLevel, tt.value)
+			assert.Equal(t, tt.want, LevelFromEnv())
+		})
+	}
+}
+
+func TestInitializeFromEnv(t *testing.T) {
+	t.Setenv(EnvLogLevel, "debug")
+
+	l := InitializeFromEnv("my-plugin")
+	assert.Equal(t, "my-plugin", l.Name())
+	assert.True(t, l.IsDebug())
+	assert.Same(t, l, Get())
+	assert.Equal(t, "my-plugin.my-function", Named("my-function").Name())
+}
+
+func TestSetLevel(t *testing.T) {
+	t.Setenv(EnvLogLevel, "info")
+	l := InitializeFromEnv("my-plugin")
+	sub := Named("my-function")
+
+	SetLevel(hclog.Trace)
+	assert.True(t, l.IsTrace())
+	assert.True(t, sub.IsTrace())
+}
+
+func TestFromContext(t *testing.T) {
+	l := InitializeFromEnv("my-plugin")
+	assert.Same(t, l, FromContext(context.Background()))
+
+	tagged := l.With("resource", "mrn:mail:smtp:send")
+	assert.Same(t, tagged, FromContext(WithContext(context.Background(), tagged)))
+}
+
+func TestRedactingWriter(t *testing.T) {
+	var buf bytes.Buffer
+	l := hclog.New(&hclog.LoggerOptions{
+		Output:     NewRedactingWriter(&buf),
+		JSONFormat: true,
+	})
+
+	remove := AddSecrets("hunter2-password")
+	l.Info("connecting", "password", "hunter2-password")
+	assert.NotContains(t, buf.String(), "hunter2-password")
+	assert.Contains(t, buf.String(), `"password":"[REDACTED]"`)
+	assert.Equal(t, "pw=[REDACTED]", Redact("pw=hunter2-password"))
+
+	remove()
+	buf.Reset()
+	l.Info("connecting", "password", "hunter2-password")
+	assert.Contains(t, buf.String(), "hunter2-password")
+}
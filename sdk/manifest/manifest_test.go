@@ -51,6 +51,44 @@ func TestManifestValidation(t *testing.T) {
 			wantErr: true,
 			errMsg:  "must be a valid MRN",
 		},
+		{
+			name: "valid rate limit",
+			modify: func(m *PluginManifest) {
+				m.Resources[0].RateLimit = &RateLimit{Requests: 10, Interval: Duration{time.Second}, Scope: "tenant"}
+			},
+			wantErr: false,
+		},
+		{
+			name: "rate limit without interval",
+			modify: func(m *PluginManifest) {
+				m.Resources[0].RateLimit = &RateLimit{Requests: 10}
+			},
+			wantErr: true,
+			errMsg:  "rateLimit.interval: must be positive",
+		},
+		{
+			name: "rate limit with unknown scope",
+			modify: func(m *PluginManifest) {
+				m.Resources[0].RateLimit = &RateLimit{Requests: 10, Interval: Duration{time.Second}, Scope: "user"}
+			},
+			wantErr: true,
+			errMsg:  "unknown scope 'user'",
+		},
+		{
+			name: "valid retry policy",
+			modify: func(m *PluginManifest) {
+				m.Resources[0].Retry = &RetryPolicy{MaxAttempts: 3, RetryableErrors: []string{"unavailable"}}
+			},
+			wantErr: false,
+		},
+		{
+			name: "retry policy with invalid jitter",
+			modify: func(m *PluginManifest) {
+				m.Resources[0].Retry = &RetryPolicy{MaxAttempts: 3, Jitter: 1.5}
+			},
+			wantErr: true,
+			errMsg:  "retry.jitter: must be between 0 and 1",
+		},
 	}
 
 	for _, tt := range tests {
@@ -118,11 +118,36 @@ type ResourceDef struct {
 	Type                string       `json:"type"`        // MRN type (e.g., "mrn:mail:smtp:send")
 	Name                string       `json:"name"`        // Human-readable name
 	Description         string       `json:"description"`
-	Category            string       `json:"category"` // "action", "query", "check"
+	Category            string       `json:"category"` // "action", "query", "check", "trigger"
 	Parameters          []Parameter  `json:"parameters"`
 	RequiredCredentials []string     `json:"requiredCredentials,omitempty"`
 	Output              *OutputDef   `json:"output,omitempty"`
 	Examples            []Example    `json:"examples,omitempty"`
+	RateLimit           *RateLimit   `json:"rateLimit,omitempty"`
+	Retry               *RetryPolicy `json:"retry,omitempty"`
+	Idempotent          bool         `json:"idempotent,omitempty"` // Repeated executions have the effect of one, e.g. with idempotency keys
+}
+
+// RateLimit limits how often the host executes a resource, e.g. to stay
+// within the quota of a third-party API
+type RateLimit struct {
+	Requests int      `json:"requests"`        // Executions allowed per interval
+	Interval Duration `json:"interval"`        // e.g. "1s", "1m"
+	Burst    int      `json:"burst,omitempty"` // Executions allowed at once, defaults to requests
+	Scope    string   `json:"scope,omitempty"` // "global" (default), "credentials", "tenant"
+}
+
+// RetryPolicy tells the host how to retry executions of a resource that
+// failed with a transient error. Actions are only retried if they are
+// idempotent or AllowNonIdempotent is set.
+type RetryPolicy struct {
+	MaxAttempts        int      `json:"maxAttempts"`                  // Attempts including the first one
+	InitialBackoff     Duration `json:"initialBackoff,omitempty"`     // Wait before the first retry, defaults to 100ms
+	MaxBackoff         Duration `json:"maxBackoff,omitempty"`         // Longest wait between attempts, defaults to 10s
+	Multiplier         float64  `json:"multiplier,omitempty"`         // Growth of the wait per retry, defaults to 2
+	Jitter             float64  `json:"jitter,omitempty"`             // Random fraction of the wait (0-1), defaults to 0.2
+	RetryableErrors    []string `json:"retryableErrors,omitempty"`    // Error types to retry, defaults to unavailable, timeout and rate_limited
+	AllowNonIdempotent bool     `json:"allowNonIdempotent,omitempty"` // Retry actions that are not idempotent
 }
 
 // Parameter describes a resource parameter
@@ -168,6 +193,7 @@ type Capabilities struct {
 	ConcurrentExecution  bool `json:"concurrent_execution"`
 	Stateless           bool `json:"stateless"`
 	SupportsCredentials bool `json:"supports_credentials,omitempty"`
+	SupportsDryRun      bool `json:"supports_dry_run,omitempty"` // Action resources can be planned without changes
 }
 
 // Limits defines resource limits
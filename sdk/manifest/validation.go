@@ -254,6 +254,13 @@ func (r *ResourceDef) Validate() error {
 		}
 	}
 
+	if r.RateLimit != nil {
+		errors = append(errors, r.RateLimit.validate()...)
+	}
+	if r.Retry != nil {
+		errors = append(errors, r.Retry.validate()...)
+	}
+
 	if len(errors) > 0 {
 		return errors
 	}
@@ -283,4 +290,61 @@ func isValidURL(s string) bool {
 func isValidEmail(email string) bool {
 	match, _ := regexp.MatchString(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`, email)
 	return match
-}
\ No newline at end of file
+}
+
+// validate validates a rate limit
+func (l *RateLimit) validate() ValidationErrors {
+	var errors ValidationErrors
+
+	if l.Requests <= 0 {
+		errors = append(errors, ValidationError{Field: "rateLimit.requests", Message: "must be positive"})
+	}
+	if l.Interval.Duration <= 0 {
+		errors = append(errors, ValidationError{Field: "rateLimit.interval", Message: "must be positive"})
+	}
+	if l.Burst < 0 {
+		errors = append(errors, ValidationError{Field: "rateLimit.burst", Message: "must not be negative"})
+	}
+	switch l.Scope {
+	case "", "global", "credentials", "tenant":
+	default:
+		errors = append(errors, ValidationError{
+			Field:   "rateLimit.scope",
+			Message: fmt.Sprintf("unknown scope '%s', must be global, credentials or tenant", l.Scope),
+		})
+	}
+	return errors
+}
+
+// validate validates a retry policy
+func (p *RetryPolicy) validate() ValidationErrors {
+	var errors ValidationErrors
+
+	if p.MaxAttempts < 1 {
+		errors = append(errors, ValidationError{Field: "retry.maxAttempts", Message: "must be at least 1"})
+	}
+	if p.InitialBackoff.Duration < 0 {
+		errors = append(errors, ValidationError{Field: "retry.initialBackoff", Message: "must not be negative"})
+	}
+	if p.MaxBackoff.Duration < 0 {
+		errors = append(errors, ValidationError{Field: "retry.maxBackoff", Message: "must not be negative"})
+	}
+	if p.MaxBackoff.Duration > 0 && p.MaxBackoff.Duration < p.InitialBackoff.Duration {
+		errors = append(errors, ValidationError{Field: "retry.maxBackoff", Message: "must not be shorter than initialBackoff"})
+	}
+	if p.Multiplier != 0 && p.Multiplier < 1 {
+		errors = append(errors, ValidationError{Field: "retry.multiplier", Message: "must be at least 1"})
+	}
+	if p.Jitter < 0 || p.Jitter > 1 {
+		errors = append(errors, ValidationError{Field: "retry.jitter", Message: "must be between 0 and 1"})
+	}
+	for i, e := range p.RetryableErrors {
+		if e == "" {
+			errors = append(errors, ValidationError{
+				Field:   fmt.Sprintf("retry.retryableErrors[%d]", i),
+				Message: "must not be empty",
+			})
+		}
+	}
+	return errors
+}
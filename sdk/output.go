@@ -0,0 +1,423 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"maschine.io/plugin-sdk/sdk/manifest"
+)
+
+// ErrorTypeInvalidOutput marks responses whose output does not match the
+// output schema declared in the manifest
+const ErrorTypeInvalidOutput = "invalid_output"
+
+// ValidateOutput validates the output of resp against the output declared
+// by the resource definition, treating OutputDef.Schema as JSON Schema.
+// OutputDef.Type applies if the schema has no type. Failed executions and
+// resources without declared output are not validated. All violations are
+// returned together as manifest.ValidationErrors with fields that are JSON
+// pointers into the output, like "/items/0/id"; the output itself is "".
+//
+// The output is decoded with the codec of its content type. The keywords
+// type, enum, const, properties, required, additionalProperties,
+// minProperties, maxProperties, items, minItems, maxItems, uniqueItems,
+// minLength, maxLength, pattern, minimum, maximum, exclusiveMinimum,
+// exclusiveMaximum, multipleOf, allOf, anyOf, oneOf, not and $ref to
+// definitions in the same schema are supported, all others are ignored.
+func ValidateOutput(def *manifest.ResourceDef, resp *ExecuteResponse) error {
+	if def.Output == nil || resp == nil || resp.Error != "" {
+		return nil
+	}
+
+	schema, err := outputSchema(def.Output)
+	if err != nil {
+		return manifest.ValidationErrors{{Field: "", Message: fmt.Sprintf("invalid output schema in manifest: %v", err)}}
+	}
+
+	value, err := decodeOutput(resp)
+	if err != nil {
+		return manifest.ValidationErrors{{Field: "", Message: err.Error()}}
+	}
+
+	v := &schemaValidator{root: schema}
+	if errors := v.validate("", schema, value); len(errors) > 0 {
+		return errors
+	}
+	return nil
+}
+
+// OutputErrorResponse returns the response of an execution whose output
+// was rejected by ValidateOutput with err. The errors are carried like
+// those of parameter validation, see ValidationErrorsFromResponse.
+func OutputErrorResponse(err error) *ExecuteResponse {
+	resp := validationErrorResponse(err)
+	resp.Error = "invalid output: " + resp.Error
+	resp.Metadata[MetadataErrorType] = ErrorTypeInvalidOutput
+	return resp
+}
+
+// outputSchema returns the schema of the output as decoded JSON, with the
+// type of the output definition if the schema has none
+func outputSchema(def *manifest.OutputDef) (any, error) {
+	var schema any = map[string]any{}
+	if def.Schema != nil {
+		data, err := json.Marshal(def.Schema)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(data, &schema); err != nil {
+			return nil, err
+		}
+	}
+
+	if m, ok := schema.(map[string]any); ok && def.Type != "" {
+		if _, found := m["type"]; !found {
+			m["type"] = def.Type
+		}
+	}
+	return schema, nil
+}
+
+// decodeOutput decodes the output into the values encoding/json produces
+func decodeOutput(resp *ExecuteResponse) (any, error) {
+	if len(resp.Output) == 0 {
+		return nil, nil
+	}
+	var decoded any
+	if err := resp.DecodeOutput(&decoded); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(decoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert output to JSON: %w", err)
+	}
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("failed to convert output to JSON: %w", err)
+	}
+	return value, nil
+}
+
+// schemaValidator validates values against a JSON Schema
+type schemaValidator struct {
+	root any
+	// refs counts the $refs followed since the validator descended into
+	// the value, to stop recursive schemas that never reach a value
+	refs int
+}
+
+// maxRefDepth is how many $refs are followed without descending into the
+// value
+const maxRefDepth = 32
+
+func (v *schemaValidator) validate(pointer string, schema, value any) manifest.ValidationErrors {
+	var errors manifest.ValidationErrors
+	add := func(format string, args ...any) {
+		errors = append(errors, manifest.ValidationError{Field: pointer, Message: fmt.Sprintf(format, args...)})
+	}
+
+	s, ok := schema.(map[string]any)
+	if !ok {
+		if allowed, ok := schema.(bool); !ok {
+			add("invalid schema in manifest: must be an object or a boolean")
+		} else if !allowed {
+			add("is not allowed")
+		}
+		return errors
+	}
+
+	if ref, ok := s["$ref"].(string); ok {
+		target, err := v.resolve(ref)
+		if err != nil {
+			add("invalid $ref %q in schema: %v", ref, err)
+			return errors
+		}
+		if v.refs >= maxRefDepth {
+			add("$ref %q in schema is recursive", ref)
+			return errors
+		}
+		v.refs++
+		errors = append(errors, v.validate(pointer, target, value)...)
+		v.refs--
+	}
+
+	if types := schemaTypes(s["type"]); len(types) > 0 && !hasAnyType(value, types) {
+		add("must be of type %s", strings.Join(types, " or "))
+		// the other keywords assume the type
+		return errors
+	}
+	if enum, ok := s["enum"].([]any); ok && !containsJSON(enum, value) {
+		add("must be one of %s", encodeJSON(enum))
+	}
+	if c, ok := s["const"]; ok && !reflect.DeepEqual(c, value) {
+		add("must be %s", encodeJSON(c))
+	}
+
+	switch value := value.(type) {
+	case map[string]any:
+		errors = append(errors, v.validateObject(pointer, s, value)...)
+	case []any:
+		errors = append(errors, v.validateArray(pointer, s, value)...)
+	case string:
+		length := utf8.RuneCountInString(value)
+		if n, ok := schemaInt(s["minLength"]); ok && length < n {
+			add("must be at least %d characters long", n)
+		}
+		if n, ok := schemaInt(s["maxLength"]); ok && length > n {
+			add("must be at most %d characters long", n)
+		}
+		if pattern, ok := s["pattern"].(string); ok {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				add("invalid pattern %q in schema: %v", pattern, err)
+			} else if !re.MatchString(value) {
+				add("must match pattern %s", pattern)
+			}
+		}
+	case float64:
+		errors = append(errors, validateNumber(pointer, s, value)...)
+	}
+
+	if all, ok := s["allOf"].([]any); ok {
+		for _, sub := range all {
+			errors = append(errors, v.validate(pointer, sub, value)...)
+		}
+	}
+	if anyOf, ok := s["anyOf"].([]any); ok && v.matches(pointer, anyOf, value) == 0 {
+		add("must match at least one schema of anyOf")
+	}
+	if oneOf, ok := s["oneOf"].([]any); ok {
+		if n := v.matches(pointer, oneOf, value); n != 1 {
+			add("must match exactly one schema of oneOf, matches %d", n)
+		}
+	}
+	if not, ok := s["not"]; ok && len(v.validate(pointer, not, value)) == 0 {
+		add("must not match the schema of not")
+	}
+	return errors
+}
+
+func (v *schemaValidator) validateObject(pointer string, s map[string]any, value map[string]any) manifest.ValidationErrors {
+	defer v.descend()()
+	var errors manifest.ValidationErrors
+	add := func(field, format string, args ...any) {
+		errors = append(errors, manifest.ValidationError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if required, ok := s["required"].([]any); ok {
+		for _, name := range required {
+			if name, ok := name.(string); ok {
+				if _, found := value[name]; !found {
+					add(pointer+"/"+escapePointer(name), "is required")
+				}
+			}
+		}
+	}
+	if n, ok := schemaInt(s["minProperties"]); ok && len(value) < n {
+		add(pointer, "must have at least %d properties", n)
+	}
+	if n, ok := schemaInt(s["maxProperties"]); ok && len(value) > n {
+		add(pointer, "must have at most %d properties", n)
+	}
+
+	properties, _ := s["properties"].(map[string]any)
+	additional, hasAdditional := s["additionalProperties"]
+	for _, name := range sortedKeys(value) {
+		field := pointer + "/" + escapePointer(name)
+		if property, found := properties[name]; found {
+			errors = append(errors, v.validate(field, property, value[name])...)
+			continue
+		}
+		if !hasAdditional {
+			continue
+		}
+		if allowed, ok := additional.(bool); ok {
+			if !allowed {
+				add(field, "is not declared")
+			}
+			continue
+		}
+		errors = append(errors, v.validate(field, additional, value[name])...)
+	}
+	return errors
+}
+
+func (v *schemaValidator) validateArray(pointer string, s map[string]any, value []any) manifest.ValidationErrors {
+	defer v.descend()()
+	var errors manifest.ValidationErrors
+	add := func(format string, args ...any) {
+		errors = append(errors, manifest.ValidationError{Field: pointer, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if n, ok := schemaInt(s["minItems"]); ok && len(value) < n {
+		add("must have at least %d items", n)
+	}
+	if n, ok := schemaInt(s["maxItems"]); ok && len(value) > n {
+		add("must have at most %d items", n)
+	}
+	if unique, _ := s["uniqueItems"].(bool); unique {
+		for i := range value {
+			if containsJSON(value[:i], value[i]) {
+				add("must not contain duplicate items")
+				break
+			}
+		}
+	}
+	if items, ok := s["items"]; ok {
+		for i, item := range value {
+			errors = append(errors, v.validate(pointer+"/"+strconv.Itoa(i), items, item)...)
+		}
+	}
+	return errors
+}
+
+func validateNumber(pointer string, s map[string]any, value float64) manifest.ValidationErrors {
+	var errors manifest.ValidationErrors
+	add := func(format string, args ...any) {
+		errors = append(errors, manifest.ValidationError{Field: pointer, Message: fmt.Sprintf(format, args...)})
+	}
+
+	// draft 4 declares exclusive bounds as booleans next to the bounds
+	exclusiveMin, _ := s["exclusiveMinimum"].(bool)
+	exclusiveMax, _ := s["exclusiveMaximum"].(bool)
+	if minimum, ok := s["minimum"].(float64); ok {
+		if exclusiveMin && value <= minimum {
+			add("must be > %v", minimum)
+		} else if value < minimum {
+			add("must be >= %v", minimum)
+		}
+	}
+	if maximum, ok := s["maximum"].(float64); ok {
+		if exclusiveMax && value >= maximum {
+			add("must be < %v", maximum)
+		} else if value > maximum {
+			add("must be <= %v", maximum)
+		}
+	}
+	if minimum, ok := s["exclusiveMinimum"].(float64); ok && value <= minimum {
+		add("must be > %v", minimum)
+	}
+	if maximum, ok := s["exclusiveMaximum"].(float64); ok && value >= maximum {
+		add("must be < %v", maximum)
+	}
+	if factor, ok := s["multipleOf"].(float64); ok && factor > 0 {
+		if q := value / factor; q != math.Trunc(q) {
+			add("must be a multiple of %v", factor)
+		}
+	}
+	return errors
+}
+
+// descend resets the count of followed $refs for the elements of a value.
+// The returned function restores it.
+func (v *schemaValidator) descend() func() {
+	refs := v.refs
+	v.refs = 0
+	return func() { v.refs = refs }
+}
+
+// matches returns how many of the schemas value matches
+func (v *schemaValidator) matches(pointer string, schemas []any, value any) int {
+	n := 0
+	for _, sub := range schemas {
+		if len(v.validate(pointer, sub, value)) == 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// resolve returns the schema a $ref points to. Only references into the
+// root schema, like "#/$defs/item", are supported.
+func (v *schemaValidator) resolve(ref string) (any, error) {
+	pointer, found := strings.CutPrefix(ref, "#")
+	if !found {
+		return nil, fmt.Errorf("only references into the schema are supported")
+	}
+	target := v.root
+	if pointer == "" {
+		return target, nil
+	}
+	for _, token := range strings.Split(strings.TrimPrefix(pointer, "/"), "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch t := target.(type) {
+		case map[string]any:
+			if target, found = t[token]; !found {
+				return nil, fmt.Errorf("%s not found", token)
+			}
+		case []any:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(t) {
+				return nil, fmt.Errorf("%s not found", token)
+			}
+			target = t[i]
+		default:
+			return nil, fmt.Errorf("%s not found", token)
+		}
+	}
+	return target, nil
+}
+
+// escapePointer escapes a property name for a JSON pointer
+func escapePointer(name string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1")
+}
+
+// schemaTypes returns the types of the type keyword, which is a type or a
+// list of types
+func schemaTypes(typ any) []string {
+	switch t := typ.(type) {
+	case string:
+		return []string{t}
+	case []any:
+		types := make([]string, 0, len(t))
+		for _, v := range t {
+			if s, ok := v.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+func hasAnyType(value any, types []string) bool {
+	for _, typ := range types {
+		if typ == "null" && value == nil || typ != "null" && value != nil && hasType(value, typ) {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaInt returns the value of a keyword that is a non-negative integer
+func schemaInt(v any) (int, bool) {
+	f, ok := v.(float64)
+	if !ok || f < 0 || f != math.Trunc(f) {
+		return 0, false
+	}
+	return int(f), true
+}
+
+func containsJSON(values []any, value any) bool {
+	for _, v := range values {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func encodeJSON(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,188 @@
+package sdk
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maschine.io/plugin-sdk/sdk/manifest"
+)
+
+func outputDef(schema string) *manifest.ResourceDef {
+	def := &manifest.ResourceDef{Type: "mrn:mail:imap:fetch", Output: &manifest.OutputDef{Type: "object"}}
+	if schema != "" {
+		var s any
+		if err := json.Unmarshal([]byte(schema), &s); err != nil {
+			panic(err)
+		}
+		def.Output.Schema = s
+	}
+	return def
+}
+
+func outputFields(t *testing.T, err error) map[string]string {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var errors manifest.ValidationErrors
+	require.ErrorAs(t, err, &errors)
+	fields := make(map[string]string, len(errors))
+	for _, e := range errors {
+		fields[e.Field] = e.Message
+	}
+	return fields
+}
+
+const mailSchema = `{
+	"properties": {
+		"folder": {"type": "string", "minLength": 1},
+		"total": {"type": "integer", "minimum": 0},
+		"mails": {
+			"type": "array",
+			"maxItems": 2,
+			"items": {"$ref": "#/$defs/mail"}
+		},
+		"a/b": {"const": 1}
+	},
+	"required": ["folder", "mails"],
+	"additionalProperties": false,
+	"$defs": {
+		"mail": {
+			"type": "object",
+			"properties": {
+				"id": {"type": "string", "pattern": "^[0-9]+$"},
+				"flags": {"type": "array", "items": {"enum": ["seen", "flagged"]}, "uniqueItems": true},
+				"size": {"type": ["number", "null"], "exclusiveMinimum": 0, "multipleOf": 0.5}
+			},
+			"required": ["id"]
+		}
+	}
+}`
+
+func TestValidateOutput(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   map[string]string
+	}{
+		{
+			name:   "valid",
+			output: `{"folder": "INBOX", "total": 1, "mails": [{"id": "1", "flags": ["seen"], "size": 1.5}, {"id": "2", "size": null}]}`,
+		},
+		{
+			name:   "wrong type",
+			output: `["INBOX"]`,
+			want:   map[string]string{"": "must be of type object"},
+		},
+		{
+			name:   "missing and undeclared properties",
+			output: `{"total": -1, "unread": 3}`,
+			want: map[string]string{
+				"/folder": "is required",
+				"/mails":  "is required",
+				"/total":  "must be >= 0",
+				"/unread": "is not declared",
+			},
+		},
+		{
+			name:   "nested",
+			output: `{"folder": "", "a/b": 2, "mails": [{"id": "x1", "flags": ["seen", "seen", "deleted"], "size": 0}, {"size": 0.7}, {"id": "3"}]}`,
+			want: map[string]string{
+				"/folder":          "must be at least 1 characters long",
+				"/a~1b":            "must be 1",
+				"/mails":           "must have at most 2 items",
+				"/mails/0/id":      "must match pattern ^[0-9]+$",
+				"/mails/0/flags":   "must not contain duplicate items",
+				"/mails/0/flags/2": `must be one of ["seen","flagged"]`,
+				"/mails/0/size":    "must be > 0",
+				"/mails/1/id":      "is required",
+				"/mails/1/size":    "must be a multiple of 0.5",
+			},
+		},
+		{
+			name:   "null size",
+			output: `{"folder": "INBOX", "mails": [{"id": "1", "size": "big"}]}`,
+			want:   map[string]string{"/mails/0/size": "must be of type number or null"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &ExecuteResponse{Output: []byte(tt.output), ContentType: ContentTypeJSON}
+			assert.Equal(t, tt.want, outputFields(t, ValidateOutput(outputDef(mailSchema), resp)))
+		})
+	}
+}
+
+func TestValidateOutputCombinators(t *testing.T) {
+	schema := `{
+		"type": ["object", "string"],
+		"anyOf": [{"type": "string"}, {"required": ["id"]}],
+		"oneOf": [{"maxProperties": 1}, {"minProperties": 1}],
+		"not": {"const": "forbidden"},
+		"allOf": [{"properties": {"id": {"type": "integer", "maximum": 10, "exclusiveMaximum": true}}}]
+	}`
+	def := outputDef(schema)
+	def.Output.Type = ""
+	validate := func(output string) map[string]string {
+		return outputFields(t, ValidateOutput(def, &ExecuteResponse{Output: []byte(output)}))
+	}
+
+	assert.Nil(t, validate(`{"id": 1, "n": 2}`))
+	assert.Equal(t, map[string]string{"": "must match exactly one schema of oneOf, matches 2"}, validate(`{"id": 1}`))
+	assert.Equal(t, map[string]string{"": "must match at least one schema of anyOf"}, validate(`{"n": 2, "m": 3}`))
+	assert.Equal(t, map[string]string{"/id": "must be < 10"}, validate(`{"id": 10, "n": 2}`))
+	assert.Equal(t, map[string]string{"": "must not match the schema of not"}, validate(`"forbidden"`))
+}
+
+func TestValidateOutputContentTypes(t *testing.T) {
+	def := outputDef(`{"properties": {"id": {"type": "integer"}}}`)
+
+	resp := &ExecuteResponse{ContentType: ContentTypeMsgPack}
+	out, err := Encode(ContentTypeMsgPack, map[string]any{"id": 1})
+	require.NoError(t, err)
+	resp.Output = out
+	assert.NoError(t, ValidateOutput(def, resp))
+
+	out, err = Encode(ContentTypeCBOR, map[string]any{"id": "1"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"/id": "must be of type integer"},
+		outputFields(t, ValidateOutput(def, &ExecuteResponse{Output: out, ContentType: ContentTypeCBOR})))
+
+	textDef := &manifest.ResourceDef{Output: &manifest.OutputDef{Type: "string"}}
+	assert.NoError(t, ValidateOutput(textDef, &ExecuteResponse{Output: []byte("hello"), ContentType: ContentTypeText}))
+	assert.Equal(t, map[string]string{"": "must be of type object"}, outputFields(t, ValidateOutput(def, &ExecuteResponse{})))
+}
+
+func TestValidateOutputSkipped(t *testing.T) {
+	resp := &ExecuteResponse{Output: []byte(`"not an object"`)}
+	assert.NoError(t, ValidateOutput(&manifest.ResourceDef{}, resp), "no declared output")
+	assert.NoError(t, ValidateOutput(outputDef(""), &ExecuteResponse{Error: "mailbox not found"}), "failed execution")
+	assert.Error(t, ValidateOutput(outputDef(""), resp), "the type applies without schema")
+}
+
+func TestValidateOutputInvalidSchema(t *testing.T) {
+	fields := outputFields(t, ValidateOutput(outputDef(`{"properties": {"id": {"pattern": "("}, "ref": {"$ref": "#/$defs/missing"}}}`),
+		&ExecuteResponse{Output: []byte(`{"id": "1", "ref": 1}`)}))
+	assert.Contains(t, fields["/id"], `invalid pattern "(" in schema`)
+	assert.Contains(t, fields["/ref"], `invalid $ref "#/$defs/missing" in schema`)
+
+	recursive := outputDef(`{"$ref": "#"}`)
+	assert.Contains(t, outputFields(t, ValidateOutput(recursive, &ExecuteResponse{Output: []byte(`{}`)}))[""], "is recursive")
+
+	tree := outputDef(`{"properties": {"child": {"$ref": "#"}, "name": {"type": "string"}}}`)
+	assert.Equal(t, map[string]string{"/child/child/name": "must be of type string"},
+		outputFields(t, ValidateOutput(tree, &ExecuteResponse{Output: []byte(`{"child": {"child": {"name": 1}}}`)})))
+}
+
+func TestOutputErrorResponse(t *testing.T) {
+	err := ValidateOutput(outputDef(mailSchema), &ExecuteResponse{Output: []byte(`{"folder": "INBOX"}`)})
+	resp := OutputErrorResponse(err)
+
+	assert.Equal(t, "invalid output: validation failed:\n/mails: is required", resp.Error)
+	assert.Equal(t, ErrorTypeInvalidOutput, resp.Metadata[MetadataErrorType])
+	errors, ok := ValidationErrorsFromResponse(resp)
+	require.True(t, ok)
+	assert.Equal(t, manifest.ValidationErrors{{Field: "/mails", Message: "is required"}}, errors)
+}
@@ -0,0 +1,77 @@
+package sdk
+
+import (
+	"fmt"
+	"runtime/debug"
+	"strings"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"maschine.io/plugin-sdk/sdk/logger"
+)
+
+// PanicError is returned by the gRPC client if the plugin panicked while
+// handling a call. The panic was recovered and the plugin process keeps
+// running.
+type PanicError struct {
+	// Value is the value passed to panic
+	Value string
+	// Stack is the stack trace of the panicking goroutine
+	Stack string
+}
+
+func (e *PanicError) Error() string {
+	return "plugin panicked: " + e.Value
+}
+
+// recoverPanic turns a panic of the current call into an Internal status
+// carrying the stack trace. It must be deferred by the gRPC handler with
+// its named error result. Secrets known to the logger are redacted from the
+// panic value and the stack trace.
+func recoverPanic(method string, err *error) {
+	v := recover()
+	if v == nil {
+		return
+	}
+
+	value := logger.Redact(fmt.Sprint(v))
+	stack := logger.Redact(string(debug.Stack()))
+	logger.Get().Error("recovered from panic", "method", method, "panic", value, "stack", stack)
+
+	st := status.New(codes.Internal, (&PanicError{Value: value}).Error())
+	if detailed, detailErr := st.WithDetails(&errdetails.DebugInfo{
+		Detail:       value,
+		StackEntries: strings.Split(strings.TrimSpace(stack), "\n"),
+	}); detailErr == nil {
+		st = detailed
+	}
+	*err = st.Err()
+}
+
+// clientError converts the statuses returned by grpcServer for shutdowns,
+// unknown sessions, rejected dry runs and recovered panics back into
+// ErrShuttingDown, ErrSessionNotFound, ErrDryRunNotSupported and
+// *PanicError
+func clientError(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+
+	if st.Code() == codes.Unavailable && st.Message() == ErrShuttingDown.Error() {
+		return ErrShuttingDown
+	}
+	if st.Code() == codes.NotFound && st.Message() == ErrSessionNotFound.Error() {
+		return ErrSessionNotFound
+	}
+	if st.Code() == codes.FailedPrecondition && st.Message() == ErrDryRunNotSupported.Error() {
+		return ErrDryRunNotSupported
+	}
+	for _, detail := range st.Details() {
+		if info, ok := detail.(*errdetails.DebugInfo); ok {
+			return &PanicError{Value: info.Detail, Stack: strings.Join(info.StackEntries, "\n")}
+		}
+	}
+	return err
+}
@@ -0,0 +1,56 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newPanickingPlugin(t *testing.T) *BasePlugin {
+	t.Helper()
+
+	p := NewBasePlugin("panic-plugin", "1.0.0")
+	require.NoError(t, p.RegisterSimpleFunction("mrn:panic:mail:send", func(ctx context.Context, req *TypedExecuteRequest) (any, error) {
+		if _, ok := req.Credentials["smtp_password"]; ok {
+			panic("login failed with " + req.Secret("smtp_password").Reveal())
+		}
+		var m map[string]string
+		m["to"] = "alice@example.com"
+		return nil, nil
+	}, "Send mail"))
+	require.NoError(t, p.RegisterSimpleFunction("mrn:panic:mail:status", func(ctx context.Context, req *TypedExecuteRequest) (any, error) {
+		return "ok", nil
+	}, "Mail status"))
+	return p
+}
+
+func TestGRPCRecoversPanic(t *testing.T) {
+	client := dispenseTestClient(t, newPanickingPlugin(t))
+
+	_, err := client.Execute(context.Background(), &ExecuteRequest{Resource: "mrn:panic:mail:send"})
+	var panicErr *PanicError
+	require.True(t, errors.As(err, &panicErr), "unexpected error: %v", err)
+	assert.Equal(t, "assignment to entry in nil map", panicErr.Value)
+	assert.Contains(t, panicErr.Stack, "panic_test.go")
+
+	// the server keeps serving
+	resp, err := client.Execute(context.Background(), &ExecuteRequest{Resource: "mrn:panic:mail:status"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `"ok"`, string(resp.Output))
+}
+
+func TestGRPCRecoversPanicRedacted(t *testing.T) {
+	client := dispenseTestClient(t, WithSecretRedaction(newPanickingPlugin(t), nil))
+
+	_, err := client.Execute(context.Background(), &ExecuteRequest{
+		Resource:    "mrn:panic:mail:send",
+		Credentials: map[string]string{"smtp_password": "panicked-password"},
+	})
+	var panicErr *PanicError
+	require.True(t, errors.As(err, &panicErr), "unexpected error: %v", err)
+	assert.Equal(t, "login failed with "+Redacted, panicErr.Value)
+	assert.NotContains(t, err.Error(), "panicked-password")
+}
@@ -0,0 +1,163 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	pluginv1 "maschine.io/plugin-sdk/proto/plugin/v1"
+)
+
+// ErrDryRunNotSupported is returned for dry runs against plugins that do
+// not declare the supports_dry_run capability in their manifest
+var ErrDryRunNotSupported = errors.New("plugin does not support dry runs")
+
+// Change is a change an execution would make, like a mail it would send or
+// a cloud resource it would create
+type Change struct {
+	// Action is the kind of change, e.g. "create", "update", "delete" or
+	// "send"
+	Action string `json:"action"`
+	// Target is the object the change applies to
+	Target      string            `json:"target"`
+	Description string            `json:"description,omitempty"`
+	Details     map[string]string `json:"details,omitempty"`
+}
+
+// Planner is implemented by plugins that can preview their executions. The
+// gRPC client implements it as well, so hosts can type assert a dispensed
+// MaschineResource to Planner.
+type Planner interface {
+	// Plan returns the changes the execution of req would make without
+	// making them
+	Plan(ctx context.Context, req *ExecuteRequest) ([]Change, error)
+}
+
+// PlanFunction returns the changes the SimpleFunction of the same resource
+// would make
+type PlanFunction func(ctx context.Context, req *TypedExecuteRequest) ([]Change, error)
+
+// DecodePlan decodes the output of a dry run into its changes
+func DecodePlan(resp *ExecuteResponse) ([]Change, error) {
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+	var changes []Change
+	if err := json.Unmarshal(resp.Output, &changes); err != nil {
+		return nil, fmt.Errorf("failed to decode plan: %w", err)
+	}
+	return changes, nil
+}
+
+// supportsDryRun reports whether the manifest of the plugin declares the
+// supports_dry_run capability
+func (s *grpcServer) supportsDryRun(ctx context.Context) bool {
+	provider, ok := lookup[ManifestProvider](s.Impl)
+	if !ok {
+		return false
+	}
+	m, err := provider.GetManifest(ctx)
+	return err == nil && m.Capabilities.SupportsDryRun
+}
+
+// checkDryRun rejects dry runs if the plugin does not support them
+func (s *grpcServer) checkDryRun(ctx context.Context, reqs ...*pluginv1.ExecuteRequest) error {
+	for _, req := range reqs {
+		if req.DryRun && !s.supportsDryRun(ctx) {
+			return status.Error(codes.FailedPrecondition, ErrDryRunNotSupported.Error())
+		}
+	}
+	return nil
+}
+
+func (s *grpcServer) Plan(ctx context.Context, req *pluginv1.ExecuteRequest) (_ *pluginv1.PlanResponse, err error) {
+	defer recoverPanic("Plan", &err)
+
+	if !s.begin() {
+		return nil, status.Error(codes.Unavailable, ErrShuttingDown.Error())
+	}
+	defer s.end()
+
+	req, err = s.resolveRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if !s.supportsDryRun(ctx) {
+		return nil, status.Error(codes.FailedPrecondition, ErrDryRunNotSupported.Error())
+	}
+	planner, ok := lookup[Planner](s.Impl)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, ErrDryRunNotSupported.Error())
+	}
+
+	ctx, release, err := s.withSession(incomingLog(ctx), req.SessionId)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	defer release()
+
+	r := fromProtoRequest(req)
+	r.DryRun = true
+	changes, err := planner.Plan(withExecutionLogger(ctx, r), r)
+	if errors.Is(err, ErrDryRunNotSupported) {
+		// middlewares plan for the wrapped resource, which cannot plan
+		return nil, status.Error(codes.Unimplemented, ErrDryRunNotSupported.Error())
+	}
+	if err != nil {
+		return &pluginv1.PlanResponse{Error: err.Error()}, nil
+	}
+
+	resp := &pluginv1.PlanResponse{}
+	for _, c := range changes {
+		resp.Changes = append(resp.Changes, &pluginv1.PlannedChange{
+			Action:      c.Action,
+			Target:      c.Target,
+			Description: c.Description,
+			Details:     c.Details,
+		})
+	}
+	return resp, nil
+}
+
+// Plan returns the changes the execution of req would make. It returns
+// ErrDryRunNotSupported if the plugin does not declare the
+// supports_dry_run capability or cannot plan.
+func (c *grpcClient) Plan(ctx context.Context, req *ExecuteRequest) ([]Change, error) {
+	pbReq := toProtoRequest(req)
+	pbReq.DryRun = true
+
+	// Requests above the threshold are uploaded in chunks
+	if proto.Size(pbReq) > c.transfer.Threshold {
+		id, err := c.upload(ctx, pbReq)
+		if err != nil {
+			return nil, err
+		}
+		pbReq = &pluginv1.ExecuteRequest{PayloadId: id}
+	}
+
+	resp, err := c.client.Plan(outgoingLog(ctx), pbReq)
+	if status.Code(err) == codes.Unimplemented {
+		return nil, ErrDryRunNotSupported
+	}
+	if err != nil {
+		return nil, clientError(err)
+	}
+	if resp.Error != "" {
+		return nil, errors.New(resp.Error)
+	}
+
+	changes := make([]Change, 0, len(resp.Changes))
+	for _, c := range resp.Changes {
+		changes = append(changes, Change{
+			Action:      c.Action,
+			Target:      c.Target,
+			Description: c.Description,
+			Details:     c.Details,
+		})
+	}
+	return changes, nil
+}
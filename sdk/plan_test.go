@@ -0,0 +1,169 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maschine.io/plugin-sdk/sdk/manifest"
+)
+
+// newSendPlugin returns a plugin that counts the mails it sends and can
+// plan sending them if dryRun is set
+func newSendPlugin(t *testing.T, dryRun bool, sent *atomic.Int32) *BasePlugin {
+	t.Helper()
+
+	p := NewBasePlugin("send-plugin", "1.0.0")
+	m := manifest.New("send-plugin", "io.test.send")
+	m.Capabilities.SupportsDryRun = dryRun
+	p.SetManifest(m)
+
+	require.NoError(t, p.RegisterSimpleFunction("mrn:send:mail:send", func(ctx context.Context, req *TypedExecuteRequest) (any, error) {
+		sent.Add(1)
+		return "sent", nil
+	}, "Send a mail"))
+	require.NoError(t, p.RegisterSimpleFunction("mrn:send:mail:draft", func(ctx context.Context, req *TypedExecuteRequest) (any, error) {
+		return "drafted", nil
+	}, "Draft a mail"))
+	require.NoError(t, p.RegisterPlanFunction("mrn:send:mail:send", func(ctx context.Context, req *TypedExecuteRequest) ([]Change, error) {
+		var to string
+		if _, err := req.GetParameter("to", &to); err != nil {
+			return nil, err
+		}
+		return []Change{{Action: "send", Target: to, Details: map[string]string{"subject": string(req.Input)}}}, nil
+	}))
+	return p
+}
+
+func TestGRPCPlan(t *testing.T) {
+	var sent atomic.Int32
+	client := dispenseTestClient(t, newSendPlugin(t, true, &sent))
+	ctx := context.Background()
+	req := &ExecuteRequest{
+		Resource:   "mrn:send:mail:send",
+		Input:      []byte("Quarterly report"),
+		Parameters: map[string][]byte{"to": []byte(`"bob@example.com"`)},
+	}
+	want := []Change{{Action: "send", Target: "bob@example.com", Details: map[string]string{"subject": "Quarterly report"}}}
+
+	changes, err := client.(Planner).Plan(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, want, changes)
+
+	dryRun := *req
+	dryRun.DryRun = true
+	resp, err := client.Execute(ctx, &dryRun)
+	require.NoError(t, err)
+	changes, err = DecodePlan(resp)
+	require.NoError(t, err)
+	assert.Equal(t, want, changes)
+
+	resps, err := client.(BatchExecutor).ExecuteBatch(ctx, []*ExecuteRequest{&dryRun, &dryRun})
+	require.NoError(t, err)
+	for _, resp := range resps {
+		changes, err = DecodePlan(resp)
+		require.NoError(t, err)
+		assert.Equal(t, want, changes)
+	}
+	assert.Zero(t, sent.Load(), "dry runs do not send mails")
+
+	_, err = client.(Planner).Plan(ctx, &ExecuteRequest{Resource: "mrn:send:mail:draft"})
+	assert.EqualError(t, err, "resource mrn:send:mail:draft does not support dry runs")
+
+	resp, err = client.Execute(ctx, req)
+	require.NoError(t, err)
+	assert.JSONEq(t, `"sent"`, string(resp.Output))
+	assert.Equal(t, int32(1), sent.Load())
+}
+
+func TestGRPCPlanNotSupported(t *testing.T) {
+	var sent atomic.Int32
+	client := dispenseTestClient(t, newSendPlugin(t, false, &sent))
+	ctx := context.Background()
+	req := &ExecuteRequest{Resource: "mrn:send:mail:send", DryRun: true}
+
+	_, err := client.(Planner).Plan(ctx, req)
+	assert.ErrorIs(t, err, ErrDryRunNotSupported)
+
+	_, err = client.Execute(ctx, req)
+	assert.ErrorIs(t, err, ErrDryRunNotSupported)
+
+	_, err = client.(BatchExecutor).ExecuteBatch(ctx, []*ExecuteRequest{{Resource: "mrn:send:mail:draft"}, req})
+	assert.ErrorIs(t, err, ErrDryRunNotSupported)
+	assert.Zero(t, sent.Load())
+}
+
+func TestRegisterPlanFunction(t *testing.T) {
+	p := NewBasePlugin("send-plugin", "1.0.0")
+	plan := func(ctx context.Context, req *TypedExecuteRequest) ([]Change, error) { return nil, nil }
+
+	assert.EqualError(t, p.RegisterPlanFunction("mrn:send:mail:send", plan), "function not registered: mrn:send:mail:send")
+	require.NoError(t, p.RegisterSimpleFunction("mrn:send:mail:send", func(ctx context.Context, req *TypedExecuteRequest) (any, error) {
+		return nil, nil
+	}, "Send a mail"))
+	require.NoError(t, p.RegisterPlanFunction("mrn:send:mail:send", plan))
+	assert.EqualError(t, p.RegisterPlanFunction("mrn:send:mail:send", plan), "plan function already registered: mrn:send:mail:send")
+
+	changes, err := p.Plan(context.Background(), &ExecuteRequest{Resource: "mrn:send:mail:send"})
+	require.NoError(t, err)
+	assert.Equal(t, []Change{}, changes)
+}
+
+func TestPlanThroughMiddlewares(t *testing.T) {
+	m := testMailManifest()
+	m.Capabilities.SupportsDryRun = true
+	m.Configuration.Credentials = []manifest.CredentialSet{
+		{Name: "smtp", Fields: []manifest.CredentialField{{Name: "password", Type: "string", Secret: true}}},
+	}
+	p := NewBasePlugin("mail-plugin", "1.0.0")
+	require.NoError(t, p.RegisterSimpleFunction("mrn:mail:smtp:send", func(ctx context.Context, req *TypedExecuteRequest) (any, error) {
+		return "sent", nil
+	}, "Send an email"))
+	require.NoError(t, p.RegisterPlanFunction("mrn:mail:smtp:send", func(ctx context.Context, req *TypedExecuteRequest) ([]Change, error) {
+		var to, priority string
+		if _, err := req.GetParameter("to", &to); err != nil {
+			return nil, err
+		}
+		if _, err := req.GetParameter("priority", &priority); err != nil {
+			return nil, err
+		}
+		if to == "fail@example.com" {
+			return nil, errors.New("login failed for " + req.Credentials["smtp_password"])
+		}
+		return []Change{{
+			Action:      "send",
+			Target:      to,
+			Description: "login with " + req.Credentials["smtp_password"],
+			Details:     map[string]string{"priority": priority},
+		}}, nil
+	}))
+	client := dispenseTestClient(t, WithSecretRedaction(WithParameterValidation(p, m), m)).(Planner)
+	ctx := context.Background()
+	credentials := map[string]string{"smtp_password": "hunter2-password"}
+
+	_, err := client.Plan(ctx, &ExecuteRequest{Resource: "mrn:mail:smtp:send", Credentials: credentials})
+	assert.ErrorContains(t, err, "parameters.to: is required")
+
+	changes, err := client.Plan(ctx, &ExecuteRequest{
+		Resource:    "mrn:mail:smtp:send",
+		Parameters:  map[string][]byte{"to": []byte(`"bob@example.com"`), "subject": []byte(`"hi"`)},
+		Credentials: credentials,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []Change{{
+		Action:      "send",
+		Target:      "bob@example.com",
+		Description: "login with [REDACTED]",
+		Details:     map[string]string{"priority": "low"},
+	}}, changes, "defaults are filled in and secrets scrubbed")
+
+	_, err = client.Plan(ctx, &ExecuteRequest{
+		Resource:    "mrn:mail:smtp:send",
+		Parameters:  map[string][]byte{"to": []byte(`"fail@example.com"`), "subject": []byte(`"hi"`)},
+		Credentials: credentials,
+	})
+	assert.EqualError(t, err, "login failed for [REDACTED]")
+}
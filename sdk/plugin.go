@@ -1,11 +1,16 @@
 package sdk
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"maps"
+	"time"
 	
 	"github.com/hashicorp/go-plugin"
 	"google.golang.org/grpc"
 	pluginv1 "maschine.io/plugin-sdk/proto/plugin/v1"
+	"maschine.io/plugin-sdk/sdk/manifest"
 )
 
 // Handshake is a common handshake that is shared by plugin and host
@@ -15,9 +20,12 @@ var Handshake = plugin.HandshakeConfig{
 	MagicCookieValue: "93f6bc9f-f0bc-4b65-a0e5-9c8c7e5d3f4b",
 }
 
+// PluginName is the name under which the MaschineResource is dispensed
+const PluginName = "maschine"
+
 // PluginMap is the map of plugins we can dispense
 var PluginMap = map[string]plugin.Plugin{
-	"maschine": &MaschinePlugin{},
+	PluginName: &MaschinePlugin{},
 }
 
 // MaschineResource is the interface that we're exposing as a plugin
@@ -27,6 +35,17 @@ type MaschineResource interface {
 	HealthCheck(context.Context, *HealthCheckRequest) (*HealthCheckResponse, error)
 }
 
+// ManifestProvider is implemented by plugins that can report their complete
+// manifest. The gRPC client implements it as well, so hosts can type assert
+// a dispensed MaschineResource to ManifestProvider.
+type ManifestProvider interface {
+	GetManifest(context.Context) (*manifest.PluginManifest, error)
+}
+
+// ErrManifestNotProvided is returned by GetManifest if the plugin does not
+// provide its manifest
+var ErrManifestNotProvided = errors.New("plugin does not provide a manifest")
+
 // GetMetadataRequest is the request for metadata
 type GetMetadataRequest struct{}
 
@@ -45,6 +64,23 @@ type ExecuteRequest struct {
 	Parameters  map[string][]byte
 	Credentials map[string]string
 	Context     map[string]string
+	// SessionID is the session the execution runs in, see SessionHandler
+	SessionID string
+	// DryRun previews the execution without changing anything. The
+	// response output is the JSON encoded []Change the execution would make.
+	DryRun bool
+	// IdempotencyKey identifies retries of the same execution, see
+	// WithIdempotency
+	IdempotencyKey string
+	// ContentType is the content type of Input, empty for JSON
+	ContentType string
+	// ParameterContentTypes are the content types of Parameters by name.
+	// Parameters without one are JSON or plain strings.
+	ParameterContentTypes map[string]string
+	// ExecutionID is the ID the host assigned to the execution. Hosts set it
+	// for the executions of a batch, which share one context, and it
+	// replaces the ID of ContextWithExecutionID.
+	ExecutionID string
 }
 
 // ExecuteResponse contains execution results
@@ -52,15 +88,35 @@ type ExecuteResponse struct {
 	Output   []byte
 	Error    string
 	Metadata map[string]string
+	// ContentType is the content type of Output, empty for JSON or unknown
+	ContentType string
+}
+
+// Clone returns a deep copy of r, so that its Output and Metadata can be
+// changed without changing r
+func (r *ExecuteResponse) Clone() *ExecuteResponse {
+	if r == nil {
+		return nil
+	}
+	c := *r
+	c.Output = bytes.Clone(r.Output)
+	c.Metadata = maps.Clone(r.Metadata)
+	return &c
 }
 
 // HealthCheckRequest is the request for health check
-type HealthCheckRequest struct{}
+type HealthCheckRequest struct {
+	Probe HealthProbe
+}
 
 // HealthCheckResponse contains health status
 type HealthCheckResponse struct {
+	// Healthy is false if Status is HealthUnhealthy
 	Healthy bool
 	Message string
+	Status  HealthStatus
+	// Dependencies are the results of the dependency checks
+	Dependencies []DependencyHealth
 }
 
 // MaschinePlugin is the implementation of plugin.Plugin so we can serve/consume this
@@ -68,13 +124,30 @@ type MaschinePlugin struct {
 	plugin.Plugin
 	// Impl Injection
 	Impl MaschineResource
+	// Transfer configures chunked transfer of large payloads
+	Transfer TransferConfig
+	// BatchConcurrency limits the concurrent executions of a batch if Impl
+	// does not implement BatchExecutor. Defaults to DefaultBatchConcurrency.
+	BatchConcurrency int
+	// SessionTTL is the idle time after which sessions expire unless the
+	// host requests another TTL. Defaults to DefaultSessionTTL.
+	SessionTTL time.Duration
 }
 
 func (p *MaschinePlugin) GRPCServer(broker *plugin.GRPCBroker, s *grpc.Server) error {
-	pluginv1.RegisterPluginServer(s, &grpcServer{Impl: p.Impl})
+	server := newGRPCServer(p.Impl, p.Transfer)
+	server.batchConcurrency = p.BatchConcurrency
+	server.sessions = newSessionManager(p.SessionTTL)
+	pluginv1.RegisterPluginServer(s, server)
+	if target, ok := healthTargets.LoadAndDelete(s); ok {
+		target.(*healthTarget).set(server)
+	}
 	return nil
 }
 
 func (p *MaschinePlugin) GRPCClient(ctx context.Context, broker *plugin.GRPCBroker, c *grpc.ClientConn) (interface{}, error) {
-	return &grpcClient{client: pluginv1.NewPluginClient(c)}, nil
+	return &grpcClient{
+		client:   pluginv1.NewPluginClient(c),
+		transfer: p.Transfer.withDefaults(),
+	}, nil
 }
\ No newline at end of file
@@ -0,0 +1,279 @@
+package plugintest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"maschine.io/plugin-sdk/sdk"
+	"maschine.io/plugin-sdk/sdk/manifest"
+)
+
+var (
+	_ sdk.MaschineResource = (*FakeResource)(nil)
+	_ sdk.ManifestProvider = (*FakeResource)(nil)
+)
+
+// FakeResource is a scriptable sdk.MaschineResource for tests of host side
+// code. It returns canned responses by resource and parameters, records
+// all executions and injects latency and errors. It is safe for concurrent
+// use.
+//
+//	fake := plugintest.NewFakeResource("mail-plugin", "1.0.0")
+//	fake.On("mrn:mail:smtp:send").WithParam("to", "ops@example.com").Return("queued")
+//	fake.On("mrn:mail:smtp:send").ReturnError("mailbox full").Times(1)
+type FakeResource struct {
+	name    string
+	version string
+
+	mu       sync.Mutex
+	stubs    []*Stub
+	calls    []*sdk.ExecuteRequest
+	health   *sdk.HealthCheckResponse
+	manifest *manifest.PluginManifest
+}
+
+// NewFakeResource creates a FakeResource that reports the name and version
+// as metadata and is healthy
+func NewFakeResource(name, version string) *FakeResource {
+	return &FakeResource{
+		name:    name,
+		version: version,
+		health:  &sdk.HealthCheckResponse{Healthy: true, Status: sdk.HealthHealthy},
+	}
+}
+
+// Stub is a canned response of a FakeResource. Its methods configure it and
+// return it for chaining; configure stubs before the executions they
+// answer.
+type Stub struct {
+	resource string
+	params   map[string][]byte
+	resp     *sdk.ExecuteResponse
+	err      error
+	latency  time.Duration
+	// remaining is the number of executions left, negative for unlimited
+	remaining int
+}
+
+// On adds a stub for executions of resource. Stubs are matched in the order
+// they were added; the first one whose resource and parameters match and
+// that has executions left answers. Without Return, it returns an empty
+// output.
+func (f *FakeResource) On(resource string) *Stub {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	s := &Stub{resource: resource, resp: &sdk.ExecuteResponse{}, remaining: -1}
+	f.stubs = append(f.stubs, s)
+	return s
+}
+
+// WithParam restricts the stub to executions with the parameter name set to
+// the JSON encoding of value
+func (s *Stub) WithParam(name string, value any) *Stub {
+	data, err := json.Marshal(value)
+	if err != nil {
+		panic(fmt.Sprintf("plugintest: failed to encode parameter %s: %v", name, err))
+	}
+	if s.params == nil {
+		s.params = make(map[string][]byte)
+	}
+	s.params[name] = data
+	return s
+}
+
+// Return makes the stub return the JSON encoded output
+func (s *Stub) Return(output any) *Stub {
+	data, err := json.Marshal(output)
+	if err != nil {
+		panic(fmt.Sprintf("plugintest: failed to encode output: %v", err))
+	}
+	s.resp = &sdk.ExecuteResponse{Output: data, ContentType: sdk.ContentTypeJSON}
+	return s
+}
+
+// ReturnResponse makes the stub return resp
+func (s *Stub) ReturnResponse(resp *sdk.ExecuteResponse) *Stub {
+	s.resp = resp
+	return s
+}
+
+// ReturnError makes the stub return a response that failed with message,
+// classified with the optional error type, see sdk.WithErrorType
+func (s *Stub) ReturnError(message string, errorType ...string) *Stub {
+	s.resp = &sdk.ExecuteResponse{Error: message}
+	if len(errorType) > 0 {
+		s.resp.Metadata = map[string]string{sdk.MetadataErrorType: errorType[0]}
+	}
+	return s
+}
+
+// Fail makes the call itself fail with err, like a broken connection
+func (s *Stub) Fail(err error) *Stub {
+	s.err = err
+	return s
+}
+
+// Delay makes the stub wait before it answers. Executions whose context
+// is done first fail with the context error.
+func (s *Stub) Delay(latency time.Duration) *Stub {
+	s.latency = latency
+	return s
+}
+
+// Times limits the stub to n executions, after which the next matching
+// stub answers
+func (s *Stub) Times(n int) *Stub {
+	s.remaining = n
+	return s
+}
+
+// matches reports whether the stub answers req
+func (s *Stub) matches(req *sdk.ExecuteRequest) bool {
+	if s.resource != req.Resource || s.remaining == 0 {
+		return false
+	}
+	for name, value := range s.params {
+		if !jsonEqual(value, req.Parameters[name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonEqual compares two JSON documents regardless of formatting
+func jsonEqual(a, b []byte) bool {
+	var va, vb any
+	if json.Unmarshal(a, &va) != nil || json.Unmarshal(b, &vb) != nil {
+		return bytes.Equal(a, b)
+	}
+	ca, _ := json.Marshal(va)
+	cb, _ := json.Marshal(vb)
+	return bytes.Equal(ca, cb)
+}
+
+// SetHealth sets the response of HealthCheck
+func (f *FakeResource) SetHealth(resp *sdk.HealthCheckResponse) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.health = resp
+}
+
+// SetManifest sets the manifest returned by GetManifest
+func (f *FakeResource) SetManifest(m *manifest.PluginManifest) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.manifest = m
+}
+
+// Calls returns the recorded executions in the order they arrived
+func (f *FakeResource) Calls() []*sdk.ExecuteRequest {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*sdk.ExecuteRequest(nil), f.calls...)
+}
+
+// CallsTo returns the recorded executions of resource
+func (f *FakeResource) CallsTo(resource string) []*sdk.ExecuteRequest {
+	var calls []*sdk.ExecuteRequest
+	for _, req := range f.Calls() {
+		if req.Resource == resource {
+			calls = append(calls, req)
+		}
+	}
+	return calls
+}
+
+// Reset removes all stubs and recorded executions
+func (f *FakeResource) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stubs = nil
+	f.calls = nil
+}
+
+// GetMetadata reports the stubbed resources as supported
+func (f *FakeResource) GetMetadata(ctx context.Context, req *sdk.GetMetadataRequest) (*sdk.GetMetadataResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	seen := make(map[string]bool)
+	resources := []string{}
+	for _, s := range f.stubs {
+		if !seen[s.resource] {
+			seen[s.resource] = true
+			resources = append(resources, s.resource)
+		}
+	}
+	sort.Strings(resources)
+	return &sdk.GetMetadataResponse{Name: f.name, Version: f.version, SupportedResources: resources}, nil
+}
+
+// GetManifest returns the manifest set with SetManifest
+func (f *FakeResource) GetManifest(ctx context.Context) (*manifest.PluginManifest, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.manifest == nil {
+		return nil, sdk.ErrManifestNotProvided
+	}
+	return f.manifest, nil
+}
+
+// HealthCheck returns the response set with SetHealth
+func (f *FakeResource) HealthCheck(ctx context.Context, req *sdk.HealthCheckRequest) (*sdk.HealthCheckResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	health := *f.health
+	return &health, nil
+}
+
+// Execute records req and answers with the first matching stub.
+// Executions without a matching stub fail like those of unknown resources.
+func (f *FakeResource) Execute(ctx context.Context, req *sdk.ExecuteRequest) (*sdk.ExecuteResponse, error) {
+	f.mu.Lock()
+	recorded := *req
+	f.calls = append(f.calls, &recorded)
+
+	var (
+		stub  *Stub
+		known bool
+	)
+	for _, s := range f.stubs {
+		known = known || s.resource == req.Resource
+		if s.matches(req) {
+			stub = s
+			break
+		}
+	}
+	if stub == nil {
+		f.mu.Unlock()
+		if known {
+			return &sdk.ExecuteResponse{Error: fmt.Sprintf("no stub matches the parameters of %s", req.Resource)}, nil
+		}
+		return &sdk.ExecuteResponse{Error: fmt.Sprintf("unknown resource: %s", req.Resource)}, nil
+	}
+	if stub.remaining > 0 {
+		stub.remaining--
+	}
+	resp, err, latency := stub.resp, stub.err, stub.latency
+	f.mu.Unlock()
+
+	if latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	copied := *resp
+	return &copied, nil
+}
@@ -0,0 +1,80 @@
+package plugintest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maschine.io/plugin-sdk/sdk"
+)
+
+func TestFakeResource(t *testing.T) {
+	fake := NewFakeResource("mail-plugin", "1.0.0")
+	fake.On("mrn:mail:smtp:send").WithParam("to", "ops@example.com").Return(map[string]bool{"queued": true})
+	fake.On("mrn:mail:smtp:send").ReturnError("mailbox full", sdk.ErrorTypeUnavailable).Times(1)
+	fake.On("mrn:mail:smtp:send").Return("fallback")
+
+	// the fake serves over gRPC like any other resource
+	c := New(t, fake)
+	send := func(to string) *Result {
+		return c.Execute(NewRequest(t, "mrn:mail:smtp:send").Param("to", to).Build())
+	}
+	send("ops@example.com").AssertOutputJSON(`{"queued": true}`)
+	send("dev@example.com").AssertError("mailbox full").AssertErrorType(sdk.ErrorTypeUnavailable)
+	send("dev@example.com").AssertOutput("fallback")
+	c.Execute(NewRequest(t, "mrn:mail:imap:fetch").Build()).AssertError("unknown resource: mrn:mail:imap:fetch")
+
+	calls := fake.CallsTo("mrn:mail:smtp:send")
+	require.Len(t, calls, 3)
+	assert.Equal(t, `"dev@example.com"`, string(calls[2].Parameters["to"]))
+	assert.Len(t, fake.Calls(), 4)
+
+	md, err := fake.GetMetadata(context.Background(), &sdk.GetMetadataRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mrn:mail:smtp:send"}, md.SupportedResources)
+
+	fake.Reset()
+	assert.Empty(t, fake.Calls())
+}
+
+func TestFakeResourceParameterMismatch(t *testing.T) {
+	fake := NewFakeResource("mail-plugin", "1.0.0")
+	fake.On("mrn:mail:smtp:send").WithParam("to", "ops@example.com")
+
+	resp, err := fake.Execute(context.Background(), &sdk.ExecuteRequest{
+		Resource:   "mrn:mail:smtp:send",
+		Parameters: map[string][]byte{"to": []byte(` "ops@example.com" `)},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, resp.Error, "formatting is ignored")
+
+	resp, err = fake.Execute(context.Background(), &sdk.ExecuteRequest{Resource: "mrn:mail:smtp:send"})
+	require.NoError(t, err)
+	assert.Equal(t, "no stub matches the parameters of mrn:mail:smtp:send", resp.Error)
+}
+
+func TestFakeResourceFailures(t *testing.T) {
+	fake := NewFakeResource("mail-plugin", "1.0.0")
+	broken := errors.New("connection reset")
+	fake.On("mrn:mail:smtp:send").Fail(broken)
+	fake.On("mrn:mail:imap:fetch").Delay(time.Minute)
+	fake.SetHealth(&sdk.HealthCheckResponse{Status: sdk.HealthDegraded, Message: "imap is slow"})
+
+	_, err := fake.Execute(context.Background(), &sdk.ExecuteRequest{Resource: "mrn:mail:smtp:send"})
+	assert.ErrorIs(t, err, broken)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = fake.Execute(ctx, &sdk.ExecuteRequest{Resource: "mrn:mail:imap:fetch"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	health, err := fake.HealthCheck(context.Background(), &sdk.HealthCheckRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, sdk.HealthDegraded, health.Status)
+
+	_, err = fake.GetManifest(context.Background())
+	assert.ErrorIs(t, err, sdk.ErrManifestNotProvided)
+}
@@ -0,0 +1,161 @@
+package plugintest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maschine.io/plugin-sdk/sdk"
+	"maschine.io/plugin-sdk/sdk/host"
+	"maschine.io/plugin-sdk/sdk/manifest"
+)
+
+// FakeHost drives a plugin served in-process the way a host does, for
+// plugin tests that depend on host services like configuration,
+// credentials, sessions, retries and shutdown. Failures are reported to the
+// test.
+//
+//	h := plugintest.NewFakeHost(t, newMailPlugin(), mailManifest())
+//	h.Configuration = &sdk.ConfigureRequest{Environment: map[string]string{"SMTP_HOST": "localhost"}}
+//	h.Lifecycle()
+type FakeHost struct {
+	// Client talks to the plugin over gRPC
+	Client   *Client
+	Manifest *manifest.PluginManifest
+	// Configuration is sent to the plugin by Start
+	Configuration *sdk.ConfigureRequest
+	// Credentials are added to every execution without credentials
+	Credentials map[string]string
+	// Context is added to every execution without context
+	Context map[string]string
+
+	t testing.TB
+}
+
+// NewFakeHost serves impl in-process for a host with the manifest m
+func NewFakeHost(t testing.TB, impl sdk.MaschineResource, m *manifest.PluginManifest) *FakeHost {
+	t.Helper()
+	return &FakeHost{Client: New(t, impl), Manifest: m, t: t}
+}
+
+// Start does what a host does after it launched the plugin: it verifies
+// the plugin against the manifest, sends the configuration and requires a
+// healthy plugin
+func (h *FakeHost) Start() {
+	h.t.Helper()
+	ctx := h.t.Context()
+
+	require.NoError(h.t, host.VerifyPlugin(ctx, h.Client.MaschineResource, h.Manifest), "plugin does not match its manifest")
+	if h.Configuration != nil {
+		configurer, ok := h.Client.MaschineResource.(sdk.Configurer)
+		require.True(h.t, ok)
+		require.NoError(h.t, configurer.Configure(ctx, h.Configuration), "configuration was rejected")
+	}
+	health, err := h.Client.HealthCheck(ctx, &sdk.HealthCheckRequest{Probe: sdk.ProbeReadiness})
+	require.NoError(h.t, err)
+	require.True(h.t, health.Healthy, "plugin is not ready: %s", health.Message)
+}
+
+// Execute runs req with the credentials and context of the host
+func (h *FakeHost) Execute(req *sdk.ExecuteRequest) *Result {
+	h.t.Helper()
+	return h.ExecuteContext(h.t.Context(), req)
+}
+
+// ExecuteContext runs req with ctx and the credentials and context of the
+// host. Like a host with strict output validation, it asserts that the
+// outputs of successful executions match the schema declared in the
+// manifest.
+func (h *FakeHost) ExecuteContext(ctx context.Context, req *sdk.ExecuteRequest) *Result {
+	h.t.Helper()
+	prepared := *req
+	if prepared.Credentials == nil {
+		prepared.Credentials = h.Credentials
+	}
+	if prepared.Context == nil {
+		prepared.Context = h.Context
+	}
+	result := h.Client.ExecuteContext(ctx, &prepared)
+	if def := resourceDef(h.Manifest, req.Resource); def != nil && !req.DryRun && result.Err == nil && result.Response.Error == "" {
+		result.assertOutputSchema(def)
+	}
+	return result
+}
+
+// Retry runs req until it succeeds or maxAttempts executions failed, with
+// the attempt number in the context like a host applying a retry policy.
+// It returns the result of the last attempt.
+func (h *FakeHost) Retry(req *sdk.ExecuteRequest, maxAttempts int) *Result {
+	h.t.Helper()
+	var result *Result
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		result = h.ExecuteContext(sdk.ContextWithAttempt(h.t.Context(), attempt), req)
+		if result.Err == nil && result.Response.Error == "" {
+			break
+		}
+	}
+	return result
+}
+
+// Session opens a session, runs fn with its ID and closes the session.
+// Executions in fn run in the session if they set the ID, e.g. with
+// RequestBuilder.Session.
+func (h *FakeHost) Session(fn func(id string)) {
+	h.t.Helper()
+	sessions, ok := h.Client.MaschineResource.(sdk.SessionClient)
+	require.True(h.t, ok)
+
+	id, err := sessions.OpenSession(h.t.Context(), &sdk.OpenSessionRequest{Context: h.Context})
+	require.NoError(h.t, err)
+	defer func() {
+		assert.NoError(h.t, sessions.CloseSession(h.t.Context(), id), "failed to close session")
+	}()
+	fn(id)
+}
+
+// RunExamples executes the examples of all resources of the manifest that
+// can be executed and requires them to succeed. With dryRun, actions run
+// as dry runs.
+func (h *FakeHost) RunExamples(dryRun bool) {
+	h.t.Helper()
+	for _, r := range h.Manifest.Resources {
+		if r.Category == "trigger" {
+			continue
+		}
+		for _, e := range r.Examples {
+			b := NewRequest(h.t, r.Type)
+			for name, value := range e.Parameters {
+				b.Param(name, value)
+			}
+			if dryRun && r.Category != "query" && r.Category != "check" {
+				b.DryRun()
+			}
+			result := h.Execute(b.Build())
+			require.NoError(h.t, result.Err, "example %q of %s", e.Name, r.Type)
+			assert.Empty(h.t, result.Response.Error, "example %q of %s failed", e.Name, r.Type)
+		}
+	}
+}
+
+// Shutdown shuts the plugin down like a host closing it, and requires
+// executions afterwards to be rejected
+func (h *FakeHost) Shutdown() {
+	h.t.Helper()
+	shutdowner, ok := h.Client.MaschineResource.(sdk.Shutdowner)
+	require.True(h.t, ok)
+	require.NoError(h.t, shutdowner.Shutdown(h.t.Context()))
+
+	_, err := h.Client.MaschineResource.Execute(h.t.Context(), &sdk.ExecuteRequest{Resource: "mrn:plugintest:after:shutdown"})
+	assert.True(h.t, errors.Is(err, sdk.ErrShuttingDown), "execution after shutdown returned %v", err)
+}
+
+// Lifecycle runs the standard scenario of a plugin's life: Start,
+// RunExamples without dry runs and Shutdown
+func (h *FakeHost) Lifecycle() {
+	h.t.Helper()
+	h.Start()
+	h.RunExamples(false)
+	h.Shutdown()
+}
@@ -0,0 +1,101 @@
+package plugintest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"maschine.io/plugin-sdk/sdk"
+	"maschine.io/plugin-sdk/sdk/manifest"
+)
+
+// hostedPlugin uses the services of the host: configuration, credentials,
+// sessions and retries
+type hostedPlugin struct {
+	*sdk.BasePlugin
+
+	mu       sync.Mutex
+	greeting string
+	sessions map[string]int
+}
+
+func newHostedPlugin() *hostedPlugin {
+	p := &hostedPlugin{BasePlugin: sdk.NewBasePlugin("greeter", "0.1.0"), sessions: make(map[string]int)}
+	p.RegisterSimpleFunction("mrn:greeter:greeting:send", func(ctx context.Context, req *sdk.TypedExecuteRequest) (any, error) {
+		var name string
+		if _, err := req.GetParameter("name", &name); err != nil {
+			return nil, err
+		}
+		if req.Credentials["token"] == "" {
+			return nil, errors.New("token is required")
+		}
+		if name == "flaky" && sdk.AttemptFromContext(ctx) < 2 {
+			return nil, sdk.WithErrorType(errors.New("try again"), sdk.ErrorTypeUnavailable)
+		}
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if s, ok := sdk.SessionFromContext(ctx); ok {
+			p.sessions[s.ID]++
+			return fmt.Sprintf("%s %s #%d", p.greeting, name, p.sessions[s.ID]), nil
+		}
+		return p.greeting + " " + name, nil
+	}, "Greets someone")
+	return p
+}
+
+func (p *hostedPlugin) Configure(ctx context.Context, req *sdk.ConfigureRequest) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.greeting = req.Environment["GREETING"]
+	return nil
+}
+
+func (p *hostedPlugin) OpenSession(ctx context.Context, s *sdk.Session) error {
+	return nil
+}
+
+func (p *hostedPlugin) CloseSession(ctx context.Context, s *sdk.Session) error {
+	return nil
+}
+
+func hostedManifest() *manifest.PluginManifest {
+	m := testManifest()
+	m.Resources[0].Output = &manifest.OutputDef{Type: "string", Schema: map[string]any{"pattern": "^Hi "}}
+	m.Resources[0].Examples = []manifest.Example{
+		{Name: "Greet Ada", Parameters: map[string]any{"name": "Ada"}},
+	}
+	return m
+}
+
+func TestFakeHost(t *testing.T) {
+	h := NewFakeHost(t, newHostedPlugin(), hostedManifest())
+	h.Configuration = &sdk.ConfigureRequest{Environment: map[string]string{"GREETING": "Hi"}}
+	h.Credentials = map[string]string{"token": "secret"}
+	h.Start()
+
+	h.Execute(NewRequest(t, "mrn:greeter:greeting:send").Param("name", "Ada").Build()).AssertOutput("Hi Ada")
+	h.Execute(NewRequest(t, "mrn:greeter:greeting:send").Param("name", "Ada").Credential("token", "").Build()).
+		AssertError("token is required")
+
+	flaky := NewRequest(t, "mrn:greeter:greeting:send").Param("name", "flaky").Build()
+	h.Execute(flaky).AssertErrorType(sdk.ErrorTypeUnavailable)
+	h.Retry(flaky, 3).AssertOutput("Hi flaky")
+
+	h.Session(func(id string) {
+		req := NewRequest(t, "mrn:greeter:greeting:send").Param("name", "Ada").Session(id).Build()
+		h.Execute(req).AssertOutput("Hi Ada #1")
+		h.Execute(req).AssertOutput("Hi Ada #2")
+	})
+
+	h.RunExamples(false)
+	h.Shutdown()
+}
+
+func TestFakeHostLifecycle(t *testing.T) {
+	h := NewFakeHost(t, newHostedPlugin(), hostedManifest())
+	h.Configuration = &sdk.ConfigureRequest{Environment: map[string]string{"GREETING": "Hi"}}
+	h.Credentials = map[string]string{"token": "secret"}
+	h.Lifecycle()
+}
@@ -0,0 +1,305 @@
+// Package plugintest runs Maschine plugins in unit tests.
+//
+// New serves a MaschineResource over go-plugin's in-memory gRPC connection
+// and returns a client that talks to it like a host does, so requests and
+// responses take the real protocol path: encoding, chunked transfer,
+// sessions and panics behave as in production.
+//
+//	func TestSend(t *testing.T) {
+//		c := plugintest.New(t, newMailPlugin())
+//		req := plugintest.NewRequest(t, "mrn:mail:smtp:send").
+//			Param("to", "ops@example.com").
+//			Build()
+//		c.Execute(req).RequireSuccess().AssertOutput(sendResult{Queued: true})
+//	}
+package plugintest
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/go-plugin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maschine.io/plugin-sdk/sdk"
+	"maschine.io/plugin-sdk/sdk/manifest"
+)
+
+// Client is a host client of a plugin served in-process. The embedded
+// MaschineResource implements the optional interfaces of the gRPC client as
+// well, like sdk.BatchExecutor and sdk.SessionClient.
+type Client struct {
+	sdk.MaschineResource
+	t testing.TB
+}
+
+// New serves impl over an in-memory gRPC connection. The connection is
+// closed when the test finishes.
+func New(t testing.TB, impl sdk.MaschineResource) *Client {
+	t.Helper()
+	return NewPlugin(t, &sdk.MaschinePlugin{Impl: impl})
+}
+
+// NewPlugin serves p over an in-memory gRPC connection, for tests of its
+// transfer, batch or session settings
+func NewPlugin(t testing.TB, p *sdk.MaschinePlugin) *Client {
+	t.Helper()
+
+	client, _ := plugin.TestPluginGRPCConn(t, false, map[string]plugin.Plugin{
+		sdk.PluginName: p,
+	})
+	// closing the client shuts the server down through its controller, a
+	// second Stop races with it
+	t.Cleanup(func() { client.Close() })
+
+	raw, err := client.Dispense(sdk.PluginName)
+	require.NoError(t, err)
+	return &Client{MaschineResource: raw.(sdk.MaschineResource), t: t}
+}
+
+// Execute runs req with the context of the test
+func (c *Client) Execute(req *sdk.ExecuteRequest) *Result {
+	c.t.Helper()
+	return c.ExecuteContext(c.t.Context(), req)
+}
+
+// ExecuteContext runs req with ctx, e.g. one with sdk.ContextWithAccept
+func (c *Client) ExecuteContext(ctx context.Context, req *sdk.ExecuteRequest) *Result {
+	c.t.Helper()
+	resp, err := c.MaschineResource.Execute(ctx, req)
+	return &Result{Response: resp, Err: err, resource: req.Resource, t: c.t}
+}
+
+// Manifest returns the manifest the plugin reports
+func (c *Client) Manifest() *manifest.PluginManifest {
+	c.t.Helper()
+	m, err := c.MaschineResource.(sdk.ManifestProvider).GetManifest(c.t.Context())
+	require.NoError(c.t, err)
+	return m
+}
+
+// RequestBuilder builds an ExecuteRequest from Go values. Encoding errors
+// fail the test.
+type RequestBuilder struct {
+	req *sdk.ExecuteRequest
+	t   testing.TB
+}
+
+// NewRequest starts a request for resource
+func NewRequest(t testing.TB, resource string) *RequestBuilder {
+	return &RequestBuilder{req: &sdk.ExecuteRequest{Resource: resource}, t: t}
+}
+
+// Input sets the JSON encoded v as input
+func (b *RequestBuilder) Input(v any) *RequestBuilder {
+	b.t.Helper()
+	data, err := json.Marshal(v)
+	require.NoError(b.t, err, "failed to encode input")
+	b.req.Input = data
+	b.req.ContentType = ""
+	return b
+}
+
+// InputAs sets v encoded with the codec of contentType as input
+func (b *RequestBuilder) InputAs(contentType string, v any) *RequestBuilder {
+	b.t.Helper()
+	require.NoError(b.t, b.req.SetInput(contentType, v))
+	return b
+}
+
+// Param sets the JSON encoded v as parameter name
+func (b *RequestBuilder) Param(name string, v any) *RequestBuilder {
+	b.t.Helper()
+	data, err := json.Marshal(v)
+	require.NoError(b.t, err, "failed to encode parameter %s", name)
+	if b.req.Parameters == nil {
+		b.req.Parameters = make(map[string][]byte)
+	}
+	b.req.Parameters[name] = data
+	delete(b.req.ParameterContentTypes, name)
+	return b
+}
+
+// ParamAs sets v encoded with the codec of contentType as parameter name
+func (b *RequestBuilder) ParamAs(name, contentType string, v any) *RequestBuilder {
+	b.t.Helper()
+	require.NoError(b.t, b.req.SetParameter(name, contentType, v))
+	return b
+}
+
+// Credential sets the credential field name
+func (b *RequestBuilder) Credential(name, value string) *RequestBuilder {
+	if b.req.Credentials == nil {
+		b.req.Credentials = make(map[string]string)
+	}
+	b.req.Credentials[name] = value
+	return b
+}
+
+// Context sets the context value key
+func (b *RequestBuilder) Context(key, value string) *RequestBuilder {
+	if b.req.Context == nil {
+		b.req.Context = make(map[string]string)
+	}
+	b.req.Context[key] = value
+	return b
+}
+
+// DryRun makes the request a dry run
+func (b *RequestBuilder) DryRun() *RequestBuilder {
+	b.req.DryRun = true
+	return b
+}
+
+// IdempotencyKey sets the idempotency key
+func (b *RequestBuilder) IdempotencyKey(key string) *RequestBuilder {
+	b.req.IdempotencyKey = key
+	return b
+}
+
+// Session runs the request in the session with the ID
+func (b *RequestBuilder) Session(id string) *RequestBuilder {
+	b.req.SessionID = id
+	return b
+}
+
+// Build returns the request
+func (b *RequestBuilder) Build() *sdk.ExecuteRequest {
+	return b.req
+}
+
+// Result is the outcome of an execution. Its assertions report failures
+// to the test and return the result, so they can be chained.
+type Result struct {
+	// Response is nil if Err is set
+	Response *sdk.ExecuteResponse
+	// Err is a failure of the call itself, like a crash of the plugin.
+	// Errors of the execution are reported in Response.Error.
+	Err error
+
+	resource string
+	t        testing.TB
+}
+
+// RequireSuccess stops the test unless the execution succeeded
+func (r *Result) RequireSuccess() *Result {
+	r.t.Helper()
+	require.NoError(r.t, r.Err)
+	require.Empty(r.t, r.Response.Error, "execution failed")
+	return r
+}
+
+// Output decodes the output into v with the codec of its content type
+func (r *Result) Output(v any) {
+	r.t.Helper()
+	r.RequireSuccess()
+	require.NoError(r.t, r.Response.DecodeOutput(v))
+}
+
+// AssertOutput asserts that the output decodes to expected. The output is
+// decoded into a value of the type of expected.
+func (r *Result) AssertOutput(expected any) *Result {
+	r.t.Helper()
+	r.RequireSuccess()
+	actual := reflect.New(reflect.TypeOf(expected))
+	if assert.NoError(r.t, r.Response.DecodeOutput(actual.Interface())) {
+		assert.Equal(r.t, expected, actual.Elem().Interface())
+	}
+	return r
+}
+
+// AssertOutputJSON asserts that the output is JSON equal to expected
+func (r *Result) AssertOutputJSON(expected string) *Result {
+	r.t.Helper()
+	r.RequireSuccess()
+	assert.JSONEq(r.t, expected, string(r.Response.Output))
+	return r
+}
+
+// AssertOutputSchema asserts that the execution succeeded and that its
+// output matches the output schema m declares for the resource, see
+// sdk.ValidateOutput. Violations are reported with JSON pointers into the
+// output.
+func (r *Result) AssertOutputSchema(m *manifest.PluginManifest) *Result {
+	r.t.Helper()
+	r.RequireSuccess()
+	def := resourceDef(m, r.resource)
+	if assert.NotNil(r.t, def, "manifest does not declare %s", r.resource) {
+		r.assertOutputSchema(def)
+	}
+	return r
+}
+
+func (r *Result) assertOutputSchema(def *manifest.ResourceDef) {
+	r.t.Helper()
+	assert.NoError(r.t, sdk.ValidateOutput(def, r.Response), "output of %s does not match its schema", r.resource)
+}
+
+// resourceDef returns the definition of resource in m or nil
+func resourceDef(m *manifest.PluginManifest, resource string) *manifest.ResourceDef {
+	for i := range m.Resources {
+		if m.Resources[i].Type == resource {
+			return &m.Resources[i]
+		}
+	}
+	return nil
+}
+
+// AssertError asserts that the execution failed with an error containing
+// contains
+func (r *Result) AssertError(contains string) *Result {
+	r.t.Helper()
+	if r.Err != nil {
+		assert.ErrorContains(r.t, r.Err, contains)
+		return r
+	}
+	if assert.NotEmpty(r.t, r.Response.Error, "execution succeeded") {
+		assert.Contains(r.t, r.Response.Error, contains)
+	}
+	return r
+}
+
+// AssertErrorType asserts that the execution failed with an error
+// classified as errorType, see sdk.WithErrorType
+func (r *Result) AssertErrorType(errorType string) *Result {
+	r.t.Helper()
+	require.NoError(r.t, r.Err)
+	assert.NotEmpty(r.t, r.Response.Error, "execution succeeded")
+	assert.Equal(r.t, errorType, r.Response.Metadata[sdk.MetadataErrorType])
+	return r
+}
+
+// AssertValidationErrors asserts that the request was rejected by
+// parameter validation with errors for exactly the fields
+func (r *Result) AssertValidationErrors(fields ...string) *Result {
+	r.t.Helper()
+	require.NoError(r.t, r.Err)
+	errors, ok := sdk.ValidationErrorsFromResponse(r.Response)
+	if !assert.True(r.t, ok, "request was not rejected by parameter validation") {
+		return r
+	}
+	actual := make([]string, 0, len(errors))
+	for _, e := range errors {
+		actual = append(actual, e.Field)
+	}
+	assert.ElementsMatch(r.t, fields, actual)
+	return r
+}
+
+// AssertMetadata asserts that the response carries the metadata value
+func (r *Result) AssertMetadata(key, value string) *Result {
+	r.t.Helper()
+	require.NoError(r.t, r.Err)
+	assert.Equal(r.t, value, r.Response.Metadata[key], "metadata %s", key)
+	return r
+}
+
+// AssertContentType asserts the content type of the output
+func (r *Result) AssertContentType(contentType string) *Result {
+	r.t.Helper()
+	require.NoError(r.t, r.Err)
+	assert.Equal(r.t, contentType, r.Response.ContentType)
+	return r
+}
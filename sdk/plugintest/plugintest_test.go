@@ -0,0 +1,140 @@
+package plugintest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maschine.io/plugin-sdk/sdk"
+	"maschine.io/plugin-sdk/sdk/manifest"
+)
+
+type greeting struct {
+	Text  string `json:"text"`
+	Count int    `json:"count"`
+}
+
+func testManifest() *manifest.PluginManifest {
+	m := manifest.New("greeter", "io.maschine.plugins.greeter")
+	m.Plugin.Description = "Greets people"
+	m.Resources = []manifest.ResourceDef{{
+		Type:        "mrn:greeter:greeting:send",
+		Name:        "Send greeting",
+		Description: "Greets someone",
+		Category:    "action",
+		Parameters: []manifest.Parameter{
+			{Name: "name", Type: "string", Required: true, Description: "Who to greet"},
+		},
+		Output: &manifest.OutputDef{Type: "object", Schema: map[string]any{
+			"properties": map[string]any{
+				"text":  map[string]any{"type": "string"},
+				"count": map[string]any{"type": "integer"},
+			},
+			"required": []string{"text"},
+		}},
+	}}
+	return m
+}
+
+func testPlugin() *sdk.BasePlugin {
+	p := sdk.NewBasePlugin("greeter", "1.0.0")
+	p.SetManifest(testManifest())
+	p.RegisterSimpleFunction("mrn:greeter:greeting:send", func(ctx context.Context, req *sdk.TypedExecuteRequest) (any, error) {
+		var name string
+		if _, err := req.GetParameter("name", &name); err != nil {
+			return nil, err
+		}
+		if name == "nobody" {
+			return nil, sdk.WithErrorType(errors.New("nobody is unavailable"), sdk.ErrorTypeUnavailable)
+		}
+		return greeting{Text: "Hello " + name, Count: len(req.Credentials)}, nil
+	}, "Greets someone")
+	return p
+}
+
+func TestExecute(t *testing.T) {
+	c := New(t, testPlugin())
+
+	req := NewRequest(t, "mrn:greeter:greeting:send").Param("name", "Ada").Credential("token", "secret").Build()
+	assert.Equal(t, []byte(`"Ada"`), req.Parameters["name"])
+	c.Execute(req).
+		RequireSuccess().
+		AssertContentType(sdk.ContentTypeJSON).
+		AssertOutput(greeting{Text: "Hello Ada", Count: 1}).
+		AssertOutputJSON(`{"text": "Hello Ada", "count": 1}`)
+
+	var out greeting
+	ctx := sdk.ContextWithAccept(context.Background(), sdk.ContentTypeMsgPack)
+	result := c.ExecuteContext(ctx, req).AssertContentType(sdk.ContentTypeMsgPack)
+	result.Output(&out)
+	assert.Equal(t, "Hello Ada", out.Text)
+
+	c.Execute(NewRequest(t, "mrn:greeter:greeting:send").Param("name", "nobody").Build()).
+		AssertError("nobody is unavailable").
+		AssertErrorType(sdk.ErrorTypeUnavailable)
+	c.Execute(NewRequest(t, "mrn:greeter:unknown").Build()).AssertError("unknown resource")
+
+	assert.Equal(t, "io.maschine.plugins.greeter", c.Manifest().Plugin.ID)
+}
+
+func TestValidationErrors(t *testing.T) {
+	p := testPlugin()
+	c := New(t, sdk.WithParameterValidation(p, testManifest()))
+
+	c.Execute(NewRequest(t, "mrn:greeter:greeting:send").Build()).AssertValidationErrors("parameters.name")
+	c.Execute(NewRequest(t, "mrn:greeter:greeting:send").Param("name", "Ada").Build()).RequireSuccess()
+}
+
+// failures records the failed assertions of a test instead of failing it
+type failures struct {
+	*testing.T
+	messages []string
+}
+
+func (f *failures) Errorf(format string, args ...any) {
+	f.messages = append(f.messages, fmt.Sprintf(format, args...))
+}
+
+func TestAssertOutputSchema(t *testing.T) {
+	c := New(t, testPlugin())
+	m := testManifest()
+	req := NewRequest(t, "mrn:greeter:greeting:send").Param("name", "Ada").Build()
+	c.Execute(req).AssertOutputSchema(m)
+
+	m.Resources[0].Output.Schema = map[string]any{
+		"properties": map[string]any{"count": map[string]any{"minimum": 1}},
+	}
+	f := &failures{T: t}
+	result := c.Execute(req)
+	result.t = f
+	result.AssertOutputSchema(m)
+	require.Len(t, f.messages, 1)
+	assert.Contains(t, f.messages[0], "/count: must be >= 1")
+}
+
+func TestRequestBuilder(t *testing.T) {
+	req := NewRequest(t, "mrn:greeter:greeting:send").
+		Input(map[string]int{"n": 1}).
+		ParamAs("name", sdk.ContentTypeText, "Ada").
+		Context("tenant", "acme").
+		DryRun().
+		IdempotencyKey("key-1").
+		Session("session-1").
+		Build()
+
+	assert.JSONEq(t, `{"n": 1}`, string(req.Input))
+	assert.Empty(t, req.ContentType)
+	assert.Equal(t, sdk.ContentTypeText, req.ParameterContentTypes["name"])
+	assert.Equal(t, "acme", req.Context["tenant"])
+	assert.True(t, req.DryRun)
+	assert.Equal(t, "key-1", req.IdempotencyKey)
+	assert.Equal(t, "session-1", req.SessionID)
+
+	req = NewRequest(t, "mrn:greeter:greeting:send").InputAs(sdk.ContentTypeCBOR, 1).Param("name", "Ada").Build()
+	assert.Equal(t, sdk.ContentTypeCBOR, req.ContentType)
+	require.Contains(t, req.Parameters, "name")
+	assert.NotContains(t, req.ParameterContentTypes, "name")
+}
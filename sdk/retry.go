@@ -0,0 +1,107 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"strconv"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// Error types of transient failures. Hosts retry executions that failed
+// with them if the retry policy of the resource allows it.
+const (
+	// ErrorTypeUnavailable marks failures of an unavailable dependency, like
+	// a refused connection
+	ErrorTypeUnavailable = "unavailable"
+	// ErrorTypeTimeout marks executions that ran out of time
+	ErrorTypeTimeout = "timeout"
+	// ErrorTypeRateLimited marks executions rejected by a rate limit
+	ErrorTypeRateLimited = "rate_limited"
+)
+
+// typedError is an error classified with an error type
+type typedError struct {
+	errorType string
+	err       error
+}
+
+func (e *typedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *typedError) Unwrap() error {
+	return e.err
+}
+
+// WithErrorType classifies err with an error type like ErrorTypeUnavailable.
+// The response of an execution that failed with it carries the type in
+// MetadataErrorType.
+func WithErrorType(err error, errorType string) error {
+	if err == nil {
+		return nil
+	}
+	return &typedError{errorType: errorType, err: err}
+}
+
+// ErrorType returns the error type err was classified with, empty if none
+func ErrorType(err error) string {
+	var typed *typedError
+	if errors.As(err, &typed) {
+		return typed.errorType
+	}
+	return ""
+}
+
+// errorResponse returns the response of an execution that failed with err
+func errorResponse(err error) *ExecuteResponse {
+	resp := &ExecuteResponse{Error: err.Error()}
+	if errorType := ErrorType(err); errorType != "" {
+		resp.Metadata = map[string]string{MetadataErrorType: errorType}
+	}
+	return resp
+}
+
+// attemptHeader is the gRPC metadata with the attempt number of an
+// execution
+const attemptHeader = "maschine-attempt"
+
+type attemptContextKey struct{}
+
+// ContextWithAttempt returns a copy of ctx with the attempt number of an
+// execution, starting at 1. Hosts set it for retries.
+func ContextWithAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, attemptContextKey{}, attempt)
+}
+
+// AttemptFromContext returns the attempt number of the execution, 1 unless
+// the host retried it
+func AttemptFromContext(ctx context.Context) int {
+	if attempt, ok := ctx.Value(attemptContextKey{}).(int); ok && attempt > 0 {
+		return attempt
+	}
+	return 1
+}
+
+// outgoingAttempt sends the attempt number of ctx to the plugin
+func outgoingAttempt(ctx context.Context) context.Context {
+	attempt := AttemptFromContext(ctx)
+	if attempt == 1 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, attemptHeader, strconv.Itoa(attempt))
+}
+
+// incomingAttempt makes the attempt number sent by the host available with
+// AttemptFromContext
+func incomingAttempt(ctx context.Context) context.Context {
+	values := metadata.ValueFromIncomingContext(ctx, attemptHeader)
+	if len(values) == 0 {
+		return ctx
+	}
+	attempt, err := strconv.Atoi(values[0])
+	if err != nil {
+		return ctx
+	}
+	return ContextWithAttempt(ctx, attempt)
+}
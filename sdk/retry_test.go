@@ -0,0 +1,42 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorType(t *testing.T) {
+	err := WithErrorType(errors.New("connection refused"), ErrorTypeUnavailable)
+	assert.EqualError(t, err, "connection refused")
+	assert.Equal(t, ErrorTypeUnavailable, ErrorType(err))
+	assert.Equal(t, ErrorTypeUnavailable, ErrorType(fmt.Errorf("failed to send mail: %w", err)))
+	assert.Empty(t, ErrorType(errors.New("invalid recipient")))
+	assert.NoError(t, WithErrorType(nil, ErrorTypeTimeout))
+}
+
+func TestGRPCAttempt(t *testing.T) {
+	p := NewBasePlugin("mail-plugin", "1.0.0")
+	require.NoError(t, p.RegisterSimpleFunction("mrn:mail:smtp:send", func(ctx context.Context, req *TypedExecuteRequest) (any, error) {
+		if attempt := AttemptFromContext(ctx); attempt < 2 {
+			return nil, WithErrorType(fmt.Errorf("attempt %d: connection refused", attempt), ErrorTypeUnavailable)
+		}
+		return "sent", nil
+	}, "Send a mail"))
+	client := dispenseTestClient(t, p)
+	req := &ExecuteRequest{Resource: "mrn:mail:smtp:send"}
+
+	resp, err := client.Execute(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, "attempt 1: connection refused", resp.Error)
+	assert.Equal(t, ErrorTypeUnavailable, resp.Metadata[MetadataErrorType])
+
+	resp, err = client.Execute(ContextWithAttempt(context.Background(), 2), req)
+	require.NoError(t, err)
+	assert.Empty(t, resp.Error)
+	assert.Equal(t, `"sent"`, string(resp.Output))
+}
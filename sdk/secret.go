@@ -0,0 +1,259 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"maschine.io/plugin-sdk/sdk/internal/redact"
+	"maschine.io/plugin-sdk/sdk/logger"
+	"maschine.io/plugin-sdk/sdk/manifest"
+)
+
+// Redacted is printed instead of secret values
+const Redacted = redact.Placeholder
+
+// Secret holds a sensitive value. It is redacted when printed, logged or
+// encoded as JSON; use Reveal to get the actual value.
+type Secret string
+
+// Reveal returns the secret value
+func (s Secret) Reveal() string {
+	return string(s)
+}
+
+// String returns a redacted placeholder
+func (s Secret) String() string {
+	return Redacted
+}
+
+// GoString returns a redacted placeholder
+func (s Secret) GoString() string {
+	return Redacted
+}
+
+// MarshalJSON encodes a redacted placeholder
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(Redacted)
+}
+
+// MarshalText encodes a redacted placeholder
+func (s Secret) MarshalText() ([]byte, error) {
+	return []byte(Redacted), nil
+}
+
+// Secret returns the credential with the given key as Secret
+func (r *ExecuteRequest) Secret(key string) Secret {
+	return Secret(r.Credentials[key])
+}
+
+// plainExecuteRequest has the fields of ExecuteRequest but none of its methods
+type plainExecuteRequest ExecuteRequest
+
+// String formats the request with all credential values redacted. It is
+// used for %v and %+v.
+func (r *ExecuteRequest) String() string {
+	return fmt.Sprintf("%+v", r.redacted())
+}
+
+// GoString formats the request with all credential values redacted. It is
+// used for %#v.
+func (r *ExecuteRequest) GoString() string {
+	return fmt.Sprintf("%#v", r.redacted())
+}
+
+func (r *ExecuteRequest) redacted() *plainExecuteRequest {
+	if r == nil {
+		return nil
+	}
+	c := plainExecuteRequest(*r)
+	if r.Credentials != nil {
+		c.Credentials = make(map[string]string, len(r.Credentials))
+		for k := range r.Credentials {
+			c.Credentials[k] = Redacted
+		}
+	}
+	return &c
+}
+
+// CredentialKey returns the key of a credential field in
+// ExecuteRequest.Credentials, e.g. "smtp_password" for the field "password"
+// of the credential set "smtp"
+func CredentialKey(set, field string) string {
+	return set + "_" + field
+}
+
+// SecretCredentialKeys returns the ExecuteRequest.Credentials keys of all
+// credential fields the configuration marks as secret
+func SecretCredentialKeys(cfg manifest.Configuration) map[string]bool {
+	keys := make(map[string]bool)
+	for _, set := range cfg.Credentials {
+		for _, f := range set.Fields {
+			if f.Secret {
+				keys[CredentialKey(set.Name, f.Name)] = true
+			}
+		}
+	}
+	return keys
+}
+
+// Scrubber removes secret values from responses before they reach the host
+type Scrubber struct {
+	secrets []string
+}
+
+// NewScrubber creates a scrubber for the given secret values. Values shorter
+// than four characters are ignored.
+func NewScrubber(secrets ...string) *Scrubber {
+	return &Scrubber{secrets: secrets}
+}
+
+// Scrub replaces all secret values in s
+func (s *Scrubber) Scrub(str string) string {
+	return redact.Replace(str, s.secrets)
+}
+
+// ScrubResponse replaces all secret values in the output, error and metadata
+// of resp. Outputs of binary content types like CBOR are left alone,
+// replacing bytes would corrupt them.
+func (s *Scrubber) ScrubResponse(resp *ExecuteResponse) {
+	if resp == nil || len(s.secrets) == 0 {
+		return
+	}
+	if len(resp.Output) > 0 && textual(resp.ContentType) {
+		resp.Output = []byte(s.Scrub(string(resp.Output)))
+	}
+	resp.Error = s.Scrub(resp.Error)
+	for k, v := range resp.Metadata {
+		resp.Metadata[k] = s.Scrub(v)
+	}
+}
+
+// scrubChange replaces all secret values in the fields of c
+func (s *Scrubber) scrubChange(c *Change) {
+	c.Action = s.Scrub(c.Action)
+	c.Target = s.Scrub(c.Target)
+	c.Description = s.Scrub(c.Description)
+	if c.Details != nil {
+		details := make(map[string]string, len(c.Details))
+		for k, v := range c.Details {
+			details[k] = s.Scrub(v)
+		}
+		c.Details = details
+	}
+}
+
+// textual reports whether payloads of contentType are text, like JSON or
+// text/plain
+func textual(contentType string) bool {
+	t := mediaType(contentType)
+	return t == "" || t == ContentTypeJSON || strings.HasSuffix(t, "+json") || strings.HasPrefix(t, "text/")
+}
+
+// WithSecretRedaction wraps impl so that the secret credentials of every
+// Execute and Plan call are redacted from the plugin logs for the duration
+// of the call, and scrubbed from the response and the returned error. Secret
+// credentials are the fields marked as secret in m; if m is nil, all
+// credentials are treated as secret.
+func WithSecretRedaction(impl MaschineResource, m *manifest.PluginManifest) MaschineResource {
+	r := &redactingResource{MaschineResource: impl}
+	if m != nil {
+		r.secretKeys = SecretCredentialKeys(m.Configuration)
+	}
+	return r
+}
+
+type redactingResource struct {
+	MaschineResource
+	// secretKeys is nil if all credentials are secret
+	secretKeys map[string]bool
+}
+
+// Unwrap returns the wrapped resource
+func (r *redactingResource) Unwrap() MaschineResource {
+	return r.MaschineResource
+}
+
+// secrets returns the values of the secret credentials of req
+func (r *redactingResource) secrets(req *ExecuteRequest) []string {
+	var secrets []string
+	for k, v := range req.Credentials {
+		if r.secretKeys == nil || r.secretKeys[k] {
+			secrets = append(secrets, v)
+		}
+	}
+	return secrets
+}
+
+func (r *redactingResource) Execute(ctx context.Context, req *ExecuteRequest) (*ExecuteResponse, error) {
+	secrets := r.secrets(req)
+	if len(secrets) == 0 {
+		return r.MaschineResource.Execute(ctx, req)
+	}
+
+	remove := logger.AddSecrets(secrets...)
+	defer remove()
+
+	scrubber := NewScrubber(secrets...)
+	defer func() {
+		// the panic is recovered by the gRPC server, scrub its value first
+		if v := recover(); v != nil {
+			panic(scrubber.Scrub(fmt.Sprint(v)))
+		}
+	}()
+
+	resp, err := r.MaschineResource.Execute(ctx, req)
+	if err != nil {
+		return nil, &redactedError{msg: scrubber.Scrub(err.Error()), err: err}
+	}
+	scrubber.ScrubResponse(resp)
+	return resp, nil
+}
+
+// Plan redacts the secret credentials of req like Execute, from the plugin
+// logs, the planned changes and the returned error
+func (r *redactingResource) Plan(ctx context.Context, req *ExecuteRequest) ([]Change, error) {
+	planner, ok := lookup[Planner](r.MaschineResource)
+	if !ok {
+		return nil, ErrDryRunNotSupported
+	}
+	secrets := r.secrets(req)
+	if len(secrets) == 0 {
+		return planner.Plan(ctx, req)
+	}
+
+	remove := logger.AddSecrets(secrets...)
+	defer remove()
+
+	scrubber := NewScrubber(secrets...)
+	defer func() {
+		if v := recover(); v != nil {
+			panic(scrubber.Scrub(fmt.Sprint(v)))
+		}
+	}()
+
+	changes, err := planner.Plan(ctx, req)
+	if err != nil {
+		return nil, &redactedError{msg: scrubber.Scrub(err.Error()), err: err}
+	}
+	for i := range changes {
+		scrubber.scrubChange(&changes[i])
+	}
+	return changes, nil
+}
+
+// redactedError reports a scrubbed message but still matches the original
+// error with errors.Is and errors.As
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string {
+	return e.msg
+}
+
+func (e *redactedError) Unwrap() error {
+	return e.err
+}
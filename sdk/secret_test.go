@@ -0,0 +1,148 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maschine.io/plugin-sdk/sdk/logger"
+	"maschine.io/plugin-sdk/sdk/manifest"
+)
+
+func TestSecret(t *testing.T) {
+	s := Secret("hunter2-password")
+
+	assert.Equal(t, "hunter2-password", s.Reveal())
+	assert.Equal(t, Redacted, fmt.Sprint(s))
+	assert.Equal(t, Redacted, fmt.Sprintf("%#v", s))
+
+	data, err := json.Marshal(map[string]any{"password": s})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"password":"[REDACTED]"}`, string(data))
+}
+
+func TestExecuteRequestFormatting(t *testing.T) {
+	req := &ExecuteRequest{
+		Resource:    "mrn:mail:smtp:send",
+		Credentials: map[string]string{"smtp_password": "hunter2-password"},
+	}
+
+	for _, format := range []string{"%v", "%+v", "%#v", "%s"} {
+		out := fmt.Sprintf(format, req)
+		assert.NotContains(t, out, "hunter2-password", format)
+		assert.Contains(t, out, "mrn:mail:smtp:send", format)
+	}
+	assert.Equal(t, "hunter2-password", req.Credentials["smtp_password"], "request is not modified")
+	assert.Equal(t, Secret("hunter2-password"), req.Secret("smtp_password"))
+}
+
+func TestWithSecretRedaction(t *testing.T) {
+	m := testMailManifest()
+	m.Configuration.Credentials = []manifest.CredentialSet{
+		{
+			Name: "smtp",
+			Fields: []manifest.CredentialField{
+				{Name: "user", Type: "string"},
+				{Name: "password", Type: "string", Secret: true},
+			},
+		},
+	}
+	assert.Equal(t, map[string]bool{"smtp_password": true}, SecretCredentialKeys(m.Configuration))
+
+	var logged string
+	p := NewBasePlugin("mail-plugin", "1.0.0")
+	require.NoError(t, p.RegisterSimpleFunction("mrn:mail:smtp:send", func(ctx context.Context, req *TypedExecuteRequest) (any, error) {
+		logged = logger.Redact("login with " + req.Credentials["smtp_password"])
+		return map[string]string{
+			"user":  req.Credentials["smtp_user"],
+			"debug": "auth " + req.Credentials["smtp_password"],
+		}, nil
+	}, "Send an email"))
+	require.NoError(t, p.RegisterSimpleFunction("mrn:mail:smtp:fail", func(ctx context.Context, req *TypedExecuteRequest) (any, error) {
+		return nil, errors.New("login failed for hunter2-password")
+	}, "Fail"))
+
+	req := &ExecuteRequest{
+		Resource: "mrn:mail:smtp:send",
+		Credentials: map[string]string{
+			"smtp_user":     "mailer-user",
+			"smtp_password": "hunter2-password",
+		},
+	}
+
+	t.Run("manifest secrets", func(t *testing.T) {
+		resp, err := WithSecretRedaction(p, m).Execute(context.Background(), req)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"user":"mailer-user","debug":"auth [REDACTED]"}`, string(resp.Output))
+		assert.Equal(t, "login with [REDACTED]", logged)
+		assert.Equal(t, "login with hunter2-password", logger.Redact("login with hunter2-password"), "secrets are unregistered after the call")
+	})
+
+	t.Run("all credentials without manifest", func(t *testing.T) {
+		resp, err := WithSecretRedaction(p, nil).Execute(context.Background(), req)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"user":"[REDACTED]","debug":"auth [REDACTED]"}`, string(resp.Output))
+	})
+
+	t.Run("binary output", func(t *testing.T) {
+		output := append([]byte{0xa1, 0x70}, "hunter2-password"...)
+		res := WithSecretRedaction(&staticResource{
+			BasePlugin: p,
+			resp: &ExecuteResponse{
+				Output:      output,
+				ContentType: ContentTypeCBOR,
+				Metadata:    map[string]string{"login": "hunter2-password"},
+			},
+		}, m)
+		resp, err := res.Execute(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, output, resp.Output, "binary payloads are not corrupted")
+		assert.Equal(t, Redacted, resp.Metadata["login"])
+
+		resp, err = WithSecretRedaction(&staticResource{
+			BasePlugin: p,
+			resp:       &ExecuteResponse{Output: []byte("auth hunter2-password"), ContentType: "text/plain; charset=utf-8"},
+		}, m).Execute(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, "auth [REDACTED]", string(resp.Output))
+	})
+
+	t.Run("error messages", func(t *testing.T) {
+		failing := *req
+		failing.Resource = "mrn:mail:smtp:fail"
+		resp, err := WithSecretRedaction(p, m).Execute(context.Background(), &failing)
+		require.NoError(t, err)
+		assert.Equal(t, "login failed for [REDACTED]", resp.Error)
+
+		errLogin := fmt.Errorf("login failed for %s", req.Credentials["smtp_password"])
+		_, err = WithSecretRedaction(&failingResource{BasePlugin: p, err: errLogin}, m).Execute(context.Background(), req)
+		require.Error(t, err)
+		assert.Equal(t, "login failed for [REDACTED]", err.Error())
+		assert.ErrorIs(t, err, errLogin)
+	})
+}
+
+// failingResource fails every execution with err instead of a response
+type failingResource struct {
+	*BasePlugin
+	err error
+}
+
+func (r *failingResource) Execute(ctx context.Context, req *ExecuteRequest) (*ExecuteResponse, error) {
+	return nil, r.err
+}
+
+func TestLookupThroughMiddlewares(t *testing.T) {
+	m := testMailManifest()
+	res := WithSecretRedaction(WithParameterValidation(NewBasePlugin("mail-plugin", "1.0.0"), m), m)
+
+	provider, ok := lookup[ManifestProvider](res)
+	require.True(t, ok)
+	reported, err := provider.GetManifest(context.Background())
+	require.NoError(t, err)
+	assert.Same(t, m, reported)
+}
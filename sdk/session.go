@@ -0,0 +1,286 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	pluginv1 "maschine.io/plugin-sdk/proto/plugin/v1"
+	"maschine.io/plugin-sdk/sdk/logger"
+)
+
+// DefaultSessionTTL is the default for MaschinePlugin.SessionTTL
+const DefaultSessionTTL = 15 * time.Minute
+
+// ErrSessionNotFound is returned for executions in a session that was
+// closed, expired or never opened
+var ErrSessionNotFound = errors.New("session not found")
+
+// ErrSessionsNotSupported is returned by OpenSession if the plugin does not
+// implement SessionHandler
+var ErrSessionsNotSupported = errors.New("plugin does not support sessions")
+
+// Session is state a plugin keeps across executions, like a login session
+// or an open transaction
+type Session struct {
+	ID string
+	// Context is the context the session was opened with
+	Context map[string]string
+	// State is set by SessionHandler.OpenSession and kept until the session
+	// is closed
+	State any
+}
+
+// SessionHandler is implemented by plugins that are not stateless.
+// OpenSession sets up the state of a new session, CloseSession releases it
+// when the host closes the session or it expired. Executions in a session
+// get it with SessionFromContext.
+type SessionHandler interface {
+	OpenSession(ctx context.Context, s *Session) error
+	CloseSession(ctx context.Context, s *Session) error
+}
+
+// SessionClient is implemented by the gRPC client, so hosts can type assert
+// a dispensed MaschineResource to SessionClient
+type SessionClient interface {
+	// OpenSession opens a session and returns its ID. Executions with the
+	// ID in ExecuteRequest.SessionID run in the session.
+	OpenSession(ctx context.Context, req *OpenSessionRequest) (string, error)
+	// CloseSession closes a session
+	CloseSession(ctx context.Context, id string) error
+}
+
+// OpenSessionRequest is the request to open a session
+type OpenSessionRequest struct {
+	Context map[string]string
+	// TTL is the idle time after which the session expires. Zero uses the
+	// plugin default.
+	TTL time.Duration
+}
+
+type sessionContextKey struct{}
+
+// SessionFromContext returns the session of the current execution
+func SessionFromContext(ctx context.Context) (*Session, bool) {
+	s, ok := ctx.Value(sessionContextKey{}).(*Session)
+	return s, ok
+}
+
+// sessionManager tracks the open sessions of a plugin. Sessions expire
+// after their TTL without executions.
+type sessionManager struct {
+	ttl time.Duration
+
+	mu       sync.Mutex
+	sessions map[string]*sessionEntry
+}
+
+type sessionEntry struct {
+	session *Session
+	handler SessionHandler
+	ttl     time.Duration
+	// running executions, the session does not expire while in use
+	inUse int
+	timer *time.Timer
+}
+
+func newSessionManager(ttl time.Duration) *sessionManager {
+	if ttl <= 0 {
+		ttl = DefaultSessionTTL
+	}
+	return &sessionManager{ttl: ttl, sessions: make(map[string]*sessionEntry)}
+}
+
+// open creates a session with handler and starts its TTL
+func (m *sessionManager) open(ctx context.Context, handler SessionHandler, sessionContext map[string]string, ttl time.Duration) (*Session, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	if ttl <= 0 {
+		ttl = m.ttl
+	}
+
+	s := &Session{ID: id, Context: sessionContext}
+	if err := handler.OpenSession(ctx, s); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[id] = &sessionEntry{
+		session: s,
+		handler: handler,
+		ttl:     ttl,
+		timer:   time.AfterFunc(ttl, func() { m.expire(id) }),
+	}
+	return s, nil
+}
+
+// acquire returns the session for an execution. The session does not
+// expire until release is called.
+func (m *sessionManager) acquire(id string) (*Session, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, found := m.sessions[id]
+	if !found {
+		return nil, false
+	}
+	e.inUse++
+	e.timer.Stop()
+	return e.session, true
+}
+
+// release restarts the TTL of the session once no execution uses it
+func (m *sessionManager) release(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, found := m.sessions[id]
+	if !found {
+		return
+	}
+	e.inUse--
+	if e.inUse == 0 {
+		e.timer.Reset(e.ttl)
+	}
+}
+
+// remove removes the session and returns it
+func (m *sessionManager) remove(id string) (*sessionEntry, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	e, found := m.sessions[id]
+	if !found {
+		return nil, false
+	}
+	e.timer.Stop()
+	delete(m.sessions, id)
+	return e, true
+}
+
+// close removes the session and releases its state
+func (m *sessionManager) close(ctx context.Context, id string) error {
+	e, found := m.remove(id)
+	if !found {
+		return ErrSessionNotFound
+	}
+	return e.handler.CloseSession(ctx, e.session)
+}
+
+// expire closes a session whose TTL passed
+func (m *sessionManager) expire(id string) {
+	m.mu.Lock()
+	e, found := m.sessions[id]
+	if !found || e.inUse > 0 {
+		m.mu.Unlock()
+		return
+	}
+	delete(m.sessions, id)
+	m.mu.Unlock()
+
+	logger.Get().Debug("session expired", "session_id", id)
+	if err := e.handler.CloseSession(context.Background(), e.session); err != nil {
+		logger.Get().Warn("failed to close expired session", "session_id", id, "error", err)
+	}
+}
+
+// closeAll closes all sessions
+func (m *sessionManager) closeAll(ctx context.Context) {
+	m.mu.Lock()
+	ids := sortedKeys(m.sessions)
+	m.mu.Unlock()
+
+	for _, id := range ids {
+		if err := m.close(ctx, id); err != nil && !errors.Is(err, ErrSessionNotFound) {
+			logger.Get().Warn("failed to close session", "session_id", id, "error", err)
+		}
+	}
+}
+
+func (s *grpcServer) OpenSession(ctx context.Context, req *pluginv1.OpenSessionRequest) (_ *pluginv1.OpenSessionResponse, err error) {
+	defer recoverPanic("OpenSession", &err)
+
+	handler, ok := lookup[SessionHandler](s.Impl)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, ErrSessionsNotSupported.Error())
+	}
+
+	session, err := s.sessions.open(ctx, handler, req.Context, req.Ttl.AsDuration())
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "failed to open session: %v", err)
+	}
+	return &pluginv1.OpenSessionResponse{SessionId: session.ID}, nil
+}
+
+func (s *grpcServer) CloseSession(ctx context.Context, req *pluginv1.CloseSessionRequest) (_ *pluginv1.CloseSessionResponse, err error) {
+	defer recoverPanic("CloseSession", &err)
+
+	err = s.sessions.close(ctx, req.SessionId)
+	if errors.Is(err, ErrSessionNotFound) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to close session: %v", err)
+	}
+	return &pluginv1.CloseSessionResponse{}, nil
+}
+
+// withSession acquires the session of an execution and adds it to ctx. The
+// returned func releases the session.
+func (s *grpcServer) withSession(ctx context.Context, id string) (context.Context, func(), error) {
+	if id == "" {
+		return ctx, func() {}, nil
+	}
+
+	session, found := s.sessions.acquire(id)
+	if !found {
+		return nil, nil, ErrSessionNotFound
+	}
+	return context.WithValue(ctx, sessionContextKey{}, session), func() { s.sessions.release(id) }, nil
+}
+
+// sessionResource runs the executions of a batch in their sessions
+type sessionResource struct {
+	MaschineResource
+	server *grpcServer
+}
+
+func (r *sessionResource) Execute(ctx context.Context, req *ExecuteRequest) (*ExecuteResponse, error) {
+	ctx, release, err := r.server.withSession(ctx, req.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return r.MaschineResource.Execute(withExecutionLogger(ctx, req), req)
+}
+
+// OpenSession opens a session in the plugin. It returns
+// ErrSessionsNotSupported if the plugin does not implement SessionHandler.
+func (c *grpcClient) OpenSession(ctx context.Context, req *OpenSessionRequest) (string, error) {
+	pbReq := &pluginv1.OpenSessionRequest{Context: req.Context}
+	if req.TTL > 0 {
+		pbReq.Ttl = durationpb.New(req.TTL)
+	}
+
+	resp, err := c.client.OpenSession(ctx, pbReq)
+	if status.Code(err) == codes.Unimplemented {
+		return "", ErrSessionsNotSupported
+	}
+	if err != nil {
+		return "", clientError(err)
+	}
+	return resp.SessionId, nil
+}
+
+// CloseSession closes a session in the plugin
+func (c *grpcClient) CloseSession(ctx context.Context, id string) error {
+	_, err := c.client.CloseSession(ctx, &pluginv1.CloseSessionRequest{SessionId: id})
+	return clientError(err)
+}
@@ -0,0 +1,147 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loginPlugin logs in per session and records closed sessions
+type loginPlugin struct {
+	*BasePlugin
+
+	mu     sync.Mutex
+	closed []string
+}
+
+func newLoginPlugin(t *testing.T) *loginPlugin {
+	t.Helper()
+
+	p := &loginPlugin{BasePlugin: NewBasePlugin("login-plugin", "1.0.0")}
+	require.NoError(t, p.RegisterSimpleFunction("mrn:login:mailbox:list", func(ctx context.Context, req *TypedExecuteRequest) (any, error) {
+		s, ok := SessionFromContext(ctx)
+		if !ok {
+			return nil, errors.New("not logged in")
+		}
+		return s.State, nil
+	}, "List the mailbox"))
+	return p
+}
+
+func (p *loginPlugin) OpenSession(ctx context.Context, s *Session) error {
+	if s.Context["user"] == "" {
+		return errors.New("user is required")
+	}
+	s.State = "logged in as " + s.Context["user"]
+	return nil
+}
+
+func (p *loginPlugin) CloseSession(ctx context.Context, s *Session) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = append(p.closed, s.ID)
+	return nil
+}
+
+func (p *loginPlugin) closedSessions() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.closed...)
+}
+
+func TestGRPCSession(t *testing.T) {
+	impl := newLoginPlugin(t)
+	client := dispenseTestClient(t, impl)
+	sessions := client.(SessionClient)
+	ctx := context.Background()
+
+	_, err := sessions.OpenSession(ctx, &OpenSessionRequest{})
+	assert.ErrorContains(t, err, "user is required")
+
+	id, err := sessions.OpenSession(ctx, &OpenSessionRequest{Context: map[string]string{"user": "alice"}})
+	require.NoError(t, err)
+
+	resp, err := client.Execute(ctx, &ExecuteRequest{Resource: "mrn:login:mailbox:list", SessionID: id})
+	require.NoError(t, err)
+	assert.JSONEq(t, `"logged in as alice"`, string(resp.Output))
+
+	resp, err = client.Execute(ctx, &ExecuteRequest{Resource: "mrn:login:mailbox:list"})
+	require.NoError(t, err)
+	assert.Equal(t, "not logged in", resp.Error)
+
+	resps, err := client.(BatchExecutor).ExecuteBatch(ctx, []*ExecuteRequest{
+		{Resource: "mrn:login:mailbox:list", SessionID: id},
+		{Resource: "mrn:login:mailbox:list", SessionID: "unknown"},
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `"logged in as alice"`, string(resps[0].Output))
+	assert.Equal(t, ErrSessionNotFound.Error(), resps[1].Error)
+
+	require.NoError(t, sessions.CloseSession(ctx, id))
+	assert.Equal(t, []string{id}, impl.closedSessions())
+
+	_, err = client.Execute(ctx, &ExecuteRequest{Resource: "mrn:login:mailbox:list", SessionID: id})
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+	assert.ErrorIs(t, sessions.CloseSession(ctx, id), ErrSessionNotFound)
+}
+
+func TestGRPCSessionExpires(t *testing.T) {
+	impl := newLoginPlugin(t)
+	client := dispenseTestPlugin(t, &MaschinePlugin{Impl: impl, SessionTTL: time.Hour})
+	sessions := client.(SessionClient)
+	ctx := context.Background()
+
+	id, err := sessions.OpenSession(ctx, &OpenSessionRequest{
+		Context: map[string]string{"user": "bob"},
+		TTL:     50 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		return len(impl.closedSessions()) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	_, err = client.Execute(ctx, &ExecuteRequest{Resource: "mrn:login:mailbox:list", SessionID: id})
+	assert.ErrorIs(t, err, ErrSessionNotFound)
+}
+
+func TestGRPCSessionClosedOnShutdown(t *testing.T) {
+	impl := newLoginPlugin(t)
+	client := dispenseTestClient(t, impl)
+
+	id, err := client.(SessionClient).OpenSession(context.Background(), &OpenSessionRequest{Context: map[string]string{"user": "carol"}})
+	require.NoError(t, err)
+
+	require.NoError(t, client.(Shutdowner).Shutdown(context.Background()))
+	assert.Equal(t, []string{id}, impl.closedSessions())
+}
+
+func TestGRPCSessionsNotSupported(t *testing.T) {
+	client := dispenseTestClient(t, NewBasePlugin("stateless-plugin", "1.0.0"))
+
+	_, err := client.(SessionClient).OpenSession(context.Background(), &OpenSessionRequest{})
+	assert.ErrorIs(t, err, ErrSessionsNotSupported)
+}
+
+func TestSessionManagerKeepsSessionsInUse(t *testing.T) {
+	m := newSessionManager(20 * time.Millisecond)
+	impl := &loginPlugin{}
+
+	s, err := m.open(context.Background(), impl, map[string]string{"user": "dave"}, 0)
+	require.NoError(t, err)
+
+	_, found := m.acquire(s.ID)
+	require.True(t, found)
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, impl.closedSessions(), "sessions in use do not expire")
+
+	m.release(s.ID)
+	require.Eventually(t, func() bool {
+		return len(impl.closedSessions()) == 1
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,122 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	pluginv1 "maschine.io/plugin-sdk/proto/plugin/v1"
+)
+
+// Shutdowner is implemented by plugins that release resources, like SMTP
+// sessions or open files, before the plugin process exits. Shutdown is
+// called after all in-flight executions finished or the deadline of ctx
+// passed, and after all sessions were closed.
+type Shutdowner interface {
+	Shutdown(ctx context.Context) error
+}
+
+// ErrShuttingDown is returned for executions started after Shutdown
+var ErrShuttingDown = errors.New("plugin is shutting down")
+
+// ErrShutdownIncomplete is returned by the gRPC client if executions were
+// still running at the shutdown deadline
+var ErrShutdownIncomplete = errors.New("executions still running at shutdown deadline")
+
+// shutdownGrace is how long the client waits past the shutdown deadline for
+// the plugin to release its resources and reply
+const shutdownGrace = 2 * time.Second
+
+// begin registers an execution. It returns false once the server drains.
+func (s *grpcServer) begin() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.draining {
+		return false
+	}
+	s.active++
+	s.inflight.Add(1)
+	return true
+}
+
+// end unregisters an execution registered with begin
+func (s *grpcServer) end() {
+	s.mu.Lock()
+	s.active--
+	s.mu.Unlock()
+	s.inflight.Done()
+}
+
+func (s *grpcServer) Shutdown(ctx context.Context, req *pluginv1.ShutdownRequest) (_ *pluginv1.ShutdownResponse, err error) {
+	defer recoverPanic("Shutdown", &err)
+
+	s.mu.Lock()
+	s.draining = true
+	s.mu.Unlock()
+	s.events.close()
+
+	drain := ctx
+	if req.Deadline != nil {
+		var cancel context.CancelFunc
+		drain, cancel = context.WithDeadline(ctx, req.Deadline.AsTime())
+		defer cancel()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		s.inflight.Wait()
+		close(done)
+	}()
+
+	resp := &pluginv1.ShutdownResponse{}
+	select {
+	case <-done:
+	case <-drain.Done():
+		resp.Unfinished = int32(s.running())
+	}
+
+	s.sessions.closeAll(ctx)
+
+	if shutdowner, ok := lookup[Shutdowner](s.Impl); ok {
+		if err := shutdowner.Shutdown(ctx); err != nil {
+			return nil, status.Errorf(codes.Internal, "shutdown failed: %v", err)
+		}
+	}
+	return resp, nil
+}
+
+// running returns the number of in-flight executions
+func (s *grpcServer) running() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.active
+}
+
+// Shutdown asks the plugin to stop accepting executions and to finish the
+// in-flight ones until the deadline of ctx. It returns ErrShutdownIncomplete
+// if executions were still running at the deadline.
+func (c *grpcClient) Shutdown(ctx context.Context) error {
+	req := &pluginv1.ShutdownRequest{}
+	if deadline, ok := ctx.Deadline(); ok {
+		req.Deadline = timestamppb.New(deadline)
+
+		// the plugin drains until the deadline, leave it time to reply
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(context.WithoutCancel(ctx), deadline.Add(shutdownGrace))
+		defer cancel()
+	}
+
+	resp, err := c.client.Shutdown(ctx, req)
+	if err != nil {
+		return clientError(err)
+	}
+	if resp.Unfinished > 0 {
+		return fmt.Errorf("%w: %d", ErrShutdownIncomplete, resp.Unfinished)
+	}
+	return nil
+}
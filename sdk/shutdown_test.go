@@ -0,0 +1,97 @@
+package sdk
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingPlugin blocks executions until release is closed and records
+// whether Shutdown was called
+type blockingPlugin struct {
+	*BasePlugin
+	started  chan struct{}
+	release  chan struct{}
+	shutdown chan struct{}
+}
+
+func newBlockingPlugin(t *testing.T) *blockingPlugin {
+	t.Helper()
+
+	p := &blockingPlugin{
+		BasePlugin: NewBasePlugin("blocking-plugin", "1.0.0"),
+		started:    make(chan struct{}, 1),
+		release:    make(chan struct{}),
+		shutdown:   make(chan struct{}),
+	}
+	require.NoError(t, p.RegisterSimpleFunction("mrn:blocking:mail:send", func(ctx context.Context, req *TypedExecuteRequest) (any, error) {
+		p.started <- struct{}{}
+		<-p.release
+		return "sent", nil
+	}, "Send and block until released"))
+	return p
+}
+
+func (p *blockingPlugin) Shutdown(ctx context.Context) error {
+	close(p.shutdown)
+	return nil
+}
+
+func TestGRPCShutdownDrains(t *testing.T) {
+	impl := newBlockingPlugin(t)
+	client := dispenseTestClient(t, impl)
+
+	result := make(chan *ExecuteResponse)
+	go func() {
+		resp, _ := client.Execute(context.Background(), &ExecuteRequest{Resource: "mrn:blocking:mail:send"})
+		result <- resp
+	}()
+	<-impl.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	shutdownErr := make(chan error)
+	go func() {
+		shutdownErr <- client.(Shutdowner).Shutdown(ctx)
+	}()
+
+	// new executions are rejected while draining
+	require.Eventually(t, func() bool {
+		_, err := client.Execute(context.Background(), &ExecuteRequest{Resource: "mrn:blocking:mail:send"})
+		return err == ErrShuttingDown
+	}, time.Second, 10*time.Millisecond)
+
+	select {
+	case <-impl.shutdown:
+		t.Fatal("Shutdown called before in-flight execution finished")
+	default:
+	}
+
+	close(impl.release)
+	resp := <-result
+	require.NotNil(t, resp)
+	assert.JSONEq(t, `"sent"`, string(resp.Output))
+
+	require.NoError(t, <-shutdownErr)
+	<-impl.shutdown
+}
+
+func TestGRPCShutdownDeadline(t *testing.T) {
+	impl := newBlockingPlugin(t)
+	defer close(impl.release)
+	client := dispenseTestClient(t, impl)
+
+	go client.Execute(context.Background(), &ExecuteRequest{Resource: "mrn:blocking:mail:send"})
+	<-impl.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	err := client.(Shutdowner).Shutdown(ctx)
+	assert.ErrorIs(t, err, ErrShutdownIncomplete)
+	<-impl.shutdown
+}
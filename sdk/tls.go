@@ -0,0 +1,124 @@
+package sdk
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Environment variables that pass operator-supplied certificates to the
+// plugin process
+const (
+	EnvTLSCA   = "MASCHINE_PLUGIN_TLS_CA"
+	EnvTLSCert = "MASCHINE_PLUGIN_TLS_CERT"
+	EnvTLSKey  = "MASCHINE_PLUGIN_TLS_KEY"
+)
+
+// DefaultTLSServerName is the default for TLSFiles.ServerName
+const DefaultTLSServerName = "localhost"
+
+// TLSFiles are the PEM files of an operator-supplied CA and certificate.
+// Host and plugin authenticate each other with certificates signed by the
+// CA.
+type TLSFiles struct {
+	// CAFile is the CA bundle that signed the certificates of host and
+	// plugin
+	CAFile string
+	// CertFile and KeyFile are the certificate and key of this side of the
+	// connection
+	CertFile string
+	KeyFile  string
+	// ServerName is the name the certificate of the plugin is issued for.
+	// Only used by the host. Defaults to DefaultTLSServerName.
+	ServerName string
+}
+
+// TLSFilesFromEnv returns the certificate files passed to the plugin
+// process. It returns false if no files were passed.
+func TLSFilesFromEnv() (TLSFiles, bool) {
+	files := TLSFiles{
+		CAFile:   os.Getenv(EnvTLSCA),
+		CertFile: os.Getenv(EnvTLSCert),
+		KeyFile:  os.Getenv(EnvTLSKey),
+	}
+	return files, files.CAFile != ""
+}
+
+// Environ returns the environment variables that pass the files to the
+// plugin process
+func (f TLSFiles) Environ() []string {
+	return []string{
+		EnvTLSCA + "=" + f.CAFile,
+		EnvTLSCert + "=" + f.CertFile,
+		EnvTLSKey + "=" + f.KeyFile,
+	}
+}
+
+// load reads the CA bundle and the key pair
+func (f TLSFiles) load() (*x509.CertPool, tls.Certificate, error) {
+	if f.CAFile == "" || f.CertFile == "" || f.KeyFile == "" {
+		return nil, tls.Certificate{}, errors.New("CA, certificate and key files are required")
+	}
+
+	ca, err := os.ReadFile(f.CAFile)
+	if err != nil {
+		return nil, tls.Certificate{}, fmt.Errorf("failed to read CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, tls.Certificate{}, fmt.Errorf("no certificates found in %s", f.CAFile)
+	}
+
+	cert, err := tls.LoadX509KeyPair(f.CertFile, f.KeyFile)
+	if err != nil {
+		return nil, tls.Certificate{}, fmt.Errorf("failed to load certificate: %w", err)
+	}
+	return pool, cert, nil
+}
+
+// ServerConfig returns the TLS configuration of the plugin server. Only
+// clients with a certificate signed by the CA are accepted.
+func (f TLSFiles) ServerConfig() (*tls.Config, error) {
+	pool, cert, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// ClientConfig returns the TLS configuration of the host client. Only
+// plugins with a certificate for ServerName signed by the CA are accepted.
+func (f TLSFiles) ClientConfig() (*tls.Config, error) {
+	pool, cert, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+	serverName := f.ServerName
+	if serverName == "" {
+		serverName = DefaultTLSServerName
+	}
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      pool,
+		ServerName:   serverName,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// TLSProvider is the plugin.ServeConfig.TLSProvider of plugins. It serves
+// with the operator-supplied certificates passed by the host. Without
+// them, go-plugin falls back to automatic mutual TLS.
+func TLSProvider() (*tls.Config, error) {
+	files, ok := TLSFilesFromEnv()
+	if !ok {
+		return nil, nil
+	}
+	return files.ServerConfig()
+}
@@ -0,0 +1,36 @@
+package sdk
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTLSFilesFromEnv(t *testing.T) {
+	t.Setenv(EnvTLSCA, "")
+	_, ok := TLSFilesFromEnv()
+	assert.False(t, ok)
+
+	config, err := TLSProvider()
+	require.NoError(t, err)
+	assert.Nil(t, config, "falls back to automatic mutual TLS")
+
+	files := TLSFiles{CAFile: "/etc/maschine/ca.pem", CertFile: "/etc/maschine/plugin.pem", KeyFile: "/etc/maschine/plugin-key.pem"}
+	for _, kv := range files.Environ() {
+		k, v, _ := strings.Cut(kv, "=")
+		t.Setenv(k, v)
+	}
+	got, ok := TLSFilesFromEnv()
+	assert.True(t, ok)
+	assert.Equal(t, files, got)
+
+	_, err = TLSProvider()
+	assert.ErrorContains(t, err, "failed to read CA")
+}
+
+func TestTLSFilesRequired(t *testing.T) {
+	_, err := TLSFiles{CAFile: "/etc/maschine/ca.pem"}.ServerConfig()
+	assert.EqualError(t, err, "CA, certificate and key files are required")
+}
@@ -0,0 +1,332 @@
+package sdk
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	pluginv1 "maschine.io/plugin-sdk/proto/plugin/v1"
+)
+
+const (
+	// DefaultMaxMessageSize is the gRPC default limit for a single message
+	DefaultMaxMessageSize = 4 * 1024 * 1024
+
+	// DefaultChunkThreshold is the message size above which payloads are
+	// transferred in chunks
+	DefaultChunkThreshold = 3 * 1024 * 1024
+
+	// DefaultChunkSize is the size of a single chunk
+	DefaultChunkSize = 1024 * 1024
+
+	// DefaultMaxPayloadSize caps the size of a chunked payload
+	DefaultMaxPayloadSize = 256 * 1024 * 1024
+
+	// DefaultPayloadTTL is how long a transferred payload is kept until it
+	// is picked up
+	DefaultPayloadTTL = time.Minute
+)
+
+// ErrPayloadTooLarge is returned if a payload exceeds the maximum payload size
+var ErrPayloadTooLarge = errors.New("payload exceeds maximum size")
+
+// TransferConfig configures how requests and responses are transferred
+// between host and plugin. Requests and responses larger than Threshold are
+// transparently streamed in chunks. Zero values are replaced by the defaults.
+type TransferConfig struct {
+	// MaxMessageSize is the maximum size of a single gRPC message
+	MaxMessageSize int
+	// Threshold is the message size above which payloads are chunked
+	Threshold int
+	// ChunkSize is the size of a single chunk
+	ChunkSize int
+	// MaxPayloadSize is the maximum size of a chunked payload
+	MaxPayloadSize int64
+	// PayloadTTL is how long a transferred payload is kept until it is
+	// picked up by Execute or Download
+	PayloadTTL time.Duration
+}
+
+// withDefaults returns a copy of c with all zero values set to defaults
+func (c TransferConfig) withDefaults() TransferConfig {
+	if c.MaxMessageSize <= 0 {
+		c.MaxMessageSize = DefaultMaxMessageSize
+	}
+	if c.Threshold <= 0 {
+		c.Threshold = min(DefaultChunkThreshold, c.MaxMessageSize*3/4)
+	}
+	if c.ChunkSize <= 0 {
+		c.ChunkSize = min(DefaultChunkSize, c.Threshold)
+	}
+	if c.MaxPayloadSize <= 0 {
+		c.MaxPayloadSize = DefaultMaxPayloadSize
+	}
+	if c.PayloadTTL <= 0 {
+		c.PayloadTTL = DefaultPayloadTTL
+	}
+	return c
+}
+
+// ServerOptions returns the gRPC server options that apply the message size
+// limits. Use them in plugin.ServeConfig.GRPCServer.
+func (c TransferConfig) ServerOptions() []grpc.ServerOption {
+	c = c.withDefaults()
+	return []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(c.MaxMessageSize),
+		grpc.MaxSendMsgSize(c.MaxMessageSize),
+	}
+}
+
+// DialOptions returns the gRPC dial options that apply the message size
+// limits. Use them in plugin.ClientConfig.GRPCDialOptions.
+func (c TransferConfig) DialOptions() []grpc.DialOption {
+	c = c.withDefaults()
+	return []grpc.DialOption{
+		grpc.WithDefaultCallOptions(
+			grpc.MaxCallRecvMsgSize(c.MaxMessageSize),
+			grpc.MaxCallSendMsgSize(c.MaxMessageSize),
+		),
+	}
+}
+
+// GRPCServer returns a constructor for plugin.ServeConfig.GRPCServer that
+// applies the message size limits
+func (c TransferConfig) GRPCServer() func([]grpc.ServerOption) *grpc.Server {
+	return GRPCServer(c.ServerOptions()...)
+}
+
+// payloadStore keeps transferred payloads until they are picked up
+type payloadStore struct {
+	ttl time.Duration
+
+	mu       sync.Mutex
+	payloads map[string]storedPayload
+}
+
+type storedPayload struct {
+	data    []byte
+	expires time.Time
+}
+
+func newPayloadStore(ttl time.Duration) *payloadStore {
+	return &payloadStore{ttl: ttl, payloads: make(map[string]storedPayload)}
+}
+
+// put stores data and returns its ID. Expired payloads are removed.
+func (s *payloadStore) put(data []byte) (string, error) {
+	id, err := newID()
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for k, p := range s.payloads {
+		if now.After(p.expires) {
+			delete(s.payloads, k)
+		}
+	}
+	s.payloads[id] = storedPayload{data: data, expires: now.Add(s.ttl)}
+	return id, nil
+}
+
+// newID returns a random ID for payloads and sessions
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// take removes and returns the payload with the given ID
+func (s *payloadStore) take(id string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, found := s.payloads[id]
+	delete(s.payloads, id)
+	if !found || time.Now().After(p.expires) {
+		return nil, false
+	}
+	return p.data, true
+}
+
+// chunkSender is implemented by the upload and download streams
+type chunkSender interface {
+	Send(*pluginv1.PayloadChunk) error
+}
+
+// chunkReceiver is implemented by the upload and download streams
+type chunkReceiver interface {
+	Recv() (*pluginv1.PayloadChunk, error)
+}
+
+func sendChunks(s chunkSender, data []byte, chunkSize int) error {
+	for offset := 0; offset < len(data) || offset == 0; offset += chunkSize {
+		end := min(offset+chunkSize, len(data))
+		chunk := &pluginv1.PayloadChunk{Data: data[offset:end]}
+		if offset == 0 {
+			chunk.TotalSize = int64(len(data))
+		}
+		if err := s.Send(chunk); err != nil {
+			return err
+		}
+		if end == len(data) {
+			break
+		}
+	}
+	return nil
+}
+
+func receiveChunks(r chunkReceiver, maxSize int64) ([]byte, error) {
+	var data []byte
+	for {
+		chunk, err := r.Recv()
+		if err == io.EOF {
+			return data, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if chunk.TotalSize > maxSize || int64(len(data)+len(chunk.Data)) > maxSize {
+			return nil, fmt.Errorf("%w: limit is %d bytes", ErrPayloadTooLarge, maxSize)
+		}
+		if data == nil && chunk.TotalSize > 0 {
+			data = make([]byte, 0, chunk.TotalSize)
+		}
+		data = append(data, chunk.Data...)
+	}
+}
+
+// upload streams req to the plugin and returns the payload ID
+func (c *grpcClient) upload(ctx context.Context, req *pluginv1.ExecuteRequest) (string, error) {
+	data, err := proto.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+	if int64(len(data)) > c.transfer.MaxPayloadSize {
+		return "", fmt.Errorf("%w: request has %d bytes, limit is %d", ErrPayloadTooLarge, len(data), c.transfer.MaxPayloadSize)
+	}
+
+	stream, err := c.client.Upload(ctx)
+	if err != nil {
+		return "", err
+	}
+	if err := sendChunks(stream, data, c.transfer.ChunkSize); err != nil && err != io.EOF {
+		return "", err
+	}
+	resp, err := stream.CloseAndRecv()
+	if err != nil {
+		return "", err
+	}
+	return resp.PayloadId, nil
+}
+
+// download streams a response from the plugin
+func (c *grpcClient) download(ctx context.Context, id string) (*pluginv1.ExecuteResponse, error) {
+	stream, err := c.client.Download(ctx, &pluginv1.DownloadRequest{PayloadId: id})
+	if err != nil {
+		return nil, err
+	}
+	data, err := receiveChunks(stream, c.transfer.MaxPayloadSize)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp pluginv1.ExecuteResponse
+	if err := proto.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+	return &resp, nil
+}
+
+func (s *grpcServer) Upload(stream pluginv1.Plugin_UploadServer) error {
+	if !s.begin() {
+		return status.Error(codes.Unavailable, ErrShuttingDown.Error())
+	}
+	defer s.end()
+
+	data, err := receiveChunks(stream, s.transfer.MaxPayloadSize)
+	if errors.Is(err, ErrPayloadTooLarge) {
+		return status.Error(codes.ResourceExhausted, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+
+	id, err := s.payloads.put(data)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to store payload: %v", err)
+	}
+	return stream.SendAndClose(&pluginv1.UploadResponse{PayloadId: id})
+}
+
+func (s *grpcServer) Download(req *pluginv1.DownloadRequest, stream pluginv1.Plugin_DownloadServer) error {
+	data, found := s.payloads.take(req.PayloadId)
+	if !found {
+		return status.Errorf(codes.NotFound, "unknown payload: %s", req.PayloadId)
+	}
+	return sendChunks(stream, data, s.transfer.ChunkSize)
+}
+
+// resolveRequest replaces a request that only references an uploaded payload
+// with the uploaded request
+func (s *grpcServer) resolveRequest(req *pluginv1.ExecuteRequest) (*pluginv1.ExecuteRequest, error) {
+	if req.PayloadId == "" {
+		return req, nil
+	}
+
+	data, found := s.payloads.take(req.PayloadId)
+	if !found {
+		return nil, status.Errorf(codes.NotFound, "unknown payload: %s", req.PayloadId)
+	}
+
+	var uploaded pluginv1.ExecuteRequest
+	if err := proto.Unmarshal(data, &uploaded); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to decode payload: %v", err)
+	}
+	uploaded.PayloadId = ""
+	return &uploaded, nil
+}
+
+// offloadResponse stores a response larger than the threshold and returns a
+// response that only references it
+func (s *grpcServer) offloadResponse(resp *pluginv1.ExecuteResponse) (*pluginv1.ExecuteResponse, error) {
+	return s.offloadAbove(resp, s.transfer.Threshold)
+}
+
+// offloadAbove stores a response larger than limit and returns a response
+// that only references it
+func (s *grpcServer) offloadAbove(resp *pluginv1.ExecuteResponse, limit int) (*pluginv1.ExecuteResponse, error) {
+	size := proto.Size(resp)
+	if size <= limit {
+		return resp, nil
+	}
+	if int64(size) > s.transfer.MaxPayloadSize {
+		return &pluginv1.ExecuteResponse{
+			Error: fmt.Sprintf("%v: response has %d bytes, limit is %d", ErrPayloadTooLarge, size, s.transfer.MaxPayloadSize),
+		}, nil
+	}
+
+	data, err := proto.Marshal(resp)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to encode response: %v", err)
+	}
+	id, err := s.payloads.put(data)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to store payload: %v", err)
+	}
+	return &pluginv1.ExecuteResponse{PayloadId: id}, nil
+}
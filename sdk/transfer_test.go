@@ -0,0 +1,114 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newEchoPlugin returns the input as output, repeated by the "times" parameter
+func newEchoPlugin(t *testing.T) *BasePlugin {
+	t.Helper()
+
+	p := NewBasePlugin("echo-plugin", "1.0.0")
+	require.NoError(t, p.RegisterSimpleFunction("mrn:echo:payload:copy", func(ctx context.Context, req *TypedExecuteRequest) (any, error) {
+		times := 1
+		if _, err := req.GetParameter("times", &times); err != nil {
+			return nil, err
+		}
+		return bytes.Repeat(req.Input, times), nil
+	}, "Echo the input"))
+	return p
+}
+
+func TestChunkedTransfer(t *testing.T) {
+	client := dispenseTestPlugin(t, &MaschinePlugin{
+		Impl:     newEchoPlugin(t),
+		Transfer: TransferConfig{Threshold: 1024, ChunkSize: 100, MaxPayloadSize: 64 * 1024},
+	})
+
+	tests := []struct {
+		name      string
+		inputSize int
+		times     int
+	}{
+		{name: "small", inputSize: 10, times: 1},
+		{name: "large input", inputSize: 10 * 1024, times: 1},
+		{name: "large output", inputSize: 100, times: 300},
+		{name: "large input and output", inputSize: 8 * 1024, times: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := bytes.Repeat([]byte("x"), tt.inputSize)
+			resp, err := client.Execute(context.Background(), &ExecuteRequest{
+				Resource:   "mrn:echo:payload:copy",
+				Input:      input,
+				Parameters: map[string][]byte{"times": []byte(strconv.Itoa(tt.times))},
+			})
+			require.NoError(t, err)
+			require.Empty(t, resp.Error)
+			assert.Equal(t, bytes.Repeat(input, tt.times), resp.Output)
+		})
+	}
+
+	t.Run("request above payload cap", func(t *testing.T) {
+		_, err := client.Execute(context.Background(), &ExecuteRequest{
+			Resource: "mrn:echo:payload:copy",
+			Input:    bytes.Repeat([]byte("x"), 128*1024),
+		})
+		assert.ErrorIs(t, err, ErrPayloadTooLarge)
+	})
+
+	t.Run("response above payload cap", func(t *testing.T) {
+		resp, err := client.Execute(context.Background(), &ExecuteRequest{
+			Resource:   "mrn:echo:payload:copy",
+			Input:      bytes.Repeat([]byte("x"), 1024),
+			Parameters: map[string][]byte{"times": []byte("100")},
+		})
+		require.NoError(t, err)
+		assert.Contains(t, resp.Error, ErrPayloadTooLarge.Error())
+	})
+}
+
+func TestChunkedTransferAboveGRPCLimit(t *testing.T) {
+	if testing.Short() {
+		t.Skip("transfers more than 4 MB")
+	}
+
+	client := dispenseTestClient(t, newEchoPlugin(t))
+
+	input := bytes.Repeat([]byte("0123456789"), DefaultMaxMessageSize/10+1024)
+	resp, err := client.Execute(context.Background(), &ExecuteRequest{
+		Resource: "mrn:echo:payload:copy",
+		Input:    input,
+	})
+	require.NoError(t, err)
+	require.Empty(t, resp.Error)
+	assert.Equal(t, input, resp.Output)
+}
+
+func TestPayloadStore(t *testing.T) {
+	s := newPayloadStore(time.Minute)
+
+	id, err := s.put([]byte("data"))
+	require.NoError(t, err)
+
+	data, found := s.take(id)
+	assert.True(t, found)
+	assert.Equal(t, []byte("data"), data)
+
+	_, found = s.take(id)
+	assert.False(t, found, "payloads can only be taken once")
+
+	s.ttl = -time.Second
+	id, err = s.put([]byte("data"))
+	require.NoError(t, err)
+	_, found = s.take(id)
+	assert.False(t, found, "expired payloads are not returned")
+}
@@ -0,0 +1,88 @@
+package sdk
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// TypedExecuteRequest wraps an ExecuteRequest with helpers to decode its
+// payloads into Go values
+type TypedExecuteRequest struct {
+	*ExecuteRequest
+}
+
+// GetParameters decodes all parameters into v, which is usually a pointer
+// to a struct with json tags. Parameters are decoded with the codec of
+// their content type; values without content type that are not valid JSON
+// are treated as plain strings.
+func (r *TypedExecuteRequest) GetParameters(v any) error {
+	params := make(map[string]json.RawMessage, len(r.Parameters))
+	for name, data := range r.Parameters {
+		raw, err := parameterJSON(r.ParameterContentTypes[name], data)
+		if err != nil {
+			return fmt.Errorf("failed to decode parameter %s: %w", name, err)
+		}
+		params[name] = raw
+	}
+
+	data, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to decode parameters: %w", err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode parameters: %w", err)
+	}
+	return nil
+}
+
+// GetParameter decodes a single parameter into v. It returns false if the
+// parameter is not present.
+func (r *TypedExecuteRequest) GetParameter(name string, v any) (bool, error) {
+	data, found := r.Parameters[name]
+	if !found {
+		return false, nil
+	}
+
+	var err error
+	if contentType := r.ParameterContentTypes[name]; contentType != "" {
+		err = Decode(contentType, data, v)
+	} else {
+		var raw json.RawMessage
+		if raw, err = parameterJSON("", data); err == nil {
+			err = json.Unmarshal(raw, v)
+		}
+	}
+	if err != nil {
+		return true, fmt.Errorf("failed to decode parameter %s: %w", name, err)
+	}
+	return true, nil
+}
+
+// GetInput decodes the input into v with the codec of its content type,
+// JSON by default
+func (r *TypedExecuteRequest) GetInput(v any) error {
+	if len(r.Input) == 0 {
+		return nil
+	}
+	if err := Decode(r.ContentType, r.Input, v); err != nil {
+		return fmt.Errorf("failed to decode input: %w", err)
+	}
+	return nil
+}
+
+// parameterJSON converts a parameter of the content type to JSON. Without
+// content type, data is returned if it is valid JSON and encoded as JSON
+// string otherwise.
+func parameterJSON(contentType string, data []byte) (json.RawMessage, error) {
+	if contentType != "" && mediaType(contentType) != ContentTypeJSON {
+		var v any
+		if err := Decode(contentType, data, &v); err != nil {
+			return nil, err
+		}
+		return json.Marshal(v)
+	}
+	if json.Valid(data) {
+		return data, nil
+	}
+	return json.Marshal(string(data))
+}
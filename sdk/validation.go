@@ -0,0 +1,300 @@
+package sdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+
+	"maschine.io/plugin-sdk/sdk/manifest"
+)
+
+const (
+	// MetadataErrorType is the response metadata key that classifies an error
+	MetadataErrorType = "error_type"
+
+	// MetadataValidationErrors is the response metadata key that carries the
+	// JSON encoded validation errors
+	MetadataValidationErrors = "validation_errors"
+
+	// ErrorTypeValidation marks responses rejected by parameter validation
+	ErrorTypeValidation = "validation"
+)
+
+// ValidateParameters validates params against the parameters declared by the
+// resource definition. It returns a copy of params with declared defaults
+// filled in. All violations are returned together as manifest.ValidationErrors
+// with fields named "parameters.<name>". Parameters the resource does not
+// declare are passed through unchecked, hosts may send more than a plugin
+// version knows about.
+//
+// Values that are not valid JSON are treated as plain strings. As in the
+// manifest, a zero Minimum, Maximum, MinLength or MaxLength means unset.
+func ValidateParameters(def *manifest.ResourceDef, params map[string][]byte) (map[string][]byte, error) {
+	var errors manifest.ValidationErrors
+
+	result := make(map[string][]byte, len(params)+len(def.Parameters))
+	declared := make(map[string]bool, len(def.Parameters))
+
+	for _, p := range def.Parameters {
+		declared[p.Name] = true
+		field := "parameters." + p.Name
+
+		data, found := params[p.Name]
+		if !found {
+			switch {
+			case p.Required:
+				errors = append(errors, manifest.ValidationError{Field: field, Message: "is required"})
+			case p.Default != nil:
+				value, err := json.Marshal(p.Default)
+				if err != nil {
+					errors = append(errors, manifest.ValidationError{
+						Field:   field,
+						Message: fmt.Sprintf("invalid default value: %v", err),
+					})
+					continue
+				}
+				result[p.Name] = value
+			}
+			continue
+		}
+
+		result[p.Name] = data
+		errors = append(errors, validateValue(field, p.Type, decodeParameter(data, p.Type), &p)...)
+	}
+
+	for name, data := range params {
+		if !declared[name] {
+			result[name] = data
+		}
+	}
+
+	if len(errors) > 0 {
+		return nil, errors
+	}
+	return result, nil
+}
+
+// WithParameterValidation wraps impl so that the parameters of every Execute
+// and Plan call are validated against the resource definitions in m before
+// impl runs. Requests for resources not declared in m, and parameters the
+// resource does not declare, are passed through.
+//
+// The returned resource implements ManifestProvider with m. A rejected
+// request is answered with an ExecuteResponse whose Error lists
+// every offending field; ValidationErrorsFromResponse recovers the
+// structured errors on the host.
+func WithParameterValidation(impl MaschineResource, m *manifest.PluginManifest) MaschineResource {
+	resources := make(map[string]*manifest.ResourceDef, len(m.Resources))
+	for i := range m.Resources {
+		resources[m.Resources[i].Type] = &m.Resources[i]
+	}
+	return &validatingResource{MaschineResource: impl, manifest: m, resources: resources}
+}
+
+type validatingResource struct {
+	MaschineResource
+	manifest  *manifest.PluginManifest
+	resources map[string]*manifest.ResourceDef
+}
+
+// Unwrap returns the wrapped resource
+func (r *validatingResource) Unwrap() MaschineResource {
+	return r.MaschineResource
+}
+
+// GetManifest returns the manifest the parameters are validated against
+func (r *validatingResource) GetManifest(ctx context.Context) (*manifest.PluginManifest, error) {
+	return r.manifest, nil
+}
+
+func (r *validatingResource) Execute(ctx context.Context, req *ExecuteRequest) (*ExecuteResponse, error) {
+	def, found := r.resources[req.Resource]
+	if !found {
+		return r.MaschineResource.Execute(ctx, req)
+	}
+
+	params, err := ValidateParameters(def, req.Parameters)
+	if err != nil {
+		return validationErrorResponse(err), nil
+	}
+
+	validated := *req
+	validated.Parameters = params
+	return r.MaschineResource.Execute(ctx, &validated)
+}
+
+// Plan validates the parameters of req like Execute before the wrapped
+// resource plans the execution. Rejected requests fail with
+// manifest.ValidationErrors.
+func (r *validatingResource) Plan(ctx context.Context, req *ExecuteRequest) ([]Change, error) {
+	planner, ok := lookup[Planner](r.MaschineResource)
+	if !ok {
+		return nil, ErrDryRunNotSupported
+	}
+	def, found := r.resources[req.Resource]
+	if !found {
+		return planner.Plan(ctx, req)
+	}
+
+	params, err := ValidateParameters(def, req.Parameters)
+	if err != nil {
+		return nil, err
+	}
+
+	validated := *req
+	validated.Parameters = params
+	return planner.Plan(ctx, &validated)
+}
+
+// ValidationErrorsFromResponse returns the validation errors carried by a
+// response that was rejected by parameter validation or whose output was
+// rejected by output validation, see OutputErrorResponse
+func ValidationErrorsFromResponse(resp *ExecuteResponse) (manifest.ValidationErrors, bool) {
+	if resp == nil {
+		return nil, false
+	}
+	if t := resp.Metadata[MetadataErrorType]; t != ErrorTypeValidation && t != ErrorTypeInvalidOutput {
+		return nil, false
+	}
+
+	var errors manifest.ValidationErrors
+	if err := json.Unmarshal([]byte(resp.Metadata[MetadataValidationErrors]), &errors); err != nil {
+		return nil, false
+	}
+	return errors, true
+}
+
+func validationErrorResponse(err error) *ExecuteResponse {
+	resp := &ExecuteResponse{
+		Error:    err.Error(),
+		Metadata: map[string]string{MetadataErrorType: ErrorTypeValidation},
+	}
+	if errors, ok := err.(manifest.ValidationErrors); ok {
+		if data, err := json.Marshal(errors); err == nil {
+			resp.Metadata[MetadataValidationErrors] = string(data)
+		}
+	}
+	return resp
+}
+
+// decodeParameter decodes a parameter value. Values that are not valid JSON,
+// and values of string parameters that do not decode to a string, are
+// returned as plain strings.
+func decodeParameter(data []byte, typ string) any {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return string(data)
+	}
+	if _, ok := value.(string); !ok && typ == "string" {
+		return string(data)
+	}
+	return value
+}
+
+func validateValue(field, typ string, value any, p *manifest.Parameter) manifest.ValidationErrors {
+	if !hasType(value, typ) {
+		return manifest.ValidationErrors{{
+			Field:   field,
+			Message: fmt.Sprintf("must be of type %s", typ),
+		}}
+	}
+
+	var errors manifest.ValidationErrors
+	add := func(format string, args ...any) {
+		errors = append(errors, manifest.ValidationError{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	switch v := value.(type) {
+	case string:
+		length := utf8.RuneCountInString(v)
+		if p.MinLength > 0 && length < p.MinLength {
+			add("must be at least %d characters long", p.MinLength)
+		}
+		if p.MaxLength > 0 && length > p.MaxLength {
+			add("must be at most %d characters long", p.MaxLength)
+		}
+		if p.Pattern != "" {
+			re, err := regexp.Compile(p.Pattern)
+			if err != nil {
+				add("invalid pattern %q in manifest: %v", p.Pattern, err)
+			} else if !re.MatchString(v) {
+				add("must match pattern %s", p.Pattern)
+			}
+		}
+	case float64:
+		if p.Minimum != 0 && v < p.Minimum {
+			add("must be >= %v", p.Minimum)
+		}
+		if p.Maximum != 0 && v > p.Maximum {
+			add("must be <= %v", p.Maximum)
+		}
+	case []any:
+		if p.Items != nil && p.Items.Type != "" {
+			for i, item := range v {
+				if !hasType(item, p.Items.Type) {
+					errors = append(errors, manifest.ValidationError{
+						Field:   fmt.Sprintf("%s[%d]", field, i),
+						Message: fmt.Sprintf("must be of type %s", p.Items.Type),
+					})
+				}
+			}
+		}
+	}
+
+	if len(p.Enum) > 0 && !inEnum(value, p.Enum) {
+		add("must be one of %v", p.Enum)
+	}
+
+	return errors
+}
+
+func hasType(value any, typ string) bool {
+	switch typ {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "number":
+		_, ok := value.(float64)
+		return ok
+	case "integer":
+		v, ok := value.(float64)
+		return ok && v == math.Trunc(v)
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "array":
+		_, ok := value.([]any)
+		return ok
+	case "object":
+		_, ok := value.(map[string]any)
+		return ok
+	default:
+		// unknown or empty types are not checked
+		return true
+	}
+}
+
+func inEnum(value any, enum []string) bool {
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = v
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		s = strconv.FormatBool(v)
+	default:
+		return false
+	}
+
+	for _, e := range enum {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
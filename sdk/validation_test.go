@@ -0,0 +1,132 @@
+package sdk
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"maschine.io/plugin-sdk/sdk/manifest"
+)
+
+func sendMailResource() manifest.ResourceDef {
+	return manifest.ResourceDef{
+		Type:        "mrn:mail:smtp:send",
+		Name:        "Send Mail",
+		Description: "Send an email",
+		Category:    "action",
+		Parameters: []manifest.Parameter{
+			{Name: "to", Type: "string", Required: true, Description: "Recipient", Pattern: `^[^@]+@[^@]+$`},
+			{Name: "subject", Type: "string", Required: true, Description: "Subject", MaxLength: 10},
+			{Name: "priority", Type: "string", Description: "Priority", Enum: []string{"low", "high"}, Default: "low"},
+			{Name: "retries", Type: "integer", Description: "Retries", Minimum: 1, Maximum: 5, Default: 3},
+			{Name: "cc", Type: "array", Description: "CC", Items: &manifest.Items{Type: "string"}},
+			{Name: "html", Type: "boolean", Description: "HTML body"},
+		},
+	}
+}
+
+func TestValidateParameters(t *testing.T) {
+	def := sendMailResource()
+
+	t.Run("valid with defaults", func(t *testing.T) {
+		params, err := ValidateParameters(&def, map[string][]byte{
+			"to":      []byte(`"a@b.io"`),
+			"subject": []byte("hello"),
+			"cc":      []byte(`["c@d.io"]`),
+			"bcc":     []byte(`"x@y.io"`),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, `"x@y.io"`, string(params["bcc"]), "undeclared parameters are passed through")
+		assert.Equal(t, `"low"`, string(params["priority"]))
+		assert.Equal(t, `3`, string(params["retries"]))
+		assert.Equal(t, "hello", string(params["subject"]))
+		assert.NotContains(t, params, "html")
+	})
+
+	t.Run("lists every offending field", func(t *testing.T) {
+		_, err := ValidateParameters(&def, map[string][]byte{
+			"to":       []byte(`"not-an-email"`),
+			"priority": []byte(`"urgent"`),
+			"retries":  []byte(`2.5`),
+			"cc":       []byte(`["c@d.io", 42]`),
+			"html":     []byte(`"yes"`),
+		})
+		require.Error(t, err)
+
+		errs, ok := err.(manifest.ValidationErrors)
+		require.True(t, ok)
+
+		fields := make(map[string]string)
+		for _, e := range errs {
+			fields[e.Field] = e.Message
+		}
+		assert.Equal(t, "must match pattern ^[^@]+@[^@]+$", fields["parameters.to"])
+		assert.Equal(t, "is required", fields["parameters.subject"])
+		assert.Equal(t, "must be one of [low high]", fields["parameters.priority"])
+		assert.Equal(t, "must be of type integer", fields["parameters.retries"])
+		assert.Equal(t, "must be of type string", fields["parameters.cc[1]"])
+		assert.Equal(t, "must be of type boolean", fields["parameters.html"])
+		assert.Len(t, errs, 6)
+	})
+
+	t.Run("length and range", func(t *testing.T) {
+		_, err := ValidateParameters(&def, map[string][]byte{
+			"to":      []byte(`"a@b.io"`),
+			"subject": []byte(`"much too long subject"`),
+			"retries": []byte(`9`),
+		})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "parameters.subject: must be at most 10 characters long")
+		assert.Contains(t, err.Error(), "parameters.retries: must be <= 5")
+	})
+}
+
+func testMailManifest() *manifest.PluginManifest {
+	m := manifest.New("mail-plugin", "io.maschine.plugins.mail")
+	m.Plugin.Version = "1.0.0"
+	m.Plugin.Description = "Mail plugin"
+	m.Resources = []manifest.ResourceDef{sendMailResource()}
+	return m
+}
+
+func TestWithParameterValidation(t *testing.T) {
+	m := testMailManifest()
+
+	var called *ExecuteRequest
+	p := NewBasePlugin("mail-plugin", "1.0.0")
+	require.NoError(t, p.RegisterSimpleFunction("mrn:mail:smtp:send", func(ctx context.Context, req *TypedExecuteRequest) (any, error) {
+		called = req.ExecuteRequest
+		return "sent", nil
+	}, "Send an email"))
+
+	res := WithParameterValidation(p, m)
+
+	t.Run("rejected before handler runs", func(t *testing.T) {
+		resp, err := res.Execute(context.Background(), &ExecuteRequest{Resource: "mrn:mail:smtp:send"})
+		require.NoError(t, err)
+		assert.Nil(t, called)
+		assert.Contains(t, resp.Error, "parameters.to: is required")
+
+		errs, ok := ValidationErrorsFromResponse(resp)
+		require.True(t, ok)
+		assert.Equal(t, manifest.ValidationErrors{
+			{Field: "parameters.to", Message: "is required"},
+			{Field: "parameters.subject", Message: "is required"},
+		}, errs)
+	})
+
+	t.Run("defaults passed to handler", func(t *testing.T) {
+		resp, err := res.Execute(context.Background(), &ExecuteRequest{
+			Resource:   "mrn:mail:smtp:send",
+			Parameters: map[string][]byte{"to": []byte(`"a@b.io"`), "subject": []byte(`"hi"`)},
+		})
+		require.NoError(t, err)
+		assert.Empty(t, resp.Error)
+		require.NotNil(t, called)
+		assert.Equal(t, `"low"`, string(called.Parameters["priority"]))
+
+		_, ok := ValidationErrorsFromResponse(resp)
+		assert.False(t, ok)
+	})
+}
@@ -0,0 +1,24 @@
+package sdk
+
+// wrapper is implemented by middlewares that wrap a MaschineResource
+type wrapper interface {
+	Unwrap() MaschineResource
+}
+
+// lookup returns the first resource in the middleware chain of res that
+// implements T, starting with res itself
+func lookup[T any](res MaschineResource) (T, bool) {
+	for res != nil {
+		if t, ok := res.(T); ok {
+			return t, true
+		}
+		w, ok := res.(wrapper)
+		if !ok {
+			break
+		}
+		res = w.Unwrap()
+	}
+
+	var zero T
+	return zero, false
+}